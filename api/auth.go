@@ -36,7 +36,7 @@ func (a Auth) router(server *Server) {
 	a.server = server
 
 	serverGroup := server.router.Group("/auth")
-	serverGroup.POST("/register", a.register)
+	serverGroup.POST("/register", IdempotencyMiddleware(), a.register)
 	serverGroup.POST("/login", a.login)
 }
 
@@ -198,7 +198,7 @@ func (a Auth) login(ctx *gin.Context) {
 		return
 	}
 
-	access_token, err := tokenManager.CreateToken(dbUser.ID, false, 30)
+	access_token, err := tokenManager.CreateToken(dbUser.ID, dbUser.Role, 30)
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
@@ -215,6 +215,7 @@ func (a Auth) login(ctx *gin.Context) {
 		Phone:      dbUser.Phone,
 		Address:    dbUser.Address,
 		IsLoggedIn: true,
+		IsAdmin:    dbUser.Role == utils.AdminRole,
 		CreatedAt:  dbUser.CreatedAt,
 		UpdatedAt:  dbUser.UpdatedAt,
 	}
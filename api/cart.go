@@ -0,0 +1,798 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// cartTTL is how long an untouched cart survives. Every change pushes the
+// expiry forward again.
+const cartTTL = 7 * 24 * time.Hour
+
+const maxCartLines = 50
+
+// maxCartShops limits how many shops one checkout can be split across. Every
+// extra shop is another pickup for the rider.
+const maxCartShops = 3
+
+var (
+	errCartLineNotFound = errors.New("cart item not found")
+	errCartTooManyShops = errors.New("cart holds items from too many shops")
+	errCartFull         = errors.New("cart is full")
+	errCartBusy         = errors.New("cart is being updated elsewhere, try again")
+)
+
+type Cart struct {
+	server *Server
+}
+
+// CartItem is a line as stored in redis. Prices are deliberately not stored:
+// they are looked up every time the cart is read so the customer always sees
+// what the shop currently charges.
+type CartItem struct {
+	ID        string   `json:"id"`
+	ProductID string   `json:"product_id"`
+	ShopID    string   `json:"shop_id"`
+	Quantity  int32    `json:"quantity"`
+	OptionIDs []string `json:"option_ids"`
+	Note      string   `json:"note"`
+}
+
+// storedCart may hold lines from several shops; checkout splits it into an
+// order per shop.
+type storedCart struct {
+	Items      []CartItem `json:"items"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	CouponCode string     `json:"coupon_code,omitempty"`
+
+	// ShopID is only found on carts saved before each line carried its own
+	// shop. readCart moves it onto the lines.
+	ShopID string `json:"shop_id,omitempty"`
+}
+
+type AddCartItemParams struct {
+	ProductID string   `json:"product_id" binding:"required"`
+	Quantity  int32    `json:"quantity" binding:"required,min=1,max=99"`
+	OptionIDs []string `json:"option_ids" binding:"omitempty,max=20,dive,required"`
+	Note      string   `json:"note" binding:"max=200"`
+}
+
+type UpdateCartItemParams struct {
+	Quantity int32   `json:"quantity" binding:"required,min=1,max=99"`
+	Note     *string `json:"note" binding:"omitempty,max=200"`
+}
+
+type CartLineOption struct {
+	ID         string      `json:"id"`
+	GroupName  string      `json:"group_name"`
+	Name       string      `json:"name"`
+	PriceDelta utils.Money `json:"price_delta"`
+}
+
+type CartLine struct {
+	ID          string           `json:"id"`
+	ProductID   string           `json:"product_id"`
+	ShopID      string           `json:"shop_id"`
+	Name        string           `json:"name"`
+	ImageUrl    string           `json:"image_url"`
+	Quantity    int32            `json:"quantity"`
+	Options     []CartLineOption `json:"options"`
+	Note        string           `json:"note"`
+	UnitPrice   utils.Money      `json:"unit_price"`
+	LineTotal   utils.Money      `json:"line_total"`
+	VAT         utils.Money      `json:"vat"`
+	VATExempt   bool             `json:"vat_exempt"`
+	IsAvailable bool             `json:"is_available"`
+	Issue       string           `json:"issue,omitempty"`
+
+	category string
+}
+
+// CartShop is one shop's share of a cart, which becomes one order at
+// checkout. Total is before delivery and Discount, the shop's share of the
+// coupon.
+type CartShop struct {
+	ShopID                   string      `json:"shop_id"`
+	ShopName                 string      `json:"shop_name"`
+	ShopIsOpen               bool        `json:"shop_is_open"`
+	ItemCount                int32       `json:"item_count"`
+	Subtotal                 utils.Money `json:"subtotal"`
+	ServiceChargeBasisPoints int32       `json:"service_charge_basis_points"`
+	ServiceCharge            utils.Money `json:"service_charge"`
+	VAT                      utils.Money `json:"vat"`
+	Total                    utils.Money `json:"total"`
+	Discount                 utils.Money `json:"discount"`
+
+	tax utils.TaxBreakdown
+}
+
+// CartResponse is a priced cart. Total is after the coupon's Discount.
+type CartResponse struct {
+	Shops         []CartShop  `json:"shops"`
+	Lines         []CartLine  `json:"lines"`
+	ItemCount     int32       `json:"item_count"`
+	Subtotal      utils.Money `json:"subtotal"`
+	ServiceCharge utils.Money `json:"service_charge"`
+	VAT           utils.Money `json:"vat"`
+	Discount      utils.Money `json:"discount"`
+	Total         utils.Money `json:"total"`
+	Coupon        *CartCoupon `json:"coupon"`
+	IsValid       bool        `json:"is_valid"`
+	Issues        []string    `json:"issues"`
+	ExpiresAt     *time.Time  `json:"expires_at"`
+}
+
+func (c Cart) router(server *Server) {
+	c.server = server
+
+	serverGroup := server.router.Group("/cart", AuthenticatedMiddleware())
+	serverGroup.GET("", c.getCart)
+	serverGroup.DELETE("", c.clearCart)
+	serverGroup.POST("/items", IdempotencyMiddleware(), c.addCartItem)
+	serverGroup.PUT("/items/:line_id", c.updateCartItem)
+	serverGroup.DELETE("/items/:line_id", c.removeCartItem)
+	serverGroup.GET("/delivery_quote", c.deliveryQuote)
+	serverGroup.POST("/coupon", c.applyCoupon)
+	serverGroup.DELETE("/coupon", c.removeCoupon)
+}
+
+func (c *Cart) getCart(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	cart, err := loadCart(context.Background(), cartKey(userId))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	c.respond(ctx, http.StatusOK, "cart fetched successfully", cart)
+}
+
+func (c *Cart) addCartItem(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := AddCartItemParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	item, _, ok := c.server.newCartItem(ctx, input, true)
+	if !ok {
+		return
+	}
+
+	cart, err := updateCart(context.Background(), cartKey(userId), func(cart *storedCart) error {
+		return addCartLine(cart, item)
+	})
+	if !cartError(ctx, err) {
+		return
+	}
+
+	c.respond(ctx, http.StatusCreated, "item added to cart", cart)
+}
+
+// newCartItem checks that a product and its options can be ordered and builds
+// the cart line for them. It writes the error response itself. requireOpen is
+// false for carts that are only checked out later.
+func (s *Server) newCartItem(ctx *gin.Context, input AddCartItemParams, requireOpen bool) (CartItem, string, bool) {
+	item := CartItem{}
+
+	optionIds := utils.Dedupe(input.OptionIDs)
+	sort.Strings(optionIds)
+
+	products, err := s.queries.ListProductsByIDs(context.Background(), []string{input.ProductID})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return item, "", false
+	}
+	if len(products) == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"message":    "The requested product does not exist.",
+		})
+		return item, "", false
+	}
+	product := products[0]
+
+	if !product.IsAvailable {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    "This product is currently unavailable.",
+		})
+		return item, "", false
+	}
+
+	shop, err := s.queries.GetShop(context.Background(), product.ShopID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return item, "", false
+	}
+	if requireOpen && !shop.IsOpen {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    "This shop is currently closed.",
+		})
+		return item, "", false
+	}
+
+	if len(optionIds) > 0 {
+		options, err := s.queries.ListProductOptionsByIDs(context.Background(), optionIds)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"Error": err.Error(),
+			})
+			return item, "", false
+		}
+
+		found := map[string]db.ProductOption{}
+		for _, option := range options {
+			found[option.ID] = option
+		}
+		for _, id := range optionIds {
+			option, ok := found[id]
+			if !ok || option.ProductID != product.ID {
+				ctx.JSON(http.StatusBadRequest, gin.H{
+					"statusCode": http.StatusBadRequest,
+					"message":    fmt.Sprintf("Option %s does not belong to this product.", id),
+				})
+				return item, "", false
+			}
+			if !option.IsAvailable {
+				ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+					"statusCode": http.StatusUnprocessableEntity,
+					"message":    fmt.Sprintf("%s is currently unavailable.", option.Name),
+				})
+				return item, "", false
+			}
+		}
+	}
+
+	lineId, err := utils.NewID()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Failed to generate CUID": err.Error(),
+		})
+		return item, "", false
+	}
+
+	item = CartItem{
+		ID:        lineId,
+		ProductID: product.ID,
+		ShopID:    product.ShopID,
+		Quantity:  input.Quantity,
+		OptionIDs: optionIds,
+		Note:      strings.TrimSpace(input.Note),
+	}
+	return item, product.ShopID, true
+}
+
+// addCartLine puts item into cart. Adding the same product with the same
+// options and note again just bumps the quantity of the existing line.
+func addCartLine(cart *storedCart, item CartItem) error {
+	shops := map[string]bool{item.ShopID: true}
+	for _, existing := range cart.Items {
+		shops[existing.ShopID] = true
+	}
+	if len(shops) > maxCartShops {
+		return errCartTooManyShops
+	}
+
+	for i, existing := range cart.Items {
+		if existing.ProductID == item.ProductID && existing.Note == item.Note && sameOptions(existing.OptionIDs, item.OptionIDs) {
+			cart.Items[i].Quantity += item.Quantity
+			if cart.Items[i].Quantity > 99 {
+				cart.Items[i].Quantity = 99
+			}
+			return nil
+		}
+	}
+
+	if len(cart.Items) >= maxCartLines {
+		return errCartFull
+	}
+
+	cart.Items = append(cart.Items, item)
+	return nil
+}
+
+func (c *Cart) updateCartItem(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := UpdateCartItemParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	lineId := ctx.Param("line_id")
+
+	cart, err := updateCart(context.Background(), cartKey(userId), func(cart *storedCart) error {
+		for i, item := range cart.Items {
+			if item.ID == lineId {
+				cart.Items[i].Quantity = input.Quantity
+				if input.Note != nil {
+					cart.Items[i].Note = strings.TrimSpace(*input.Note)
+				}
+				return nil
+			}
+		}
+		return errCartLineNotFound
+	})
+	if !cartError(ctx, err) {
+		return
+	}
+
+	c.respond(ctx, http.StatusAccepted, "cart item updated successfully", cart)
+}
+
+func (c *Cart) removeCartItem(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	lineId := ctx.Param("line_id")
+
+	cart, err := updateCart(context.Background(), cartKey(userId), func(cart *storedCart) error {
+		for i, item := range cart.Items {
+			if item.ID == lineId {
+				cart.Items = append(cart.Items[:i], cart.Items[i+1:]...)
+				return nil
+			}
+		}
+		return errCartLineNotFound
+	})
+	if !cartError(ctx, err) {
+		return
+	}
+
+	c.respond(ctx, http.StatusAccepted, "cart item removed successfully", cart)
+}
+
+func (c *Cart) clearCart(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	if err := clearCart(context.Background(), cartKey(userId)); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "cart cleared successfully",
+	})
+}
+
+func (c *Cart) respond(ctx *gin.Context, status int, message string, cart storedCart) {
+	response, err := c.server.priceCart(context.Background(), cart)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	userId, _ := currentUserID(ctx)
+	if _, _, err := c.server.discountCart(context.Background(), userId, cart.CouponCode, &response, nil); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(status, gin.H{
+		"statusCode": status,
+		"status":     "success",
+		"message":    message,
+		"data":       response,
+	})
+}
+
+// priceCart resolves every line against the current products, options and
+// shops, and adds the totals up in kobo, per shop and overall, with each
+// shop's service charge and VAT. Lines that can no longer be bought are
+// kept, flagged and left out of the subtotal so the customer can fix them.
+func (s *Server) priceCart(ctx context.Context, cart storedCart) (CartResponse, error) {
+	response := CartResponse{
+		Shops:         []CartShop{},
+		Lines:         []CartLine{},
+		Subtotal:      utils.Kobo(0),
+		ServiceCharge: utils.Kobo(0),
+		VAT:           utils.Kobo(0),
+		Discount:      utils.Kobo(0),
+		Total:         utils.Kobo(0),
+		Issues:        []string{},
+	}
+
+	if len(cart.Items) == 0 {
+		return response, nil
+	}
+
+	expiresAt := cart.UpdatedAt.Add(cartTTL)
+	response.ExpiresAt = &expiresAt
+
+	productIds := []string{}
+	optionIds := []string{}
+	for _, item := range cart.Items {
+		productIds = append(productIds, item.ProductID)
+		optionIds = append(optionIds, item.OptionIDs...)
+	}
+
+	products, err := s.queries.ListProductsByIDs(ctx, utils.Dedupe(productIds))
+	if err != nil {
+		return response, err
+	}
+	productsById := map[string]db.Product{}
+	for _, product := range products {
+		productsById[product.ID] = product
+	}
+
+	optionsById := map[string]db.ProductOption{}
+	if len(optionIds) > 0 {
+		options, err := s.queries.ListProductOptionsByIDs(ctx, utils.Dedupe(optionIds))
+		if err != nil {
+			return response, err
+		}
+		for _, option := range options {
+			optionsById[option.ID] = option
+		}
+	}
+
+	// Shops are listed in the order they were first added to the cart.
+	shopIndex := map[string]int{}
+	for _, item := range cart.Items {
+		if _, ok := shopIndex[item.ShopID]; ok {
+			continue
+		}
+
+		shop, err := s.queries.GetShop(ctx, item.ShopID)
+		if err == sql.ErrNoRows {
+			shop = db.Shop{ID: item.ShopID}
+		} else if err != nil {
+			return response, err
+		}
+
+		shopIndex[item.ShopID] = len(response.Shops)
+		response.Shops = append(response.Shops, CartShop{
+			ShopID:                   item.ShopID,
+			ShopName:                 shop.Name,
+			ShopIsOpen:               shop.IsOpen,
+			ServiceChargeBasisPoints: shop.ServiceChargeBasisPoints,
+			Discount:                 utils.Kobo(0),
+		})
+		if shop.Name != "" && !shop.IsOpen {
+			response.Issues = append(response.Issues, fmt.Sprintf("%s is currently closed.", shop.Name))
+		}
+	}
+
+	for _, item := range cart.Items {
+		cartShop := &response.Shops[shopIndex[item.ShopID]]
+		line := CartLine{
+			ID:          item.ID,
+			ProductID:   item.ProductID,
+			ShopID:      item.ShopID,
+			Quantity:    item.Quantity,
+			Options:     []CartLineOption{},
+			Note:        item.Note,
+			IsAvailable: true,
+		}
+
+		product, ok := productsById[item.ProductID]
+		switch {
+		case !ok:
+			line.IsAvailable = false
+			line.Issue = "This product no longer exists."
+		case cartShop.ShopName == "":
+			line.IsAvailable = false
+			line.Issue = "This shop no longer exists."
+		case product.ShopID != item.ShopID:
+			line.IsAvailable = false
+			line.Issue = "This product has moved to another shop."
+		case !product.IsAvailable:
+			line.IsAvailable = false
+			line.Issue = "This product is currently unavailable."
+		}
+
+		if ok {
+			line.Name = product.Name
+			line.category = product.Category
+			if len(product.ImageUrls) > 0 {
+				line.ImageUrl = product.ImageUrls[0]
+			}
+
+			line.UnitPrice = product.Price
+		}
+
+		for _, id := range item.OptionIDs {
+			option, found := optionsById[id]
+			if !found || option.ProductID != item.ProductID {
+				line.IsAvailable = false
+				line.Issue = "One of the selected options no longer exists."
+				continue
+			}
+			if !option.IsAvailable && line.IsAvailable {
+				line.IsAvailable = false
+				line.Issue = fmt.Sprintf("%s is currently unavailable.", option.Name)
+			}
+
+			line.UnitPrice = line.UnitPrice.Add(option.PriceDelta)
+			line.Options = append(line.Options, CartLineOption{
+				ID:         option.ID,
+				GroupName:  option.GroupName,
+				Name:       option.Name,
+				PriceDelta: option.PriceDelta,
+			})
+		}
+
+		line.LineTotal = line.UnitPrice.Mul(int64(line.Quantity))
+
+		if line.IsAvailable {
+			cartShop.Subtotal = cartShop.Subtotal.Add(line.LineTotal)
+			cartShop.ItemCount += line.Quantity
+			response.Subtotal = response.Subtotal.Add(line.LineTotal)
+			response.ItemCount += line.Quantity
+		} else {
+			name := line.Name
+			if name == "" {
+				name = "An item"
+			}
+			response.Issues = append(response.Issues, fmt.Sprintf("%s: %s", name, line.Issue))
+		}
+
+		response.Lines = append(response.Lines, line)
+	}
+
+	s.taxCart(&response, productsById)
+	response.IsValid = len(response.Issues) == 0
+	return response, nil
+}
+
+// taxCart works out each shop's service charge and VAT from the lines that
+// can be bought, and the VAT on each of those lines.
+func (s *Server) taxCart(response *CartResponse, productsById map[string]db.Product) {
+	for i := range response.Shops {
+		shop := &response.Shops[i]
+
+		indexes := []int{}
+		lines := []utils.TaxLine{}
+		for j, line := range response.Lines {
+			if line.ShopID != shop.ShopID || !line.IsAvailable {
+				continue
+			}
+			indexes = append(indexes, j)
+			lines = append(lines, utils.TaxLine{
+				Amount:   line.LineTotal,
+				Category: productsById[line.ProductID].Category,
+			})
+		}
+
+		shop.tax = s.tax.Compute(lines, int64(shop.ServiceChargeBasisPoints))
+		for k, j := range indexes {
+			response.Lines[j].VAT = shop.tax.Lines[k].VAT
+			response.Lines[j].VATExempt = shop.tax.Lines[k].Exempt
+		}
+
+		shop.ServiceCharge = shop.tax.ServiceCharge
+		shop.VAT = shop.tax.VAT
+		shop.Total = shop.tax.Total
+
+		response.ServiceCharge = response.ServiceCharge.Add(shop.ServiceCharge)
+		response.VAT = response.VAT.Add(shop.VAT)
+		response.Total = response.Total.Add(shop.Total)
+	}
+}
+
+// orderable reports whether the cart can be turned into orders. A shop that
+// is closed right now only blocks orders wanted right now.
+func (c CartResponse) orderable(scheduled bool) bool {
+	if len(c.Lines) == 0 {
+		return false
+	}
+	for _, line := range c.Lines {
+		if !line.IsAvailable {
+			return false
+		}
+	}
+	return c.shopsOpen() || scheduled
+}
+
+// shopsOpen reports whether every shop in the cart is open right now.
+func (c CartResponse) shopsOpen() bool {
+	for _, shop := range c.Shops {
+		if !shop.ShopIsOpen {
+			return false
+		}
+	}
+	return true
+}
+
+// shopLines returns the lines of the cart that belong to one shop.
+func (c CartResponse) shopLines(shopId string) []CartLine {
+	lines := []CartLine{}
+	for _, line := range c.Lines {
+		if line.ShopID == shopId {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func cartKey(userId string) string {
+	return "cart:" + userId
+}
+
+func loadCart(ctx context.Context, key string) (storedCart, error) {
+	return readCart(ctx, Rdb, key)
+}
+
+func readCart(ctx context.Context, rdb redis.Cmdable, key string) (storedCart, error) {
+	cart := storedCart{Items: []CartItem{}}
+
+	raw, err := rdb.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return cart, nil
+	} else if err != nil {
+		return cart, err
+	}
+
+	if err := json.Unmarshal(raw, &cart); err != nil {
+		return cart, err
+	}
+
+	if cart.ShopID != "" {
+		for i := range cart.Items {
+			if cart.Items[i].ShopID == "" {
+				cart.Items[i].ShopID = cart.ShopID
+			}
+		}
+		cart.ShopID = ""
+	}
+	return cart, nil
+}
+
+// updateCart applies fn to the cart stored at key under an optimistic lock,
+// so two tabs adding items at the same time cannot overwrite each other.
+func updateCart(ctx context.Context, key string, fn func(*storedCart) error) (storedCart, error) {
+	var cart storedCart
+
+	txf := func(tx *redis.Tx) error {
+		var err error
+		cart, err = readCart(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(&cart); err != nil {
+			return err
+		}
+		cart.UpdatedAt = time.Now()
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if len(cart.Items) == 0 {
+				pipe.Del(ctx, key)
+				return nil
+			}
+
+			raw, err := json.Marshal(cart)
+			if err != nil {
+				return err
+			}
+			pipe.Set(ctx, key, raw, cartTTL)
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		err := Rdb.Watch(ctx, txf, key)
+		if err == redis.TxFailedErr {
+			continue
+		}
+		return cart, err
+	}
+	return cart, errCartBusy
+}
+
+func clearCart(ctx context.Context, keys ...string) error {
+	return Rdb.Del(ctx, keys...).Err()
+}
+
+// cartError writes the response for a failed cart update and reports whether
+// the handler may carry on.
+func cartError(ctx *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errCartLineNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"message":    "The requested cart item does not exist.",
+		})
+	case errors.Is(err, errCartTooManyShops):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    fmt.Sprintf("A cart can hold items from at most %d shops.", maxCartShops),
+		})
+	case errors.Is(err, errCartFull):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    fmt.Sprintf("A cart can hold at most %d different items.", maxCartLines),
+		})
+	case errors.Is(err, errCartBusy):
+		ctx.JSON(http.StatusConflict, gin.H{
+			"statusCode": http.StatusConflict,
+			"message":    err.Error(),
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+	}
+	return false
+}
+
+func sameOptions(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
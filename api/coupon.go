@@ -0,0 +1,588 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type Coupon struct {
+	server *Server
+}
+
+type CreateCouponParams struct {
+	Code        string      `json:"code" binding:"required,min=3,max=30,alphanum"`
+	Description string      `json:"description" binding:"max=200"`
+	Kind        string      `json:"kind" binding:"required,oneof=percentage fixed free_delivery"`
+	PercentOff  int32       `json:"percent_off" binding:"omitempty,min=1,max=100"`
+	AmountOff   utils.Money `json:"amount_off" binding:"omitempty,isPositive"`
+	MaxDiscount utils.Money `json:"max_discount" binding:"omitempty,isPositive"`
+	MinOrder    utils.Money `json:"min_order" binding:"omitempty,isPositive"`
+
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+
+	// Limits on how often the coupon can be used, by everyone together and
+	// by one customer. Left out, there is no limit.
+	MaxRedemptions *int32 `json:"max_redemptions" binding:"omitempty,min=1"`
+	MaxPerUser     *int32 `json:"max_per_user" binding:"omitempty,min=1"`
+	FirstOrderOnly bool   `json:"first_order_only"`
+
+	// ShopID limits the coupon to one shop, which then pays for it.
+	ShopID     string   `json:"shop_id" binding:"max=50"`
+	Categories []string `json:"categories" binding:"omitempty,max=20,dive,required,max=50"`
+}
+
+type ListCouponsParams struct {
+	PageID   int32 `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32 `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type SetCouponActiveParams struct {
+	IsActive *bool `json:"is_active" binding:"required"`
+}
+
+type ApplyCouponParams struct {
+	Code string `json:"code" binding:"required,max=30"`
+}
+
+// CartCoupon is the coupon on a cart and what it takes off. A free delivery
+// coupon only has a Discount once delivery has been quoted. When the coupon
+// cannot be used, Issue says why and nothing is taken off.
+type CartCoupon struct {
+	Code         string      `json:"code"`
+	Kind         string      `json:"kind,omitempty"`
+	Description  string      `json:"description,omitempty"`
+	Discount     utils.Money `json:"discount"`
+	FreeDelivery bool        `json:"free_delivery"`
+	Issue        string      `json:"issue,omitempty"`
+}
+
+func (cp Coupon) router(server *Server) {
+	cp.server = server
+
+	adminGroup := server.router.Group("/admin/coupons", AuthenticatedMiddleware(), AdminMiddleware())
+	adminGroup.POST("", cp.createCoupon)
+	adminGroup.GET("", cp.listCoupons)
+	adminGroup.GET("/:id", cp.getCoupon)
+	adminGroup.PUT("/:id/active", cp.setCouponActive)
+}
+
+func (cp *Coupon) createCoupon(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := CreateCouponParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	arg := db.CreateCouponParams{
+		Code:           strings.ToUpper(input.Code),
+		Description:    strings.TrimSpace(input.Description),
+		Kind:           input.Kind,
+		PercentOff:     input.PercentOff,
+		AmountOff:      input.AmountOff,
+		MaxDiscount:    input.MaxDiscount,
+		MinOrder:       input.MinOrder,
+		FirstOrderOnly: input.FirstOrderOnly,
+		ShopID:         sql.NullString{String: input.ShopID, Valid: input.ShopID != ""},
+		Categories:     []string{},
+		CreatedBy:      sql.NullString{String: userId, Valid: true},
+	}
+	if input.StartsAt != nil {
+		arg.StartsAt = sql.NullTime{Time: *input.StartsAt, Valid: true}
+	}
+	if input.EndsAt != nil {
+		arg.EndsAt = sql.NullTime{Time: *input.EndsAt, Valid: true}
+	}
+	if input.MaxRedemptions != nil {
+		arg.MaxRedemptions = sql.NullInt32{Int32: *input.MaxRedemptions, Valid: true}
+	}
+	if input.MaxPerUser != nil {
+		arg.MaxPerUser = sql.NullInt32{Int32: *input.MaxPerUser, Valid: true}
+	}
+	for _, category := range input.Categories {
+		arg.Categories = append(arg.Categories, strings.ToLower(strings.TrimSpace(category)))
+	}
+	arg.Categories = utils.Dedupe(arg.Categories)
+
+	if err := couponRules(db.Coupon{
+		Kind:       arg.Kind,
+		PercentOff: arg.PercentOff,
+		AmountOff:  arg.AmountOff,
+		StartsAt:   arg.StartsAt,
+		EndsAt:     arg.EndsAt,
+	}).Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if arg.ShopID.Valid {
+		_, err := cp.server.queries.GetShop(context.Background(), arg.ShopID.String)
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"statusCode": http.StatusNotFound,
+				"message":    "The requested shop does not exist.",
+			})
+			return
+		} else if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"Error": err.Error(),
+			})
+			return
+		}
+	}
+
+	id, err := utils.NewID()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Failed to generate CUID": err.Error(),
+		})
+		return
+	}
+	arg.ID = id
+
+	coupon, err := cp.server.queries.CreateCoupon(context.Background(), arg)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"statusCode": http.StatusConflict,
+				"message":    "A coupon with this code already exists.",
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"statusCode": http.StatusCreated,
+		"status":     "success",
+		"message":    "coupon created successfully",
+		"data":       coupon,
+	})
+}
+
+func (cp *Coupon) listCoupons(ctx *gin.Context) {
+	input := ListCouponsParams{}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	limit, offset := pagination(input.PageID, input.PageSize)
+
+	coupons, err := cp.server.queries.ListCoupons(context.Background(), db.ListCouponsParams{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "coupons fetched successfully",
+		"data":       coupons,
+	})
+}
+
+func (cp *Coupon) getCoupon(ctx *gin.Context) {
+	coupon, err := cp.server.queries.GetCoupon(context.Background(), ctx.Param("id"))
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"message":    "The requested coupon does not exist.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "coupon fetched successfully",
+		"data":       coupon,
+	})
+}
+
+// setCouponActive switches a coupon off, or back on. Coupons are never
+// deleted, since orders point at the coupon that discounted them.
+func (cp *Coupon) setCouponActive(ctx *gin.Context) {
+	input := SetCouponActiveParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	coupon, err := cp.server.queries.SetCouponActive(context.Background(), db.SetCouponActiveParams{
+		ID:       ctx.Param("id"),
+		IsActive: *input.IsActive,
+	})
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"message":    "The requested coupon does not exist.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"statusCode": http.StatusAccepted,
+		"status":     "success",
+		"message":    "coupon updated successfully",
+		"data":       coupon,
+	})
+}
+
+// applyCoupon puts a coupon on the user's cart. A coupon that cannot be used
+// on the cart as it is now is refused; one that stops applying later, say
+// because items were removed, stays on the cart with its issue shown.
+func (c *Cart) applyCoupon(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := ApplyCouponParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+	code := strings.ToUpper(strings.TrimSpace(input.Code))
+
+	cart, err := loadCart(context.Background(), cartKey(userId))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if len(cart.Items) == 0 {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    "Your cart is empty.",
+		})
+		return
+	}
+
+	priced, err := c.server.priceCart(context.Background(), cart)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if _, _, err := c.server.discountCart(context.Background(), userId, code, &priced, nil); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if priced.Coupon.Issue != "" {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    priced.Coupon.Issue,
+		})
+		return
+	}
+
+	cart, err = updateCart(context.Background(), cartKey(userId), func(cart *storedCart) error {
+		cart.CouponCode = code
+		return nil
+	})
+	if !cartError(ctx, err) {
+		return
+	}
+
+	c.respond(ctx, http.StatusAccepted, "coupon applied successfully", cart)
+}
+
+func (c *Cart) removeCoupon(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	cart, err := updateCart(context.Background(), cartKey(userId), func(cart *storedCart) error {
+		cart.CouponCode = ""
+		return nil
+	})
+	if !cartError(ctx, err) {
+		return
+	}
+
+	c.respond(ctx, http.StatusAccepted, "coupon removed successfully", cart)
+}
+
+// discountCart takes the coupon with code off a priced cart, and off its
+// delivery quote when there is one. Whatever stops the coupon being used is
+// put on priced.Coupon rather than returned; only lookups that fail are
+// errors. The coupon and discount come back for checkout to redeem.
+func (s *Server) discountCart(ctx context.Context, userId, code string, priced *CartResponse, delivery *CartDeliveryQuoteResponse) (db.Coupon, utils.CouponDiscount, error) {
+	discount := utils.CouponDiscount{Total: utils.Kobo(0)}
+	if code == "" {
+		return db.Coupon{}, discount, nil
+	}
+	priced.Coupon = &CartCoupon{Code: code, Discount: utils.Kobo(0)}
+
+	coupon, err := s.queries.GetCouponByCode(ctx, code)
+	if err == sql.ErrNoRows {
+		priced.Coupon.Issue = couponIssue(utils.ErrCouponNotFound)
+		return coupon, discount, nil
+	} else if err != nil {
+		return coupon, discount, err
+	}
+
+	priced.Coupon.Kind = coupon.Kind
+	priced.Coupon.Description = coupon.Description
+	priced.Coupon.FreeDelivery = coupon.Kind == utils.CouponFreeDelivery
+
+	if err := checkCoupon(ctx, s.queries, coupon, userId); err != nil {
+		if issue := couponIssue(err); issue != "" {
+			priced.Coupon.Issue = issue
+			return coupon, discount, nil
+		}
+		return coupon, discount, err
+	}
+
+	var fees map[string]utils.Money
+	if delivery != nil {
+		fees = map[string]utils.Money{}
+		for _, quote := range delivery.Shops {
+			fees[quote.ShopID] = quote.Fee
+		}
+	}
+
+	rules := couponRules(coupon)
+	lines := []utils.CouponLine{}
+	for _, line := range priced.Lines {
+		if line.IsAvailable {
+			lines = append(lines, utils.CouponLine{ShopID: line.ShopID, Category: line.category, Amount: line.LineTotal})
+		}
+	}
+
+	discount, err = rules.Discount(lines, fees)
+	if err == utils.ErrCouponMinOrder {
+		priced.Coupon.Issue = fmt.Sprintf("This coupon needs %s or more of items it applies to.", coupon.MinOrder)
+		return coupon, discount, nil
+	} else if err != nil {
+		priced.Coupon.Issue = couponIssue(err)
+		return coupon, discount, nil
+	}
+
+	priced.Coupon.Discount = discount.Total
+	priced.Discount = discount.Total
+	priced.Total = priced.Total.Sub(discount.Total)
+	for i := range priced.Shops {
+		if share, ok := discount.Shops[priced.Shops[i].ShopID]; ok {
+			priced.Shops[i].Discount = share
+		}
+	}
+	if delivery != nil {
+		delivery.Discount = discount.Total
+		delivery.Total = delivery.Total.Sub(discount.Total)
+	}
+	return coupon, discount, nil
+}
+
+// checkCoupon reports whether userId may use coupon right now, leaving what
+// it is used on to the coupon's rules.
+func checkCoupon(ctx context.Context, q *db.Queries, coupon db.Coupon, userId string) error {
+	if !coupon.IsActive {
+		return utils.ErrCouponInactive
+	}
+	if err := couponRules(coupon).Check(time.Now()); err != nil {
+		return err
+	}
+	if coupon.MaxRedemptions.Valid && coupon.RedemptionCount >= coupon.MaxRedemptions.Int32 {
+		return utils.ErrCouponUsedUp
+	}
+
+	if coupon.MaxPerUser.Valid {
+		used, err := q.CountUserRedemptions(ctx, db.CountUserRedemptionsParams{
+			CouponID: coupon.ID,
+			UserID:   userId,
+		})
+		if err != nil {
+			return err
+		}
+		if used >= int64(coupon.MaxPerUser.Int32) {
+			return utils.ErrCouponUserLimit
+		}
+	}
+
+	if coupon.FirstOrderOnly {
+		placed, err := q.CountUserPlacedOrders(ctx, userId)
+		if err != nil {
+			return err
+		}
+		if placed > 0 {
+			return utils.ErrCouponFirstOrder
+		}
+	}
+	return nil
+}
+
+// redeemCoupon counts one use of coupon by a checkout. Counting the use
+// locks the coupon until the checkout commits, so the limits are checked
+// again behind it: another checkout may have used the last of them while
+// this one was being priced.
+func redeemCoupon(ctx context.Context, q *db.Queries, coupon db.Coupon, userId, checkoutId string, discount utils.Money) error {
+	redeemed, err := q.RedeemCoupon(ctx, coupon.ID)
+	if err == sql.ErrNoRows {
+		return utils.ErrCouponUsedUp
+	} else if err != nil {
+		return err
+	}
+
+	// The count now includes this use, which checkCoupon would take as the
+	// coupon being used up.
+	redeemed.MaxRedemptions = sql.NullInt32{}
+	if err := checkCoupon(ctx, q, redeemed, userId); err != nil {
+		return err
+	}
+
+	id, err := utils.NewID()
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateCouponRedemption(ctx, db.CreateCouponRedemptionParams{
+		ID:         id,
+		CouponID:   coupon.ID,
+		UserID:     userId,
+		CheckoutID: checkoutId,
+		Discount:   discount,
+	})
+	return err
+}
+
+// releaseCoupon gives back the coupon use of an order's checkout once none
+// of its orders are going ahead.
+func releaseCoupon(ctx context.Context, q *db.Queries, order db.Order) error {
+	if !order.CheckoutID.Valid {
+		return nil
+	}
+
+	redemption, err := q.ReleaseCheckoutRedemption(ctx, order.CheckoutID.String)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return q.UnredeemCoupon(ctx, redemption.CouponID)
+}
+
+// orderCoupon returns the coupon that discounted an order, if there was one
+// and it still exists.
+func orderCoupon(ctx context.Context, q *db.Queries, order db.Order) (db.Coupon, bool, error) {
+	if !order.CouponID.Valid || order.Discount.IsZero() {
+		return db.Coupon{}, false, nil
+	}
+
+	coupon, err := q.GetCoupon(ctx, order.CouponID.String)
+	if err == sql.ErrNoRows {
+		return coupon, false, nil
+	}
+	return coupon, err == nil, err
+}
+
+func couponRules(coupon db.Coupon) utils.CouponRules {
+	rules := utils.CouponRules{
+		Kind:        coupon.Kind,
+		PercentOff:  int64(coupon.PercentOff),
+		AmountOff:   coupon.AmountOff,
+		MaxDiscount: coupon.MaxDiscount,
+		MinOrder:    coupon.MinOrder,
+		ShopID:      coupon.ShopID.String,
+		Categories:  coupon.Categories,
+	}
+	if coupon.StartsAt.Valid {
+		rules.StartsAt = coupon.StartsAt.Time
+	}
+	if coupon.EndsAt.Valid {
+		rules.EndsAt = coupon.EndsAt.Time
+	}
+	return rules
+}
+
+// couponIssue is what to tell a customer whose coupon cannot be used, or ""
+// when err is not about the coupon.
+func couponIssue(err error) string {
+	switch {
+	case errors.Is(err, utils.ErrCouponNotFound):
+		return "This coupon does not exist."
+	case errors.Is(err, utils.ErrCouponInactive), errors.Is(err, utils.ErrCouponExpired):
+		return "This coupon is no longer available."
+	case errors.Is(err, utils.ErrCouponNotStarted):
+		return "This coupon cannot be used yet."
+	case errors.Is(err, utils.ErrCouponUsedUp):
+		return "This coupon has been used up."
+	case errors.Is(err, utils.ErrCouponUserLimit):
+		return "You have already used this coupon."
+	case errors.Is(err, utils.ErrCouponFirstOrder):
+		return "This coupon is only for your first order."
+	case errors.Is(err, utils.ErrCouponMinOrder):
+		return "Your order is below this coupon's minimum."
+	case errors.Is(err, utils.ErrCouponNotApplicable):
+		return "This coupon does not apply to anything in your cart."
+	}
+	return ""
+}
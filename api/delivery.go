@@ -0,0 +1,284 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type UpdateShopLocationParams struct {
+	Latitude  *float64 `json:"latitude" binding:"required,min=-90,max=90"`
+	Longitude *float64 `json:"longitude" binding:"required,min=-180,max=180"`
+}
+
+type DeliveryQuoteParams struct {
+	Latitude     *float64   `form:"latitude" binding:"omitempty,min=-90,max=90,required_with=Longitude"`
+	Longitude    *float64   `form:"longitude" binding:"omitempty,min=-180,max=180,required_with=Latitude"`
+	ScheduledFor *time.Time `form:"scheduled_for"`
+}
+
+// DeliveryQuoteResponse is the delivery fee for one shop's order. When the
+// shop cannot deliver, Deliverable is false and Issue says why.
+type DeliveryQuoteResponse struct {
+	ShopID               string      `json:"shop_id"`
+	ShopName             string      `json:"shop_name"`
+	DistanceMeters       *int64      `json:"distance_meters"`
+	DistanceFee          utils.Money `json:"distance_fee"`
+	SurgePercent         int64       `json:"surge_percent"`
+	SurgeFee             utils.Money `json:"surge_fee"`
+	SmallOrderFee        utils.Money `json:"small_order_fee"`
+	FreeDeliveryDiscount utils.Money `json:"free_delivery_discount"`
+	Fee                  utils.Money `json:"fee"`
+	Deliverable          bool        `json:"deliverable"`
+	Issue                string      `json:"issue,omitempty"`
+}
+
+type CartDeliveryQuoteResponse struct {
+	Shops         []DeliveryQuoteResponse `json:"shops"`
+	Subtotal      utils.Money             `json:"subtotal"`
+	ServiceCharge utils.Money             `json:"service_charge"`
+	VAT           utils.Money             `json:"vat"`
+	DeliveryFee   utils.Money             `json:"delivery_fee"`
+	Discount      utils.Money             `json:"discount"`
+	Total         utils.Money             `json:"total"`
+	Coupon        *CartCoupon             `json:"coupon"`
+	Deliverable   bool                    `json:"deliverable"`
+	Issues        []string                `json:"issues"`
+}
+
+func (s *Shop) getShopLocation(ctx *gin.Context) {
+	location, err := s.server.queries.GetShopLocation(context.Background(), ctx.Param("id"))
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"Error":      err.Error(),
+			"message":    "This shop has not set its location.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "shop location fetched successfully",
+		"data":       location,
+	})
+}
+
+// updateShopLocation pins a shop on the map so delivery can be priced by
+// distance.
+func (s *Shop) updateShopLocation(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := UpdateShopLocationParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	shop, ok := s.server.ownedShop(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	location, err := s.server.queries.UpsertShopLocation(context.Background(), db.UpsertShopLocationParams{
+		ShopID:    shop.ID,
+		Latitude:  *input.Latitude,
+		Longitude: *input.Longitude,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"statusCode": http.StatusAccepted,
+		"status":     "success",
+		"message":    "shop location updated successfully",
+		"data":       location,
+	})
+}
+
+// deliveryQuote prices delivery of everything in the user's cart.
+func (c *Cart) deliveryQuote(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := DeliveryQuoteParams{}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	cart, err := loadCart(context.Background(), cartKey(userId))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	priced, err := c.server.priceCart(context.Background(), cart)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	at := time.Now()
+	if input.ScheduledFor != nil {
+		at = *input.ScheduledFor
+	}
+
+	quote, err := c.server.quoteCartDelivery(context.Background(), priced, input.Latitude, input.Longitude, at)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if _, _, err := c.server.discountCart(context.Background(), userId, cart.CouponCode, &priced, &quote); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+	quote.Coupon = priced.Coupon
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "delivery quote fetched successfully",
+		"data":       quote,
+	})
+}
+
+// quoteCartDelivery prices every shop in a priced cart separately, since each
+// becomes its own order with its own trip.
+func (s *Server) quoteCartDelivery(ctx context.Context, priced CartResponse, lat, lng *float64, at time.Time) (CartDeliveryQuoteResponse, error) {
+	response := CartDeliveryQuoteResponse{
+		Shops:       []DeliveryQuoteResponse{},
+		Discount:    utils.Kobo(0),
+		Deliverable: len(priced.Shops) > 0,
+		Issues:      []string{},
+	}
+
+	for _, shop := range priced.Shops {
+		quote, err := s.quoteDelivery(ctx, shop.ShopID, shop.ShopName, shop.Subtotal, lat, lng, at)
+		if err != nil {
+			return response, err
+		}
+
+		if !quote.Deliverable {
+			response.Deliverable = false
+			response.Issues = append(response.Issues, quote.Issue)
+		}
+		response.DeliveryFee = response.DeliveryFee.Add(quote.Fee)
+		response.Shops = append(response.Shops, quote)
+	}
+
+	response.Subtotal = priced.Subtotal
+	response.ServiceCharge = priced.ServiceCharge
+	response.VAT = priced.VAT
+	response.Total = priced.Total.Add(response.DeliveryFee)
+	return response, nil
+}
+
+// quoteDelivery prices delivering an order worth subtotal from a shop to
+// lat/lng. Shops or customers without coordinates pay the flat fee.
+func (s *Server) quoteDelivery(ctx context.Context, shopId, shopName string, subtotal utils.Money, lat, lng *float64, at time.Time) (DeliveryQuoteResponse, error) {
+	response := DeliveryQuoteResponse{
+		ShopID:   shopId,
+		ShopName: shopName,
+	}
+
+	var distance int64
+	known := false
+
+	if lat != nil && lng != nil {
+		location, err := s.queries.GetShopLocation(ctx, shopId)
+		if err != nil && err != sql.ErrNoRows {
+			return response, err
+		} else if err == nil {
+			distance = utils.DistanceMeters(location.Latitude, location.Longitude, *lat, *lng)
+			known = true
+		}
+	}
+
+	quote, err := s.delivery.Quote(distance, known, subtotal, at.In(s.location))
+	switch {
+	case errors.Is(err, utils.ErrBelowMinimumOrder):
+		response.Issue = fmt.Sprintf("%s delivers orders of %s or more.", shopName, s.delivery.MinOrder)
+	case errors.Is(err, utils.ErrOutOfDeliveryArea):
+		response.Issue = fmt.Sprintf("%s does not deliver that far.", shopName)
+	case err != nil:
+		return response, err
+	default:
+		response.Deliverable = true
+	}
+
+	response.DistanceMeters = quote.DistanceMeters
+	response.DistanceFee = quote.DistanceFee
+	response.SurgePercent = quote.SurgePercent
+	response.SurgeFee = quote.SurgeFee
+	response.SmallOrderFee = quote.SmallOrderFee
+	response.FreeDeliveryDiscount = quote.FreeDeliveryDiscount
+	response.Fee = quote.Fee
+	return response, nil
+}
+
+// recordDelivery stores how an order's delivery fee was worked out.
+func recordDelivery(ctx context.Context, q *db.Queries, orderId string, quote DeliveryQuoteResponse, lat, lng *float64) error {
+	arg := db.CreateOrderDeliveryParams{
+		OrderID:              orderId,
+		DistanceFee:          quote.DistanceFee,
+		SmallOrderFee:        quote.SmallOrderFee,
+		SurgePercent:         int32(quote.SurgePercent),
+		SurgeFee:             quote.SurgeFee,
+		FreeDeliveryDiscount: quote.FreeDeliveryDiscount,
+		Fee:                  quote.Fee,
+	}
+	if lat != nil && lng != nil {
+		arg.Latitude = sql.NullFloat64{Float64: *lat, Valid: true}
+		arg.Longitude = sql.NullFloat64{Float64: *lng, Valid: true}
+	}
+	if quote.DistanceMeters != nil {
+		arg.DistanceMeters = sql.NullInt32{Int32: int32(*quote.DistanceMeters), Valid: true}
+	}
+
+	_, err := q.CreateOrderDelivery(ctx, arg)
+	return err
+}
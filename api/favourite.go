@@ -0,0 +1,249 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+type Favourite struct {
+	server *Server
+}
+
+func (f Favourite) router(server *Server) {
+	f.server = server
+
+	server.router.POST("/shops/:id/favourite", AuthenticatedMiddleware(), f.favouriteShop)
+	server.router.DELETE("/shops/:id/favourite", AuthenticatedMiddleware(), f.unfavouriteShop)
+	server.router.POST("/products/:id/favourite", AuthenticatedMiddleware(), f.favouriteProduct)
+	server.router.DELETE("/products/:id/favourite", AuthenticatedMiddleware(), f.unfavouriteProduct)
+	server.router.GET("/users/favourites", AuthenticatedMiddleware(), f.listFavourites)
+}
+
+func (f *Favourite) favouriteShop(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	shop, err := f.server.queries.GetShop(context.Background(), ctx.Param("id"))
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"Error":      err.Error(),
+			"message":    "The requested shop does not exist.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	err = f.server.queries.AddFavouriteShop(context.Background(), db.AddFavouriteShopParams{
+		UserID: userId,
+		ShopID: shop.ID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "shop added to favourites",
+	})
+}
+
+func (f *Favourite) unfavouriteShop(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	err := f.server.queries.RemoveFavouriteShop(context.Background(), db.RemoveFavouriteShopParams{
+		UserID: userId,
+		ShopID: ctx.Param("id"),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "shop removed from favourites",
+	})
+}
+
+func (f *Favourite) favouriteProduct(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	product, err := f.server.queries.GetProduct(context.Background(), ctx.Param("id"))
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"Error":      err.Error(),
+			"message":    "The requested product does not exist.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	err = f.server.queries.AddFavouriteProduct(context.Background(), db.AddFavouriteProductParams{
+		UserID:    userId,
+		ProductID: product.ID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "product added to favourites",
+	})
+}
+
+func (f *Favourite) unfavouriteProduct(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	err := f.server.queries.RemoveFavouriteProduct(context.Background(), db.RemoveFavouriteProductParams{
+		UserID:    userId,
+		ProductID: ctx.Param("id"),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "product removed from favourites",
+	})
+}
+
+// listFavourites returns the user's favourites joined with the live shop and
+// product rows, so availability and price are always current.
+func (f *Favourite) listFavourites(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	shops, err := f.server.queries.ListFavouriteShops(context.Background(), userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	products, err := f.server.queries.ListFavouriteProducts(context.Background(), userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "favourites fetched successfully",
+		"data": gin.H{
+			"shops":    shops,
+			"products": products,
+		},
+	})
+}
+
+// favouriteShopIDs returns which of the given shops the signed in user has
+// favourited. Anonymous requests get an empty set.
+func (s *Server) favouriteShopIDs(ctx *gin.Context, shopIds []string) (map[string]bool, bool) {
+	favourites := map[string]bool{}
+
+	userId, ok := currentUserID(ctx)
+	if !ok || len(shopIds) == 0 {
+		return favourites, true
+	}
+
+	ids, err := s.queries.FilterFavouriteShops(context.Background(), db.FilterFavouriteShopsParams{
+		UserID:  userId,
+		ShopIds: shopIds,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return nil, false
+	}
+
+	for _, id := range ids {
+		favourites[id] = true
+	}
+	return favourites, true
+}
+
+// favouriteProductIDs is favouriteShopIDs for products.
+func (s *Server) favouriteProductIDs(ctx *gin.Context, productIds []string) (map[string]bool, bool) {
+	favourites := map[string]bool{}
+
+	userId, ok := currentUserID(ctx)
+	if !ok || len(productIds) == 0 {
+		return favourites, true
+	}
+
+	ids, err := s.queries.FilterFavouriteProducts(context.Background(), db.FilterFavouriteProductsParams{
+		UserID:     userId,
+		ProductIds: productIds,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return nil, false
+	}
+
+	for _, id := range ids {
+		favourites[id] = true
+	}
+	return favourites, true
+}
@@ -0,0 +1,898 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	groupOpen      = "open"
+	groupLocked    = "locked"
+	groupSubmitted = "submitted"
+	groupCancelled = "cancelled"
+
+	// groupPayHost means the host pays for everyone. It is the only mode
+	// for now: members paying their own share would need a payment for
+	// each of them against the one order, and refunds spread across them.
+	groupPayHost = "host"
+
+	maxGroupDeadline = 24 * time.Hour
+)
+
+var errGroupClosed = errors.New("group order is no longer taking items")
+
+type GroupOrder struct {
+	server *Server
+}
+
+type CreateGroupOrderParams struct {
+	ShopID          string    `json:"shop_id" binding:"required"`
+	Deadline        time.Time `json:"deadline" binding:"required"`
+	PaymentMode     string    `json:"payment_mode" binding:"required,oneof=host"`
+	DeliveryAddress string    `json:"delivery_address" binding:"max=500"`
+	Note            string    `json:"note" binding:"max=500"`
+}
+
+type GroupMemberResponse struct {
+	UserID    string       `json:"user_id"`
+	Firstname string       `json:"firstname"`
+	IsHost    bool         `json:"is_host"`
+	Cart      CartResponse `json:"cart"`
+	AmountDue utils.Money  `json:"amount_due"`
+}
+
+type GroupOrderResponse struct {
+	db.GroupOrder
+	OrderID       *string               `json:"order_id"`
+	InviteLink    string                `json:"invite_link"`
+	IsOpen        bool                  `json:"is_open"`
+	Members       []GroupMemberResponse `json:"members"`
+	Subtotal      utils.Money           `json:"subtotal"`
+	ServiceCharge utils.Money           `json:"service_charge"`
+	VAT           utils.Money           `json:"vat"`
+	Total         utils.Money           `json:"total"`
+	IsValid       bool                  `json:"is_valid"`
+	Issues        []string              `json:"issues"`
+
+	tax utils.TaxBreakdown
+}
+
+func (g GroupOrder) router(server *Server) {
+	g.server = server
+
+	serverGroup := server.router.Group("/group_orders", AuthenticatedMiddleware())
+	serverGroup.POST("", IdempotencyMiddleware(), g.createGroupOrder)
+	serverGroup.GET("", g.listGroupOrders)
+	serverGroup.POST("/join/:code", g.joinGroupOrder)
+	serverGroup.GET("/:id", g.getGroupOrder)
+	serverGroup.DELETE("/:id/members/me", g.leaveGroupOrder)
+	serverGroup.POST("/:id/items", IdempotencyMiddleware(), g.addGroupItem)
+	serverGroup.PUT("/:id/items/:line_id", g.updateGroupItem)
+	serverGroup.DELETE("/:id/items/:line_id", g.removeGroupItem)
+	serverGroup.POST("/:id/lock", g.lockGroupOrder)
+	serverGroup.POST("/:id/cancel", g.cancelGroupOrder)
+	serverGroup.POST("/:id/submit", IdempotencyMiddleware(), g.submitGroupOrder)
+}
+
+func (g *GroupOrder) createGroupOrder(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := CreateGroupOrderParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	now := time.Now()
+	if !input.Deadline.After(now) || input.Deadline.After(now.Add(maxGroupDeadline)) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": "deadline must be in the next 24 hours",
+		})
+		return
+	}
+
+	shop, err := g.server.queries.GetShop(context.Background(), input.ShopID)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"Error":      err.Error(),
+			"message":    "The requested shop does not exist.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	address := strings.TrimSpace(input.DeliveryAddress)
+	if address == "" {
+		user, err := g.server.queries.GetUserById(context.Background(), userId)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"Error": err.Error(),
+			})
+			return
+		}
+		address = strings.TrimSpace(user.Address)
+	}
+	if address == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": "a delivery address is required",
+		})
+		return
+	}
+
+	groupId, err := utils.NewID()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Failed to generate CUID": err.Error(),
+		})
+		return
+	}
+
+	inviteCode, err := utils.NewID()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Failed to generate CUID": err.Error(),
+		})
+		return
+	}
+
+	var group db.GroupOrder
+
+	err = g.server.execTx(ctx, func(q *db.Queries) error {
+		var err error
+
+		group, err = q.CreateGroupOrder(ctx, db.CreateGroupOrderParams{
+			ID:              groupId,
+			HostID:          userId,
+			ShopID:          shop.ID,
+			InviteCode:      inviteCode,
+			PaymentMode:     input.PaymentMode,
+			Deadline:        input.Deadline,
+			DeliveryAddress: address,
+			Note:            strings.TrimSpace(input.Note),
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.AddGroupOrderMember(ctx, db.AddGroupOrderMemberParams{
+			GroupOrderID: group.ID,
+			UserID:       userId,
+		})
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	g.respond(ctx, http.StatusCreated, "group order created successfully", group)
+}
+
+func (g *GroupOrder) listGroupOrders(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := ListOrdersParams{}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	limit, offset := pagination(input.PageID, input.PageSize)
+
+	groups, err := g.server.queries.ListUserGroupOrders(context.Background(), db.ListUserGroupOrdersParams{
+		UserID: userId,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	response := []GroupOrderResponse{}
+	for _, group := range groups {
+		response = append(response, newGroupOrderSummary(group))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "group orders fetched successfully",
+		"data":       response,
+	})
+}
+
+func (g *GroupOrder) joinGroupOrder(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	group, err := g.server.queries.GetGroupOrderByInviteCode(context.Background(), ctx.Param("code"))
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"Error":      err.Error(),
+			"message":    "This invite link is not valid.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if !isGroupOpen(group) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    "This group order is closed.",
+		})
+		return
+	}
+
+	err = g.server.queries.AddGroupOrderMember(context.Background(), db.AddGroupOrderMemberParams{
+		GroupOrderID: group.ID,
+		UserID:       userId,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	g.respond(ctx, http.StatusOK, "joined group order successfully", group)
+}
+
+func (g *GroupOrder) getGroupOrder(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	group, ok := g.server.groupMember(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	g.respond(ctx, http.StatusOK, "group order fetched successfully", group)
+}
+
+// leaveGroupOrder takes a member and their items out of an open group. The
+// host cannot leave; they cancel instead.
+func (g *GroupOrder) leaveGroupOrder(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	group, ok := g.server.groupMember(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	if group.HostID == userId {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    "The host cannot leave a group order. Cancel it instead.",
+		})
+		return
+	}
+
+	if !isGroupOpen(group) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    "This group order is closed.",
+		})
+		return
+	}
+
+	err := g.server.queries.RemoveGroupOrderMember(context.Background(), db.RemoveGroupOrderMemberParams{
+		GroupOrderID: group.ID,
+		UserID:       userId,
+	})
+	if err == nil {
+		err = clearCart(context.Background(), groupCartKey(group.ID, userId))
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "left group order successfully",
+	})
+}
+
+func (g *GroupOrder) addGroupItem(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := AddCartItemParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	group, ok := g.server.groupMember(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	// The shop may well be closed while lunch is still being collected, so
+	// that is only checked when the host submits.
+	item, shopId, ok := g.server.newCartItem(ctx, input, false)
+	if !ok {
+		return
+	}
+
+	if shopId != group.ShopID {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"message":    "This product is not from the group order's shop.",
+		})
+		return
+	}
+
+	_, err := updateCart(context.Background(), groupCartKey(group.ID, userId), func(cart *storedCart) error {
+		if !isGroupOpen(group) {
+			return errGroupClosed
+		}
+		return addCartLine(cart, item)
+	})
+	if !groupCartError(ctx, err) {
+		return
+	}
+
+	g.respond(ctx, http.StatusCreated, "item added to group order", group)
+}
+
+func (g *GroupOrder) updateGroupItem(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := UpdateCartItemParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	group, ok := g.server.groupMember(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	lineId := ctx.Param("line_id")
+
+	_, err := updateCart(context.Background(), groupCartKey(group.ID, userId), func(cart *storedCart) error {
+		if !isGroupOpen(group) {
+			return errGroupClosed
+		}
+		for i, item := range cart.Items {
+			if item.ID == lineId {
+				cart.Items[i].Quantity = input.Quantity
+				if input.Note != nil {
+					cart.Items[i].Note = strings.TrimSpace(*input.Note)
+				}
+				return nil
+			}
+		}
+		return errCartLineNotFound
+	})
+	if !groupCartError(ctx, err) {
+		return
+	}
+
+	g.respond(ctx, http.StatusAccepted, "group order item updated successfully", group)
+}
+
+func (g *GroupOrder) removeGroupItem(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	group, ok := g.server.groupMember(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	lineId := ctx.Param("line_id")
+
+	_, err := updateCart(context.Background(), groupCartKey(group.ID, userId), func(cart *storedCart) error {
+		if !isGroupOpen(group) {
+			return errGroupClosed
+		}
+		for i, item := range cart.Items {
+			if item.ID == lineId {
+				cart.Items = append(cart.Items[:i], cart.Items[i+1:]...)
+				return nil
+			}
+		}
+		return errCartLineNotFound
+	})
+	if !groupCartError(ctx, err) {
+		return
+	}
+
+	g.respond(ctx, http.StatusAccepted, "group order item removed successfully", group)
+}
+
+// lockGroupOrder lets the host close the group before the deadline.
+func (g *GroupOrder) lockGroupOrder(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	group, ok := g.server.hostedGroup(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	g.setGroupStatus(ctx, group, groupOpen, groupLocked, "group order locked successfully")
+}
+
+func (g *GroupOrder) cancelGroupOrder(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	group, ok := g.server.hostedGroup(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	from := group.Status
+	if from != groupOpen && from != groupLocked {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    fmt.Sprintf("A %s group order cannot be cancelled.", from),
+		})
+		return
+	}
+
+	g.setGroupStatus(ctx, group, from, groupCancelled, "group order cancelled successfully")
+}
+
+func (g *GroupOrder) setGroupStatus(ctx *gin.Context, group db.GroupOrder, from, to, message string) {
+	group, err := g.server.queries.SetGroupOrderStatus(context.Background(), db.SetGroupOrderStatusParams{
+		ToStatus:   to,
+		ID:         group.ID,
+		FromStatus: from,
+	})
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"statusCode": http.StatusConflict,
+			"message":    "The group order has changed, reload and try again.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	g.respond(ctx, http.StatusAccepted, message, group)
+}
+
+// submitGroupOrder turns every member's items into a single order for the
+// shop. Each item remembers who added it, which gives the per-person
+// breakdown on the order.
+func (g *GroupOrder) submitGroupOrder(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	group, ok := g.server.hostedGroup(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	if group.Status != groupOpen && group.Status != groupLocked {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    fmt.Sprintf("A %s group order cannot be submitted.", group.Status),
+		})
+		return
+	}
+
+	response, err := g.server.groupOrderResponse(context.Background(), group)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if !response.IsValid {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    "Some items in the group order can no longer be ordered.",
+			"data":       response,
+		})
+		return
+	}
+
+	shop, err := g.server.queries.GetShop(context.Background(), group.ShopID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	// Group orders only have a typed address, so they pay the flat fee.
+	delivery, err := g.server.quoteDelivery(context.Background(), shop.ID, shop.Name, response.Subtotal, nil, nil, time.Now())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if !delivery.Deliverable {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    delivery.Issue,
+			"data":       delivery,
+		})
+		return
+	}
+
+	orderId, err := utils.NewID()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Failed to generate CUID": err.Error(),
+		})
+		return
+	}
+
+	var order db.Order
+
+	err = g.server.execTx(ctx, func(q *db.Queries) error {
+		var err error
+
+		order, err = q.CreateOrder(ctx, db.CreateOrderParams{
+			ID:              orderId,
+			UserID:          group.HostID,
+			ShopID:          group.ShopID,
+			Subtotal:        response.Subtotal,
+			Total:           response.Total.Add(delivery.Fee),
+			DeliveryAddress: group.DeliveryAddress,
+			Note:            group.Note,
+			Status:          utils.OrderPending,
+			GroupOrderID:    sql.NullString{String: group.ID, Valid: true},
+			DeliveryFee:     delivery.Fee,
+			ServiceCharge:   response.ServiceCharge,
+			Vat:             response.VAT,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := recordDelivery(ctx, q, order.ID, delivery, nil, nil); err != nil {
+			return err
+		}
+
+		if err := issueInvoice(ctx, q, order, response.tax); err != nil {
+			return err
+		}
+
+		for _, member := range response.Members {
+			if err := createOrderItems(ctx, q, order.ID, member.UserID, member.Cart.Lines); err != nil {
+				return err
+			}
+		}
+
+		group, err = q.SubmitGroupOrder(ctx, db.SubmitGroupOrderParams{
+			ID:      group.ID,
+			OrderID: sql.NullString{String: order.ID, Valid: true},
+		})
+		if err == sql.ErrNoRows {
+			return errGroupClosed
+		} else if err != nil {
+			return err
+		}
+
+		return recordOrderStatus(ctx, q, order.ID, "", utils.OrderPending, userId, utils.ActorCustomer, "group order")
+	})
+	if errors.Is(err, errGroupClosed) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"statusCode": http.StatusConflict,
+			"message":    "The group order has already been submitted or cancelled.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	keys := []string{}
+	for _, member := range response.Members {
+		keys = append(keys, groupCartKey(group.ID, member.UserID))
+	}
+	if err := clearCart(context.Background(), keys...); err != nil {
+		log.Printf("could not clear group carts for %s: %v", group.ID, err)
+	}
+
+	placed, err := g.server.orderResponse(context.Background(), order, utils.ActorCustomer)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"statusCode": http.StatusCreated,
+		"status":     "success",
+		"message":    "group order submitted successfully",
+		"data": gin.H{
+			"order":       placed,
+			"group_order": response,
+		},
+	})
+}
+
+func (g *GroupOrder) respond(ctx *gin.Context, status int, message string, group db.GroupOrder) {
+	response, err := g.server.groupOrderResponse(context.Background(), group)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(status, gin.H{
+		"statusCode": status,
+		"status":     "success",
+		"message":    message,
+		"data":       response,
+	})
+}
+
+// groupMember loads a group order the user has joined. Groups they are not
+// part of are reported as missing.
+func (s *Server) groupMember(ctx *gin.Context, groupId, userId string) (db.GroupOrder, bool) {
+	group, err := s.queries.GetGroupOrder(context.Background(), groupId)
+	if err == nil {
+		var members []db.GroupOrderMember
+		members, err = s.queries.ListGroupOrderMembers(context.Background(), group.ID)
+
+		joined := false
+		for _, member := range members {
+			joined = joined || member.UserID == userId
+		}
+		if err == nil && !joined {
+			err = sql.ErrNoRows
+		}
+	}
+
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"Error":      err.Error(),
+			"message":    "The requested group order does not exist.",
+		})
+		return group, false
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return group, false
+	}
+
+	return group, true
+}
+
+// hostedGroup is groupMember for actions only the host may take.
+func (s *Server) hostedGroup(ctx *gin.Context, groupId, userId string) (db.GroupOrder, bool) {
+	group, ok := s.groupMember(ctx, groupId, userId)
+	if !ok {
+		return group, false
+	}
+
+	if group.HostID != userId {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": "Forbidden: only the host can do this",
+		})
+		return group, false
+	}
+
+	return group, true
+}
+
+// groupOrderResponse prices every member's items and works out what each of
+// them owes, which is everything for the host and nothing for the rest.
+func (s *Server) groupOrderResponse(ctx context.Context, group db.GroupOrder) (GroupOrderResponse, error) {
+	response := newGroupOrderSummary(group)
+
+	members, err := s.queries.ListGroupOrderMembers(ctx, group.ID)
+	if err != nil {
+		return response, err
+	}
+
+	itemCount := 0
+	shopOpen := true
+
+	for _, member := range members {
+		user, err := s.queries.GetUserById(ctx, member.UserID)
+		if err != nil {
+			return response, err
+		}
+
+		cart, err := loadCart(ctx, groupCartKey(group.ID, member.UserID))
+		if err != nil {
+			return response, err
+		}
+
+		priced, err := s.priceCart(ctx, cart)
+		if err != nil {
+			return response, err
+		}
+
+		for _, line := range priced.Lines {
+			if !line.IsAvailable {
+				response.Issues = append(response.Issues, fmt.Sprintf("%s's %s: %s", user.Firstname, line.Name, line.Issue))
+			}
+		}
+		if !priced.shopsOpen() {
+			shopOpen = false
+		}
+
+		// Each member's service charge and VAT are rounded on their own
+		// share, so the per-person breakdown always adds up to the order.
+		for _, shop := range priced.Shops {
+			response.tax = response.tax.Add(shop.tax)
+		}
+
+		itemCount += len(priced.Lines)
+		response.Subtotal = response.Subtotal.Add(priced.Subtotal)
+		response.ServiceCharge = response.ServiceCharge.Add(priced.ServiceCharge)
+		response.VAT = response.VAT.Add(priced.VAT)
+		response.Total = response.Total.Add(priced.Total)
+		response.Members = append(response.Members, GroupMemberResponse{
+			UserID:    member.UserID,
+			Firstname: user.Firstname,
+			IsHost:    member.UserID == group.HostID,
+			Cart:      priced,
+			AmountDue: utils.Kobo(0),
+		})
+	}
+
+	for i := range response.Members {
+		if response.Members[i].IsHost && group.PaymentMode == groupPayHost {
+			response.Members[i].AmountDue = response.Total
+		}
+	}
+
+	if itemCount == 0 {
+		response.Issues = append(response.Issues, "Nobody has added anything yet.")
+	}
+	if !shopOpen {
+		response.Issues = append(response.Issues, "The shop is currently closed.")
+	}
+
+	response.IsValid = len(response.Issues) == 0
+	return response, nil
+}
+
+func newGroupOrderSummary(group db.GroupOrder) GroupOrderResponse {
+	return GroupOrderResponse{
+		GroupOrder:    group,
+		OrderID:       nullString(group.OrderID),
+		InviteLink:    "/group_orders/join/" + group.InviteCode,
+		IsOpen:        isGroupOpen(group),
+		Members:       []GroupMemberResponse{},
+		Subtotal:      utils.Kobo(0),
+		ServiceCharge: utils.Kobo(0),
+		VAT:           utils.Kobo(0),
+		Total:         utils.Kobo(0),
+		Issues:        []string{},
+	}
+}
+
+// isGroupOpen reports whether members can still change their items. The
+// scheduler locks groups once their deadline passes, but that can lag by a
+// minute, so the deadline is checked here too.
+func isGroupOpen(group db.GroupOrder) bool {
+	return group.Status == groupOpen && time.Now().Before(group.Deadline)
+}
+
+func groupCartKey(groupId, userId string) string {
+	return "groupcart:" + groupId + ":" + userId
+}
+
+func groupCartError(ctx *gin.Context, err error) bool {
+	if errors.Is(err, errGroupClosed) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    "This group order is closed.",
+		})
+		return false
+	}
+	return cartError(ctx, err)
+}
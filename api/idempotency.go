@@ -0,0 +1,241 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	idempotencyHeader = "Idempotency-Key"
+
+	// idempotencyTTL is how long a finished response is replayed for.
+	idempotencyTTL = 24 * time.Hour
+
+	// idempotencyLockTTL bounds how long a crashed request can block its
+	// retries. A request still running has its lock renewed every
+	// idempotencyLockRefresh, so slow handlers keep it however long they take.
+	idempotencyLockTTL     = time.Minute
+	idempotencyLockRefresh = idempotencyLockTTL / 3
+
+	maxIdempotencyKeyLength = 255
+
+	// maxIdempotentBody bounds the body read into memory to be hashed. The
+	// routes behind the middleware take small JSON bodies.
+	maxIdempotentBody = 1 << 20
+)
+
+type idempotencyRecord struct {
+	RequestHash string `json:"request_hash"`
+	Done        bool   `json:"done"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// idempotencyWriter keeps a copy of everything the handler writes so it can be
+// stored for replay.
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyWriter) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// IdempotencyMiddleware makes a POST safe to retry. When the client sends an
+// Idempotency-Key header the first response is kept in redis and replayed for
+// any retry with the same key and body. A retry that arrives while the first
+// request is still running, or that reuses a key with a different body, gets a
+// 409. Requests without the header are not affected.
+//
+// On authenticated routes it must come after AuthenticatedMiddleware so keys
+// are scoped to the user.
+func IdempotencyMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(idempotencyHeader)
+		if key == "" {
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"Error": "Idempotency-Key must be at most 255 characters",
+			})
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxIdempotentBody))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+					"Error": "request body is too large",
+				})
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"Error": err.Error(),
+			})
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		requestHash := sha256.Sum256(body)
+		record := idempotencyRecord{RequestHash: hex.EncodeToString(requestHash[:])}
+		redisKey := idempotencyKey(ctx, key)
+
+		raw, err := json.Marshal(record)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"Error": err.Error(),
+			})
+			return
+		}
+
+		claimed, err := Rdb.SetNX(context.Background(), redisKey, raw, idempotencyLockTTL).Result()
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"Error": err.Error(),
+			})
+			return
+		}
+
+		if !claimed {
+			replayIdempotent(ctx, redisKey, record.RequestHash)
+			return
+		}
+
+		writer := &idempotencyWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+
+		// The deferred release covers a handler that panics.
+		release := holdIdempotencyLock(redisKey)
+		defer release()
+		ctx.Next()
+		release()
+
+		// Server errors are not remembered so the client can try again.
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			if err := Rdb.Del(context.Background(), redisKey).Err(); err != nil {
+				log.Printf("could not release idempotency key %s: %v", redisKey, err)
+			}
+			return
+		}
+
+		record.Done = true
+		record.Status = status
+		record.ContentType = writer.Header().Get("Content-Type")
+		record.Body = writer.body.Bytes()
+
+		raw, err = json.Marshal(record)
+		if err == nil {
+			err = Rdb.Set(context.Background(), redisKey, raw, idempotencyTTL).Err()
+		}
+		if err != nil {
+			log.Printf("could not store idempotent response for %s: %v", redisKey, err)
+		}
+	}
+}
+
+// holdIdempotencyLock renews the lock on redisKey until the returned func is
+// first called, which waits for the renewing to stop so it cannot cut short
+// the TTL of the stored response.
+func holdIdempotencyLock(redisKey string) func() {
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(idempotencyLockRefresh)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := Rdb.Expire(context.Background(), redisKey, idempotencyLockTTL).Err(); err != nil {
+					log.Printf("could not renew idempotency lock %s: %v", redisKey, err)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stop)
+			<-stopped
+		})
+	}
+}
+
+func replayIdempotent(ctx *gin.Context, redisKey, requestHash string) {
+	record := idempotencyRecord{}
+
+	// redis.Nil means the first request failed and released the key between
+	// our SETNX and GET, which is still an in-flight duplicate.
+	stored, err := Rdb.Get(context.Background(), redisKey).Bytes()
+	if err == nil {
+		err = json.Unmarshal(stored, &record)
+	}
+	if err != nil && err != redis.Nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if record.RequestHash != "" && record.RequestHash != requestHash {
+		ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"statusCode": http.StatusConflict,
+			"message":    "This Idempotency-Key was already used with a different request body.",
+		})
+		return
+	}
+
+	if !record.Done {
+		ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"statusCode": http.StatusConflict,
+			"message":    "A request with this Idempotency-Key is still being processed.",
+		})
+		return
+	}
+
+	ctx.Header("Idempotent-Replayed", "true")
+	ctx.Data(record.Status, record.ContentType, record.Body)
+	ctx.Abort()
+}
+
+// idempotencyKey scopes a client key to the caller and route, so two users
+// picking the same key, or one key sent to two endpoints, never collide.
+func idempotencyKey(ctx *gin.Context, key string) string {
+	scope, ok := currentUserID(ctx)
+	if !ok {
+		scope = "anon:" + ctx.ClientIP()
+	}
+
+	sum := sha256.Sum256([]byte(scope + "\x00" + ctx.Request.Method + " " + ctx.Request.URL.Path + "\x00" + key))
+	return "idempotency:" + hex.EncodeToString(sum[:])
+}
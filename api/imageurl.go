@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// verifyImageURLs checks that every url serves a small public image. When one
+// doesn't it writes a 400 listing the reason for each rejected url and
+// returns false, so handlers can simply return.
+func (s *Server) verifyImageURLs(ctx *gin.Context, urls []string) bool {
+	failed := []utils.ImageURLResult{}
+
+	for _, result := range s.imageURLs.Verify(ctx, urls) {
+		if !result.OK {
+			failed = append(failed, result)
+		}
+	}
+
+	if len(failed) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"message":    "one or more image urls are invalid",
+			"Error":      failed,
+		})
+		return false
+	}
+
+	return true
+}
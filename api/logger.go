@@ -0,0 +1,26 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// requestLogger is gin's access log with credentials taken out of the
+// query string. Tracking streams are opened with ?access_token= because
+// EventSource cannot send headers, and the token must not be written out.
+func requestLogger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v\n%s",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			param.StatusCode,
+			param.Latency.Round(time.Microsecond),
+			param.ClientIP,
+			param.Method,
+			utils.RedactQuery(param.Path, utils.SecretQueryParams...),
+			param.ErrorMessage,
+		)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
 	"github.com/gin-gonic/gin"
 )
 
@@ -45,3 +46,61 @@ func AuthenticatedMiddleware() gin.HandlerFunc {
 
 	}
 }
+
+// AdminMiddleware must come after AuthenticatedMiddleware.
+func AdminMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.GetString("role") != utils.AdminRole {
+			ctx.JSON(http.StatusForbidden, gin.H{
+				"message": "Forbidden: admin access required",
+			})
+			ctx.Abort()
+			return
+		}
+	}
+}
+
+// RiderMiddleware must come after AuthenticatedMiddleware.
+func RiderMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.GetString("role") != utils.RiderRole {
+			ctx.JSON(http.StatusForbidden, gin.H{
+				"message": "Forbidden: rider access required",
+			})
+			ctx.Abort()
+			return
+		}
+	}
+}
+
+// OptionalAuthMiddleware is for public endpoints that personalise their
+// response when a valid token is sent. Requests without one, or with a bad
+// one, carry on anonymously.
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tokenSplit := strings.Split(ctx.GetHeader("Authorization"), " ")
+
+		if len(tokenSplit) != 2 || strings.ToLower(tokenSplit[0]) != "bearer" {
+			return
+		}
+
+		userId, role, err := tokenManager.VerifyToken(tokenSplit[1])
+		if err != nil {
+			return
+		}
+
+		ctx.Set("id", userId)
+		ctx.Set("role", role)
+	}
+}
+
+// currentUserID returns the id AuthenticatedMiddleware stored on the context.
+func currentUserID(ctx *gin.Context) (string, bool) {
+	value, exist := ctx.Get("id")
+	if !exist {
+		return "", false
+	}
+
+	userId, ok := value.(string)
+	return userId, ok && userId != ""
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"database/sql"
+	"time"
+)
+
+// The sql.Null* types marshal as {"String": "", "Valid": false}, so responses
+// convert them to pointers which marshal as the value or null.
+
+func nullString(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+func nullInt16(value sql.NullInt16) *int16 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int16
+}
+
+func nullInt32(value sql.NullInt32) *int32 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int32
+}
+
+func nullFloat64(value sql.NullFloat64) *float64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Float64
+}
+
+func nullTime(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
@@ -0,0 +1,1273 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type Order struct {
+	server *Server
+}
+
+type CreateOrderParams struct {
+	DeliveryAddress string `json:"delivery_address" binding:"max=500"`
+	Note            string `json:"note" binding:"max=500"`
+
+	// ScheduledFor asks for delivery at a later time instead of as soon as
+	// possible.
+	ScheduledFor *time.Time `json:"scheduled_for"`
+
+	// Where to deliver, for pricing delivery by distance. Without them the
+	// flat delivery fee applies.
+	Latitude  *float64 `json:"latitude" binding:"omitempty,min=-90,max=90,required_with=Longitude"`
+	Longitude *float64 `json:"longitude" binding:"omitempty,min=-180,max=180,required_with=Latitude"`
+
+	// Tip is for the riders, split evenly across the shops' orders. It is
+	// charged with the order but paid to riders in full.
+	Tip utils.Money `json:"tip" binding:"omitempty,isPositive"`
+}
+
+type UpdateOrderStatusParams struct {
+	Status string `json:"status" binding:"required,oneof=accepted preparing ready picked_up delivered cancelled rejected"`
+	Note   string `json:"note" binding:"max=500"`
+}
+
+type CancelOrderParams struct {
+	ReasonCode string `json:"reason_code" binding:"required,oneof=changed_mind ordered_by_mistake taking_too_long wrong_address duplicate_order other"`
+	Reason     string `json:"reason" binding:"max=500"`
+	RefundTo   string `json:"refund_to" binding:"omitempty,oneof=original wallet"`
+}
+
+// orderChange is one status change. ReasonCode and RefundTo are only used
+// when an order is cancelled.
+type orderChange struct {
+	To         string
+	ActorID    string
+	Actor      string
+	Note       string
+	ReasonCode string
+	RefundTo   string
+}
+
+type OrderCancellationResponse struct {
+	db.OrderCancellation
+	CancelledBy *string `json:"cancelled_by"`
+}
+
+// CancellationQuoteResponse is what cancelling a live order would cost.
+type CancellationQuoteResponse struct {
+	Status       string       `json:"status"`
+	CanCancel    bool         `json:"can_cancel"`
+	Fee          *utils.Money `json:"fee,omitempty"`
+	RefundAmount *utils.Money `json:"refund_amount,omitempty"`
+}
+
+type OrderStatusHistoryResponse struct {
+	FromStatus *string   `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ActorRole  string    `json:"actor_role"`
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ListOrdersParams struct {
+	PageID   int32 `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32 `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type OrderItemOptionResponse struct {
+	OptionID   *string     `json:"option_id"`
+	GroupName  string      `json:"group_name"`
+	Name       string      `json:"name"`
+	PriceDelta utils.Money `json:"price_delta"`
+}
+
+type OrderItemResponse struct {
+	ID          string                    `json:"id"`
+	ProductID   *string                   `json:"product_id"`
+	ProductName string                    `json:"product_name"`
+	UnitPrice   utils.Money               `json:"unit_price"`
+	Quantity    int32                     `json:"quantity"`
+	LineTotal   utils.Money               `json:"line_total"`
+	VAT         utils.Money               `json:"vat"`
+	VATExempt   bool                      `json:"vat_exempt"`
+	Note        string                    `json:"note"`
+	AddedBy     *string                   `json:"added_by"`
+	Options     []OrderItemOptionResponse `json:"options"`
+}
+
+// OrderSummaryResponse is an order without its items, for lists.
+type OrderSummaryResponse struct {
+	db.Order
+	RiderID      *string    `json:"rider_id"`
+	ScheduledFor *time.Time `json:"scheduled_for"`
+	ReleaseAt    *time.Time `json:"release_at"`
+	GroupOrderID *string    `json:"group_order_id"`
+	CheckoutID   *string    `json:"checkout_id"`
+}
+
+type OrderResponse struct {
+	db.Order
+	RiderID      *string             `json:"rider_id"`
+	ScheduledFor *time.Time          `json:"scheduled_for"`
+	ReleaseAt    *time.Time          `json:"release_at"`
+	GroupOrderID *string             `json:"group_order_id"`
+	CheckoutID   *string             `json:"checkout_id"`
+	NextStatuses []string            `json:"next_statuses"`
+	Items        []OrderItemResponse `json:"items"`
+
+	Delivery     *OrderDeliveryResponse     `json:"delivery"`
+	Invoice      *OrderInvoiceResponse      `json:"invoice"`
+	Cancellation *OrderCancellationResponse `json:"cancellation"`
+	Tips         []db.OrderTip              `json:"tips"`
+}
+
+// OrderDeliveryResponse is how an order's delivery fee was made up.
+type OrderDeliveryResponse struct {
+	db.OrderDelivery
+	Latitude       *float64 `json:"latitude"`
+	Longitude      *float64 `json:"longitude"`
+	DistanceMeters *int32   `json:"distance_meters"`
+}
+
+// CheckoutResponse is one payment's worth of orders, one per shop.
+type CheckoutResponse struct {
+	db.Checkout
+	Orders []OrderResponse `json:"orders"`
+}
+
+// plannedOrder is a shop's order worked out before anything is written.
+type plannedOrder struct {
+	id        string
+	shop      CartShop
+	delivery  DeliveryQuoteResponse
+	tip       utils.Money
+	status    string
+	releaseAt sql.NullTime
+}
+
+var (
+	errOrderTransition    = errors.New("order status change not allowed")
+	errOrderStatusChanged = errors.New("order status changed, reload and try again")
+	errOrderTaken         = errors.New("order already has a rider")
+	errOrderUnpaid        = errors.New("order has not been paid for")
+)
+
+func (o Order) router(server *Server) {
+	o.server = server
+
+	serverGroup := server.router.Group("/orders", AuthenticatedMiddleware())
+	serverGroup.POST("", IdempotencyMiddleware(), o.createOrder)
+	serverGroup.GET("", o.listOrders)
+	serverGroup.GET("/:id", o.getOrder)
+	serverGroup.GET("/:id/history", o.getOrderHistory)
+	serverGroup.GET("/:id/cancellation", o.getCancellation)
+	serverGroup.GET("/:id/receipt.pdf", o.getReceipt)
+	serverGroup.POST("/:id/cancel", IdempotencyMiddleware(), o.cancelOrder)
+	serverGroup.POST("/:id/reorder", IdempotencyMiddleware(), o.reorder)
+	serverGroup.POST("/:id/tip", IdempotencyMiddleware(), o.tipOrder)
+
+	server.router.GET("/checkouts/:id", AuthenticatedMiddleware(), o.getCheckout)
+
+	adminGroup := server.router.Group("/admin/orders", AuthenticatedMiddleware(), AdminMiddleware())
+	adminGroup.PUT("/:id/status", o.adminUpdateOrderStatus)
+}
+
+// createOrder turns the user's cart into an order. Prices and option names
+// are copied onto the order rows so later menu changes never rewrite what the
+// customer was charged.
+func (o *Order) createOrder(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := CreateOrderParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if input.Tip.Cmp(utils.MaxTip) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": fmt.Sprintf("a tip cannot be more than %s", utils.MaxTip),
+		})
+		return
+	}
+
+	cart, err := loadCart(context.Background(), cartKey(userId))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if len(cart.Items) == 0 {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    "Your cart is empty.",
+		})
+		return
+	}
+
+	priced, err := o.server.priceCart(context.Background(), cart)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if !priced.orderable(input.ScheduledFor != nil) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    "Some items in your cart can no longer be ordered.",
+			"data":       priced,
+		})
+		return
+	}
+
+	address := strings.TrimSpace(input.DeliveryAddress)
+	if address == "" {
+		user, err := o.server.queries.GetUserById(context.Background(), userId)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"Error": err.Error(),
+			})
+			return
+		}
+		address = strings.TrimSpace(user.Address)
+	}
+	if address == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": "a delivery address is required",
+		})
+		return
+	}
+
+	scheduledFor := sql.NullTime{}
+	deliverAt := time.Now()
+	if input.ScheduledFor != nil {
+		scheduledFor = sql.NullTime{Time: *input.ScheduledFor, Valid: true}
+		deliverAt = *input.ScheduledFor
+	}
+
+	delivery, err := o.server.quoteCartDelivery(context.Background(), priced, input.Latitude, input.Longitude, deliverAt)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if !delivery.Deliverable {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    delivery.Issues[0],
+			"data":       delivery,
+		})
+		return
+	}
+
+	coupon, discount, err := o.server.discountCart(context.Background(), userId, cart.CouponCode, &priced, &delivery)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	// A coupon the customer expects must not be quietly dropped.
+	if priced.Coupon != nil && priced.Coupon.Issue != "" {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    priced.Coupon.Issue,
+			"data":       priced.Coupon,
+		})
+		return
+	}
+	couponId := sql.NullString{String: coupon.ID, Valid: priced.Coupon != nil}
+
+	// Each shop gets its own order, checked against its own lead time and
+	// opening hours.
+	planned := []plannedOrder{}
+	tips := input.Tip.Split(len(priced.Shops))
+	for i, shop := range priced.Shops {
+		order := plannedOrder{shop: shop, delivery: delivery.Shops[i], tip: tips[i], status: utils.OrderPending}
+
+		if input.ScheduledFor != nil {
+			release, ok := o.server.scheduleOrder(ctx, shop.ShopID, *input.ScheduledFor)
+			if !ok {
+				return
+			}
+
+			order.releaseAt = sql.NullTime{Time: release, Valid: true}
+
+			// Orders due soon enough go straight to the shop.
+			if release.After(time.Now()) {
+				order.status = utils.OrderScheduled
+			}
+		}
+
+		id, err := utils.NewID()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"Failed to generate CUID": err.Error(),
+			})
+			return
+		}
+		order.id = id
+
+		planned = append(planned, order)
+	}
+
+	checkoutId, err := utils.NewID()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Failed to generate CUID": err.Error(),
+		})
+		return
+	}
+
+	var checkout db.Checkout
+	orders := []db.Order{}
+
+	err = o.server.execTx(ctx, func(q *db.Queries) error {
+		var err error
+
+		checkout, err = q.CreateCheckout(ctx, db.CreateCheckoutParams{
+			ID:              checkoutId,
+			UserID:          userId,
+			Subtotal:        priced.Subtotal,
+			DeliveryFee:     delivery.DeliveryFee,
+			Total:           delivery.Total.Add(input.Tip),
+			DeliveryAddress: address,
+			Note:            strings.TrimSpace(input.Note),
+			ServiceCharge:   priced.ServiceCharge,
+			Vat:             priced.VAT,
+			Tip:             input.Tip,
+			Discount:        discount.Total,
+			CouponID:        couponId,
+		})
+		if err != nil {
+			return err
+		}
+
+		if couponId.Valid {
+			if err := redeemCoupon(ctx, q, coupon, userId, checkout.ID, discount.Total); err != nil {
+				return err
+			}
+		}
+
+		for _, next := range planned {
+			order, err := q.CreateOrder(ctx, db.CreateOrderParams{
+				ID:              next.id,
+				UserID:          userId,
+				ShopID:          next.shop.ShopID,
+				Subtotal:        next.shop.Subtotal,
+				Total:           next.shop.Total.Add(next.delivery.Fee).Add(next.tip).Sub(next.shop.Discount),
+				DeliveryAddress: address,
+				Note:            strings.TrimSpace(input.Note),
+				Status:          next.status,
+				ScheduledFor:    scheduledFor,
+				ReleaseAt:       next.releaseAt,
+				CheckoutID:      sql.NullString{String: checkout.ID, Valid: true},
+				DeliveryFee:     next.delivery.Fee,
+				ServiceCharge:   next.shop.ServiceCharge,
+				Vat:             next.shop.VAT,
+				Tip:             next.tip,
+				Discount:        next.shop.Discount,
+				CouponID:        couponId,
+			})
+			if err != nil {
+				return err
+			}
+
+			if err := recordTip(ctx, q, order, utils.TipAtCheckout); err != nil {
+				return err
+			}
+
+			if err := recordDelivery(ctx, q, order.ID, next.delivery, input.Latitude, input.Longitude); err != nil {
+				return err
+			}
+
+			if err := issueInvoice(ctx, q, order, next.shop.tax); err != nil {
+				return err
+			}
+
+			if err := createOrderItems(ctx, q, order.ID, userId, priced.shopLines(next.shop.ShopID)); err != nil {
+				return err
+			}
+
+			if err := recordOrderStatus(ctx, q, order.ID, "", next.status, userId, utils.ActorCustomer, ""); err != nil {
+				return err
+			}
+
+			orders = append(orders, order)
+		}
+		return nil
+	})
+	if issue := couponIssue(err); issue != "" {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    issue,
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	// The order is already placed; a stale cart is only an annoyance.
+	if err := clearCart(context.Background(), cartKey(userId)); err != nil {
+		log.Printf("could not clear cart for user %s: %v", userId, err)
+	}
+
+	response, err := o.server.checkoutResponse(context.Background(), checkout, orders)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"statusCode": http.StatusCreated,
+		"status":     "success",
+		"message":    "order placed successfully",
+		"data":       response,
+	})
+}
+
+// getCheckout shows a checkout with every shop's order in it.
+func (o *Order) getCheckout(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	checkout, err := o.server.queries.GetCheckout(context.Background(), ctx.Param("id"))
+	if err == sql.ErrNoRows || (err == nil && checkout.UserID != userId) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"message":    "The requested checkout does not exist.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	orders, err := o.server.queries.ListCheckoutOrders(context.Background(), sql.NullString{String: checkout.ID, Valid: true})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	response, err := o.server.checkoutResponse(context.Background(), checkout, orders)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "checkout fetched successfully",
+		"data":       response,
+	})
+}
+
+func (o *Order) listOrders(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := ListOrdersParams{}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	limit, offset := pagination(input.PageID, input.PageSize)
+
+	orders, err := o.server.queries.ListUserOrders(context.Background(), db.ListUserOrdersParams{
+		UserID: userId,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "orders fetched successfully",
+		"data":       newOrderSummaries(orders),
+	})
+}
+
+func (o *Order) getOrder(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	order, ok := o.server.customerOrder(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	response, err := o.server.orderResponse(context.Background(), order, utils.ActorCustomer)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "order fetched successfully",
+		"data":       response,
+	})
+}
+
+func (o *Order) getOrderHistory(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	order, ok := o.server.customerOrder(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	history, err := o.server.queries.ListOrderStatusHistory(context.Background(), order.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	response := []OrderStatusHistoryResponse{}
+	for _, entry := range history {
+		response = append(response, OrderStatusHistoryResponse{
+			FromStatus: nullString(entry.FromStatus),
+			ToStatus:   entry.ToStatus,
+			ActorRole:  entry.ActorRole,
+			Note:       entry.Note,
+			CreatedAt:  entry.CreatedAt,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "order history fetched successfully",
+		"data":       response,
+	})
+}
+
+func (o *Order) cancelOrder(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := CancelOrderParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	order, ok := o.server.customerOrder(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	o.server.advanceOrder(ctx, order, orderChange{
+		To:         utils.OrderCancelled,
+		ActorID:    userId,
+		Actor:      utils.ActorCustomer,
+		Note:       input.Reason,
+		ReasonCode: input.ReasonCode,
+		RefundTo:   input.RefundTo,
+	})
+}
+
+func (o *Order) adminUpdateOrderStatus(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := UpdateOrderStatusParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	order, err := o.server.queries.GetOrder(context.Background(), ctx.Param("id"))
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"Error":      err.Error(),
+			"message":    "The requested order does not exist.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	o.server.advanceOrder(ctx, order, orderChange{To: input.Status, ActorID: userId, Actor: utils.ActorAdmin, Note: input.Note})
+}
+
+// getCancellation shows how an order was cancelled, or, while it is still
+// live, what cancelling it now would cost.
+func (o *Order) getCancellation(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	order, ok := o.server.customerOrder(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	if order.Status == utils.OrderCancelled {
+		cancellation, err := o.server.queries.GetOrderCancellation(context.Background(), order.ID)
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"statusCode": http.StatusNotFound,
+				"Error":      err.Error(),
+				"message":    "This order was cancelled before cancellation details were kept.",
+			})
+			return
+		} else if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"Error": err.Error(),
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"statusCode": http.StatusOK,
+			"status":     "success",
+			"message":    "order cancellation fetched successfully",
+			"data":       newOrderCancellationResponse(cancellation),
+		})
+		return
+	}
+
+	quote := CancellationQuoteResponse{Status: order.Status}
+	fee, refund, err := o.server.cancellationTerms(order, utils.ActorCustomer)
+	if err != nil && !errors.Is(err, errOrderTransition) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+	if err == nil && utils.CanTransitionOrder(order.Status, utils.OrderCancelled, utils.ActorCustomer) {
+		quote.CanCancel = true
+		quote.Fee = &fee
+		quote.RefundAmount = &refund
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "cancellation terms fetched successfully",
+		"data":       quote,
+	})
+}
+
+// advanceOrder moves an order to a new status on behalf of change.Actor and
+// writes the response.
+func (s *Server) advanceOrder(ctx *gin.Context, order db.Order, change orderChange) {
+	to, actor := change.To, change.Actor
+	order, err := s.transitionOrder(ctx, order, change)
+	switch {
+	case errors.Is(err, errOrderTransition):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    fmt.Sprintf("An order that is %s cannot be moved to %s.", order.Status, to),
+			"data": gin.H{
+				"status":        order.Status,
+				"next_statuses": utils.NextOrderStatuses(order.Status, actor),
+			},
+		})
+		return
+	case errors.Is(err, errOrderUnpaid):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    "This order has not been paid for yet.",
+		})
+		return
+	case errors.Is(err, errOrderStatusChanged), errors.Is(err, errOrderTaken):
+		ctx.JSON(http.StatusConflict, gin.H{
+			"statusCode": http.StatusConflict,
+			"message":    err.Error(),
+		})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	response, err := s.orderResponse(context.Background(), order, actor)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"statusCode": http.StatusAccepted,
+		"status":     "success",
+		"message":    "order status updated successfully",
+		"data":       response,
+	})
+}
+
+// transitionOrder checks the change against the transition table and applies
+// it with its history row in one transaction. The update only matches while
+// the order still has the status the caller saw, so two people acting on the
+// same order cannot both win. Cancellations also record the fee and open
+// the refund owed, which is sent once the change has committed. No shop
+// starts on an order, and no scheduled order is released to one, before it
+// is paid for.
+func (s *Server) transitionOrder(ctx context.Context, order db.Order, change orderChange) (db.Order, error) {
+	to, actorId, actor, note := change.To, change.ActorID, change.Actor, change.Note
+	if !utils.CanTransitionOrder(order.Status, to, actor) {
+		return order, errOrderTransition
+	}
+	released := order.Status == utils.OrderScheduled && to == utils.OrderPending
+	if (to == utils.OrderAccepted || released) && !orderPaid(order) {
+		return order, errOrderUnpaid
+	}
+
+	var fee, refund utils.Money
+	if to == utils.OrderCancelled {
+		var err error
+		fee, refund, err = s.cancellationTerms(order, actor)
+		if err != nil {
+			return order, err
+		}
+	}
+
+	updated := order
+	var opened db.Refund
+	err := s.execTx(ctx, func(q *db.Queries) error {
+		var err error
+
+		if to == utils.OrderPickedUp && actor == utils.ActorRider {
+			_, err = q.AssignOrderRider(ctx, db.AssignOrderRiderParams{
+				ID:      order.ID,
+				RiderID: sql.NullString{String: actorId, Valid: true},
+			})
+			if err == sql.ErrNoRows {
+				return errOrderTaken
+			} else if err != nil {
+				return err
+			}
+		}
+
+		updated, err = q.UpdateOrderStatus(ctx, db.UpdateOrderStatusParams{
+			ToStatus:   to,
+			ID:         order.ID,
+			FromStatus: order.Status,
+		})
+		if err == sql.ErrNoRows {
+			return errOrderStatusChanged
+		} else if err != nil {
+			return err
+		}
+
+		if err := recordOrderStatus(ctx, q, order.ID, order.Status, to, actorId, actor, strings.TrimSpace(note)); err != nil {
+			return err
+		}
+
+		switch to {
+		case utils.OrderCancelled:
+			if err := recordCancellation(ctx, q, order, change, fee, refund); err != nil {
+				return err
+			}
+			opened, err = openCancellationRefund(ctx, q, order, change, refund)
+			if err != nil {
+				return err
+			}
+			return releaseCoupon(ctx, q, updated)
+		case utils.OrderRejected:
+			return releaseCoupon(ctx, q, updated)
+		case utils.OrderDelivered:
+			return s.recordVendorEarning(ctx, q, updated)
+		}
+		return nil
+	})
+	if err != nil {
+		return order, err
+	}
+
+	if opened.Status == utils.RefundPending {
+		go func() {
+			if _, err := s.sendRefund(context.Background(), opened); err != nil {
+				log.Printf("could not send refund for order %s: %v", order.ID, err)
+			}
+		}()
+	}
+
+	publishOrderStatus(updated)
+	if updated.Status == utils.OrderDelivered {
+		go s.emailReceipt(updated)
+	}
+	return updated, nil
+}
+
+// cancellationTerms works out the fee and refund for cancelling order now.
+// Only customers pay a fee: when the shop, support or the system cancels,
+// everything is refunded.
+func (s *Server) cancellationTerms(order db.Order, actor string) (utils.Money, utils.Money, error) {
+	if actor != utils.ActorCustomer {
+		return utils.Kobo(0), order.Total, nil
+	}
+
+	fee, ok := s.cancellation.Fee(order.Status, order.Subtotal)
+	if !ok {
+		return utils.Money{}, utils.Money{}, errOrderTransition
+	}
+	return fee, order.Total.Sub(fee), nil
+}
+
+func recordCancellation(ctx context.Context, q *db.Queries, order db.Order, change orderChange, fee, refund utils.Money) error {
+	reason := change.ReasonCode
+	if reason == "" {
+		switch change.Actor {
+		case utils.ActorVendor:
+			reason = utils.CancelByVendor
+		case utils.ActorAdmin:
+			reason = utils.CancelByAdmin
+		case utils.ActorSystem:
+			reason = utils.CancelBySystem
+		default:
+			reason = utils.CancelOther
+		}
+	}
+
+	refundTo := change.RefundTo
+	if refundTo == "" {
+		refundTo = utils.RefundToOriginal
+	}
+
+	// Nothing was taken from an unpaid order, so nothing goes back.
+	refundStatus := utils.RefundNotRequired
+	if refund.Cmp(utils.Kobo(0)) > 0 && refundable(order) {
+		refundStatus = utils.RefundPending
+	}
+
+	_, err := q.CreateOrderCancellation(ctx, db.CreateOrderCancellationParams{
+		OrderID:      order.ID,
+		CancelledBy:  sql.NullString{String: change.ActorID, Valid: change.ActorID != ""},
+		ActorRole:    change.Actor,
+		ReasonCode:   reason,
+		Note:         strings.TrimSpace(change.Note),
+		Fee:          fee,
+		RefundAmount: refund,
+		RefundTo:     refundTo,
+		RefundStatus: refundStatus,
+	})
+	return err
+}
+
+// openCancellationRefund opens the refund a cancellation owes a paid
+// order. The policy already decided the amount, so it needs no approval.
+func openCancellationRefund(ctx context.Context, q *db.Queries, order db.Order, change orderChange, amount utils.Money) (db.Refund, error) {
+	if amount.Cmp(utils.Kobo(0)) <= 0 || !refundable(order) {
+		return db.Refund{}, nil
+	}
+
+	refundTo := change.RefundTo
+	if refundTo == "" {
+		refundTo = utils.RefundToOriginal
+	}
+
+	req := newRefund{
+		order:       order,
+		source:      utils.RefundForCancellation,
+		requestedBy: change.ActorID,
+		reason:      strings.TrimSpace(change.Note),
+		amount:      &amount,
+		refundTo:    refundTo,
+	}
+
+	// Support may already have given some of it back; then whatever is
+	// left goes, if anything is.
+	refund, err := openRefund(ctx, q, req)
+	var refundErr refundError
+	if errors.As(err, &refundErr) {
+		req.amount = nil
+		refund, err = openRefund(ctx, q, req)
+		if errors.As(err, &refundErr) {
+			return db.Refund{}, nil
+		}
+	}
+	return refund, err
+}
+
+// refundable reports whether money was taken for order that could still
+// be given back.
+func refundable(order db.Order) bool {
+	return orderPaid(order)
+}
+
+// orderPaid reports whether the customer has paid for order and kept at
+// least part of it.
+func orderPaid(order db.Order) bool {
+	return order.PaymentStatus == utils.OrderPaid || order.PaymentStatus == utils.OrderPartiallyRefunded
+}
+
+func recordOrderStatus(ctx context.Context, q *db.Queries, orderId, from, to, actorId, actor, note string) error {
+	id, err := utils.NewID()
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateOrderStatusHistory(ctx, db.CreateOrderStatusHistoryParams{
+		ID:         id,
+		OrderID:    orderId,
+		FromStatus: sql.NullString{String: from, Valid: from != ""},
+		ToStatus:   to,
+		ActorID:    sql.NullString{String: actorId, Valid: actorId != ""},
+		ActorRole:  actor,
+		Note:       note,
+	})
+	return err
+}
+
+// scheduleOrder checks a requested delivery time against the shop's lead time
+// and opening hours, and works out when the order should reach the shop.
+func (s *Server) scheduleOrder(ctx *gin.Context, shopId string, when time.Time) (time.Time, bool) {
+	shop, err := s.queries.GetShop(context.Background(), shopId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return when, false
+	}
+
+	hours, err := s.queries.ListShopHours(context.Background(), shop.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return when, false
+	}
+
+	now := time.Now()
+	leadTime := time.Duration(shop.LeadTimeMinutes) * time.Minute
+
+	reason := ""
+	switch {
+	case when.Before(now.Add(leadTime)):
+		reason = fmt.Sprintf("%s needs at least %d minutes' notice.", shop.Name, shop.LeadTimeMinutes)
+	case when.After(now.Add(utils.MaxScheduleAhead)):
+		reason = "Orders can be scheduled at most 7 days ahead."
+	case !utils.IsOpenAt(when.In(s.location), openingHours(hours)):
+		reason = fmt.Sprintf("%s is closed at the requested time.", shop.Name)
+	}
+	if reason != "" {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    reason,
+		})
+		return when, false
+	}
+
+	// The shop always gets at least its lead time.
+	release := s.release
+	if leadTime > release {
+		release = leadTime
+	}
+	return when.Add(-release), true
+}
+
+// customerOrder loads an order placed by userId. Orders belonging to someone
+// else are reported as missing so ids cannot be probed.
+func (s *Server) customerOrder(ctx *gin.Context, orderId, userId string) (db.Order, bool) {
+	order, err := s.queries.GetOrder(context.Background(), orderId)
+	if err == nil && order.UserID != userId {
+		err = sql.ErrNoRows
+	}
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"Error":      err.Error(),
+			"message":    "The requested order does not exist.",
+		})
+		return order, false
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return order, false
+	}
+
+	return order, true
+}
+
+// createOrderItems snapshots priced cart lines onto an order.
+func createOrderItems(ctx context.Context, q *db.Queries, orderId, addedBy string, lines []CartLine) error {
+	for _, line := range lines {
+		itemId, err := utils.NewID()
+		if err != nil {
+			return err
+		}
+
+		item, err := q.CreateOrderItem(ctx, db.CreateOrderItemParams{
+			ID:          itemId,
+			OrderID:     orderId,
+			ProductID:   sql.NullString{String: line.ProductID, Valid: true},
+			ProductName: line.Name,
+			UnitPrice:   line.UnitPrice,
+			Quantity:    line.Quantity,
+			LineTotal:   line.LineTotal,
+			Note:        line.Note,
+			AddedBy:     sql.NullString{String: addedBy, Valid: addedBy != ""},
+			Vat:         line.VAT,
+			VatExempt:   line.VATExempt,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, option := range line.Options {
+			optionId, err := utils.NewID()
+			if err != nil {
+				return err
+			}
+
+			_, err = q.CreateOrderItemOption(ctx, db.CreateOrderItemOptionParams{
+				ID:          optionId,
+				OrderItemID: item.ID,
+				OptionID:    sql.NullString{String: option.ID, Valid: true},
+				GroupName:   option.GroupName,
+				Name:        option.Name,
+				PriceDelta:  option.PriceDelta,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func newOrderSummaries(orders []db.Order) []OrderSummaryResponse {
+	summaries := []OrderSummaryResponse{}
+	for _, order := range orders {
+		summaries = append(summaries, OrderSummaryResponse{
+			Order:        order,
+			RiderID:      nullString(order.RiderID),
+			ScheduledFor: nullTime(order.ScheduledFor),
+			ReleaseAt:    nullTime(order.ReleaseAt),
+			GroupOrderID: nullString(order.GroupOrderID),
+			CheckoutID:   nullString(order.CheckoutID),
+		})
+	}
+	return summaries
+}
+
+// orderResponse loads an order's items. viewer is the actor looking at it and
+// decides which next statuses are offered.
+func (s *Server) orderResponse(ctx context.Context, order db.Order, viewer string) (OrderResponse, error) {
+	response := OrderResponse{
+		Order:        order,
+		RiderID:      nullString(order.RiderID),
+		ScheduledFor: nullTime(order.ScheduledFor),
+		ReleaseAt:    nullTime(order.ReleaseAt),
+		GroupOrderID: nullString(order.GroupOrderID),
+		CheckoutID:   nullString(order.CheckoutID),
+		NextStatuses: utils.NextOrderStatuses(order.Status, viewer),
+		Items:        []OrderItemResponse{},
+	}
+
+	delivery, err := s.queries.GetOrderDelivery(ctx, order.ID)
+	if err != nil && err != sql.ErrNoRows {
+		return response, err
+	} else if err == nil {
+		response.Delivery = &OrderDeliveryResponse{
+			OrderDelivery:  delivery,
+			Latitude:       nullFloat64(delivery.Latitude),
+			Longitude:      nullFloat64(delivery.Longitude),
+			DistanceMeters: nullInt32(delivery.DistanceMeters),
+		}
+	}
+
+	invoice, err := s.queries.GetOrderInvoice(ctx, order.ID)
+	if err != nil && err != sql.ErrNoRows {
+		return response, err
+	} else if err == nil {
+		response.Invoice = &OrderInvoiceResponse{
+			OrderInvoice:  invoice,
+			InvoiceNumber: utils.InvoiceNumber(invoice.Number),
+		}
+	}
+
+	if order.Status == utils.OrderCancelled {
+		cancellation, err := s.queries.GetOrderCancellation(ctx, order.ID)
+		if err != nil && err != sql.ErrNoRows {
+			return response, err
+		} else if err == nil {
+			cancelled := newOrderCancellationResponse(cancellation)
+			response.Cancellation = &cancelled
+		}
+	}
+
+	tips, err := s.queries.ListOrderTips(ctx, order.ID)
+	if err != nil {
+		return response, err
+	}
+	response.Tips = tips
+
+	items, err := s.queries.ListOrderItems(ctx, order.ID)
+	if err != nil {
+		return response, err
+	}
+
+	itemIds := []string{}
+	for _, item := range items {
+		itemIds = append(itemIds, item.ID)
+	}
+
+	options, err := s.queries.ListOrderItemOptions(ctx, itemIds)
+	if err != nil {
+		return response, err
+	}
+
+	optionsByItem := map[string][]OrderItemOptionResponse{}
+	for _, option := range options {
+		optionsByItem[option.OrderItemID] = append(optionsByItem[option.OrderItemID], OrderItemOptionResponse{
+			OptionID:   nullString(option.OptionID),
+			GroupName:  option.GroupName,
+			Name:       option.Name,
+			PriceDelta: option.PriceDelta,
+		})
+	}
+
+	for _, item := range items {
+		itemOptions := optionsByItem[item.ID]
+		if itemOptions == nil {
+			itemOptions = []OrderItemOptionResponse{}
+		}
+
+		response.Items = append(response.Items, OrderItemResponse{
+			ID:          item.ID,
+			ProductID:   nullString(item.ProductID),
+			ProductName: item.ProductName,
+			UnitPrice:   item.UnitPrice,
+			Quantity:    item.Quantity,
+			LineTotal:   item.LineTotal,
+			VAT:         item.Vat,
+			VATExempt:   item.VatExempt,
+			Note:        item.Note,
+			AddedBy:     nullString(item.AddedBy),
+			Options:     itemOptions,
+		})
+	}
+
+	return response, nil
+}
+
+func newOrderCancellationResponse(cancellation db.OrderCancellation) OrderCancellationResponse {
+	return OrderCancellationResponse{
+		OrderCancellation: cancellation,
+		CancelledBy:       nullString(cancellation.CancelledBy),
+	}
+}
+
+func (s *Server) checkoutResponse(ctx context.Context, checkout db.Checkout, orders []db.Order) (CheckoutResponse, error) {
+	response := CheckoutResponse{
+		Checkout: checkout,
+		Orders:   []OrderResponse{},
+	}
+
+	for _, order := range orders {
+		orderResponse, err := s.orderResponse(ctx, order, utils.ActorCustomer)
+		if err != nil {
+			return response, err
+		}
+		response.Orders = append(response.Orders, orderResponse)
+	}
+	return response, nil
+}
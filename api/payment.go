@@ -0,0 +1,871 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type Payment struct {
+	server *Server
+}
+
+// CreatePaymentParams names what is being paid for. Exactly one is set: a
+// checkout, an order placed without one (group orders), or a tip added
+// after delivery. Method "wallet" pays from the wallet balance at once
+// instead of through the provider.
+type CreatePaymentParams struct {
+	CheckoutID string `json:"checkout_id" binding:"max=50"`
+	OrderID    string `json:"order_id" binding:"max=50"`
+	TipID      string `json:"tip_id" binding:"max=50"`
+	Method     string `json:"method" binding:"omitempty,oneof=provider wallet"`
+}
+
+type PaymentResponse struct {
+	db.Payment
+	CheckoutID *string    `json:"checkout_id"`
+	OrderID    *string    `json:"order_id"`
+	TipID      *string    `json:"tip_id"`
+	WalletID   *string    `json:"wallet_account_id"`
+	PaidAt     *time.Time `json:"paid_at"`
+}
+
+// paymentTarget is what a new payment is for and how much it is.
+type paymentTarget struct {
+	checkoutID sql.NullString
+	orderID    sql.NullString
+	tipID      sql.NullString
+	walletID   sql.NullString
+	amount     utils.Money
+}
+
+var (
+	errPaymentTarget = errors.New("set exactly one of checkout_id, order_id or tip_id")
+	errAlreadyPaid   = errors.New("already paid for")
+)
+
+// paymentInProgress is a payment for the same thing that the customer may
+// still be completing.
+type paymentInProgress struct {
+	payment db.Payment
+}
+
+func (e paymentInProgress) Error() string {
+	return "a payment is already in progress"
+}
+
+func (p Payment) router(server *Server) {
+	p.server = server
+
+	serverGroup := server.router.Group("/payments")
+	serverGroup.POST("", AuthenticatedMiddleware(), IdempotencyMiddleware(), p.createPayment)
+	serverGroup.GET("", AuthenticatedMiddleware(), p.listPayments)
+	serverGroup.GET("/:id", AuthenticatedMiddleware(), p.getPayment)
+	serverGroup.POST("/webhook/:provider", p.webhook)
+
+	// The fake provider has no payment page, so its authorization URL
+	// settles the payment straight away, for the customer who made it.
+	if _, ok := server.payments.(*utils.FakePaymentProvider); ok {
+		serverGroup.GET("/fake/:reference", AuthenticatedMiddleware(), p.completeFakePayment)
+	}
+}
+
+// createPayment starts a payment with the configured provider and returns
+// the page to send the customer to.
+func (p *Payment) createPayment(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := CreatePaymentParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	target, ok := p.server.paymentTarget(ctx, input, userId)
+	if !ok {
+		return
+	}
+
+	// A payment the customer walked away from has usually failed at the
+	// provider by now, which lets them start again straight away.
+	pending, err := p.server.queries.GetPendingPayment(context.Background(), db.GetPendingPaymentParams{
+		CheckoutID: target.checkoutID,
+		OrderID:    target.orderID,
+		TipID:      target.tipID,
+	})
+	if err == nil {
+		if _, err := p.server.settlePayment(context.Background(), pending); err != nil {
+			log.Printf("could not check payment %s: %v", pending.ID, err)
+		}
+	}
+
+	if input.Method == utils.WalletProvider {
+		p.server.payFromWallet(ctx, userId, target)
+		return
+	}
+
+	p.server.startPayment(ctx, userId, target)
+}
+
+// startPayment records a new payment and opens it with the provider,
+// responding with the page to send the customer to.
+func (s *Server) startPayment(ctx *gin.Context, userId string, target paymentTarget) {
+	user, err := s.queries.GetUserById(context.Background(), userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	id, err := utils.NewID()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Failed to generate CUID": err.Error(),
+		})
+		return
+	}
+
+	var payment db.Payment
+	err = s.execTx(context.Background(), func(q *db.Queries) error {
+		if err := checkPayable(context.Background(), q, target); err != nil {
+			return err
+		}
+
+		var err error
+		payment, err = q.CreatePayment(context.Background(), newPaymentParams(id, userId, s.payments.Name(), target))
+		return err
+	})
+	if respondUnpayable(ctx, err) {
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	session, err := s.payments.Initialize(context.Background(), utils.PaymentRequest{
+		Reference:   payment.Reference,
+		Amount:      payment.Amount,
+		Email:       user.Email,
+		CallbackURL: s.config2.PaymentCallbackURL,
+		Metadata:    map[string]string{"payment_id": payment.ID},
+	})
+	if err != nil {
+		// The attempt is kept, failed, so it shows up in the history.
+		if _, settleErr := s.queries.SettlePayment(context.Background(), db.SettlePaymentParams{
+			ID:            payment.ID,
+			Status:        utils.PaymentFailed,
+			FailureReason: err.Error(),
+		}); settleErr != nil {
+			log.Printf("could not fail payment %s: %v", payment.ID, settleErr)
+		}
+		ctx.JSON(http.StatusBadGateway, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	payment, err = s.queries.SetPaymentAuthorization(context.Background(), db.SetPaymentAuthorizationParams{
+		ID:               payment.ID,
+		AuthorizationUrl: session.AuthorizationURL,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"statusCode": http.StatusCreated,
+		"status":     "success",
+		"message":    "payment started successfully",
+		"data":       newPaymentResponse(payment),
+	})
+}
+
+func (p *Payment) listPayments(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := ListOrdersParams{}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	limit, offset := pagination(input.PageID, input.PageSize)
+
+	payments, err := p.server.queries.ListUserPayments(context.Background(), db.ListUserPaymentsParams{
+		UserID: userId,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	response := []PaymentResponse{}
+	for _, payment := range payments {
+		response = append(response, newPaymentResponse(payment))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "payments fetched successfully",
+		"data":       response,
+	})
+}
+
+// getPayment is where the customer lands after paying. A payment still
+// pending here is checked with the provider, in case its webhook has not
+// arrived yet.
+func (p *Payment) getPayment(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	payment, err := p.server.queries.GetPayment(context.Background(), ctx.Param("id"))
+	if err == nil && payment.UserID != userId {
+		err = sql.ErrNoRows
+	}
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"message":    "The requested payment does not exist.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if payment.Status == utils.PaymentPending {
+		settled, err := p.server.settlePayment(context.Background(), payment)
+		if err != nil {
+			log.Printf("could not check payment %s: %v", payment.ID, err)
+		} else {
+			payment = settled
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "payment fetched successfully",
+		"data":       newPaymentResponse(payment),
+	})
+}
+
+// webhook takes payment notifications from the provider. Anything that
+// fails the signature check is rejected; anything that is not about one of
+// our payments is acknowledged and ignored so the provider stops retrying.
+// Notifications are often sent more than once, and settling a payment that
+// is already settled does nothing.
+func (p *Payment) webhook(ctx *gin.Context) {
+	if ctx.Param("provider") != p.server.payments.Name() {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"message":    "Unknown payment provider.",
+		})
+		return
+	}
+
+	body, err := ctx.GetRawData()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	reference, err := p.server.payments.Webhook(ctx.Request.Header, body)
+	if errors.Is(err, utils.ErrInvalidSignature) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if reference != "" {
+		payment, err := p.server.queries.GetPaymentByReference(context.Background(), reference)
+		if err != nil && err != sql.ErrNoRows {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"Error": err.Error(),
+			})
+			return
+		}
+
+		if err == nil && payment.Status == utils.PaymentPending {
+			if _, err := p.server.settlePayment(context.Background(), payment); err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{
+					"Error": err.Error(),
+				})
+				return
+			}
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+	})
+}
+
+// completeFakePayment plays the part of the provider's payment page in
+// development. Passing ?fail=true declines the payment instead.
+func (p *Payment) completeFakePayment(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	fake := p.server.payments.(*utils.FakePaymentProvider)
+
+	payment, err := p.server.queries.GetPaymentByReference(context.Background(), ctx.Param("reference"))
+	if err == sql.ErrNoRows || (err == nil && payment.UserID != userId) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"message":    "The requested payment does not exist.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if err := fake.Complete(payment.Reference, ctx.Query("fail") != "true"); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"Error":      err.Error(),
+		})
+		return
+	}
+
+	payment, err = p.server.settlePayment(context.Background(), payment)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "payment completed",
+		"data":       newPaymentResponse(payment),
+	})
+}
+
+// paymentTarget checks that what the customer wants to pay for is theirs
+// and still payable, and works out the amount.
+func (s *Server) paymentTarget(ctx *gin.Context, input CreatePaymentParams, userId string) (paymentTarget, bool) {
+	target := paymentTarget{}
+
+	set := 0
+	for _, id := range []string{input.CheckoutID, input.OrderID, input.TipID} {
+		if id != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": errPaymentTarget.Error(),
+		})
+		return target, false
+	}
+
+	switch {
+	case input.CheckoutID != "":
+		checkout, err := s.queries.GetCheckout(context.Background(), input.CheckoutID)
+		if err == sql.ErrNoRows || (err == nil && checkout.UserID != userId) {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"statusCode": http.StatusNotFound,
+				"message":    "The requested checkout does not exist.",
+			})
+			return target, false
+		} else if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"Error": err.Error(),
+			})
+			return target, false
+		}
+
+		target.checkoutID = sql.NullString{String: checkout.ID, Valid: true}
+
+		// The checkout total covers every order in it, so it cannot be
+		// paid once any of them has been called off.
+		orders, err := s.queries.ListCheckoutOrders(context.Background(), target.checkoutID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"Error": err.Error(),
+			})
+			return target, false
+		}
+		for _, order := range orders {
+			if order.Status == utils.OrderCancelled || order.Status == utils.OrderRejected {
+				ctx.JSON(http.StatusConflict, gin.H{
+					"statusCode": http.StatusConflict,
+					"message":    "This checkout can no longer be paid for.",
+				})
+				return target, false
+			}
+		}
+
+		target.amount = checkout.Total
+
+	case input.OrderID != "":
+		order, ok := s.customerOrder(ctx, input.OrderID, userId)
+		if !ok {
+			return target, false
+		}
+
+		if order.CheckoutID.Valid {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"Error": "this order is paid for through its checkout",
+			})
+			return target, false
+		}
+		if order.Status == utils.OrderCancelled || order.Status == utils.OrderRejected {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"statusCode": http.StatusConflict,
+				"message":    "This order can no longer be paid for.",
+			})
+			return target, false
+		}
+
+		target.orderID = sql.NullString{String: order.ID, Valid: true}
+		target.amount = order.Total
+
+	default:
+		tip, err := s.queries.GetOrderTip(context.Background(), input.TipID)
+		if err == sql.ErrNoRows || (err == nil && tip.UserID != userId) {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"statusCode": http.StatusNotFound,
+				"message":    "The requested tip does not exist.",
+			})
+			return target, false
+		} else if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"Error": err.Error(),
+			})
+			return target, false
+		}
+
+		// Tips chosen at checkout are paid with the checkout.
+		if tip.Stage != utils.TipAfterDelivery || tip.Status != utils.TipPending {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"statusCode": http.StatusConflict,
+				"message":    "This tip cannot be paid for on its own.",
+			})
+			return target, false
+		}
+
+		target.tipID = sql.NullString{String: tip.ID, Valid: true}
+		target.amount = tip.Amount
+	}
+
+	if !target.amount.IsZero() {
+		return target, true
+	}
+
+	ctx.JSON(http.StatusConflict, gin.H{
+		"statusCode": http.StatusConflict,
+		"message":    "There is nothing to pay.",
+	})
+	return target, false
+}
+
+// checkPayable holds the checkout, order or tip being paid for until the
+// transaction ends, and makes sure no other payment has paid for it or is
+// still in the middle of doing so. Top-ups can always be paid.
+func checkPayable(ctx context.Context, q *db.Queries, target paymentTarget) error {
+	if !target.checkoutID.Valid && !target.orderID.Valid && !target.tipID.Valid {
+		return nil
+	}
+
+	if err := lockPaymentTarget(ctx, q, target); err != nil {
+		return err
+	}
+
+	paid, err := q.CountSucceededPayments(ctx, db.CountSucceededPaymentsParams{
+		CheckoutID: target.checkoutID,
+		OrderID:    target.orderID,
+		TipID:      target.tipID,
+	})
+	if err != nil {
+		return err
+	}
+	if paid > 0 {
+		return errAlreadyPaid
+	}
+
+	pending, err := q.GetPendingPayment(ctx, db.GetPendingPaymentParams{
+		CheckoutID: target.checkoutID,
+		OrderID:    target.orderID,
+		TipID:      target.tipID,
+	})
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if time.Since(pending.CreatedAt) < utils.PaymentInProgressFor {
+		return paymentInProgress{payment: pending}
+	}
+	return nil
+}
+
+// respondUnpayable answers for the errors checkPayable gives and reports
+// whether it did. A payment in progress is sent back so the customer can
+// carry on with it.
+func respondUnpayable(ctx *gin.Context, err error) bool {
+	var inProgress paymentInProgress
+	switch {
+	case errors.Is(err, errAlreadyPaid):
+		ctx.JSON(http.StatusConflict, gin.H{
+			"statusCode": http.StatusConflict,
+			"message":    "This has already been paid for.",
+		})
+	case errors.As(err, &inProgress):
+		ctx.JSON(http.StatusConflict, gin.H{
+			"statusCode": http.StatusConflict,
+			"message":    "A payment for this is already in progress.",
+			"data":       newPaymentResponse(inProgress.payment),
+		})
+	default:
+		return false
+	}
+	return true
+}
+
+// lockPaymentTarget holds the checkout, order or tip being paid for until
+// the transaction ends.
+func lockPaymentTarget(ctx context.Context, q *db.Queries, target paymentTarget) error {
+	var err error
+	switch {
+	case target.checkoutID.Valid:
+		_, err = q.LockCheckout(ctx, target.checkoutID.String)
+	case target.orderID.Valid:
+		_, err = q.LockOrder(ctx, target.orderID.String)
+	case target.tipID.Valid:
+		_, err = q.LockOrderTip(ctx, target.tipID.String)
+	}
+	return err
+}
+
+// settlePayment asks the provider how a pending payment went and records
+// the outcome along with what it paid for. A payment is only ever settled
+// once; if another request got there first its result is returned. A
+// payment that succeeds for something another payment already paid for is
+// flagged as a duplicate and refunded in full.
+func (s *Server) settlePayment(ctx context.Context, payment db.Payment) (db.Payment, error) {
+	result, err := s.payments.Verify(ctx, payment.Reference)
+	if err != nil {
+		return payment, err
+	}
+	if result.Status == utils.PaymentPending {
+		return payment, nil
+	}
+
+	settle := db.SettlePaymentParams{
+		ID:                payment.ID,
+		Status:            result.Status,
+		ProviderReference: result.ProviderReference,
+		Channel:           result.Channel,
+	}
+	if result.Status == utils.PaymentFailed {
+		settle.FailureReason = result.Message
+	}
+
+	// Never trust that the customer paid what they were asked for.
+	if result.Status == utils.PaymentSucceeded &&
+		(result.Amount.Currency() != payment.Currency || result.Amount.Kobo() != payment.Amount.Kobo()) {
+		log.Printf("payment %s: provider charged %s %s, expected %s %s",
+			payment.ID, result.Amount.Currency(), result.Amount, payment.Currency, payment.Amount)
+		settle.Status = utils.PaymentFailed
+		settle.FailureReason = "amount paid does not match the amount due"
+	}
+
+	if settle.Status == utils.PaymentSucceeded {
+		settle.PaidAt = sql.NullTime{Time: result.PaidAt, Valid: true}
+		if result.PaidAt.IsZero() {
+			settle.PaidAt = sql.NullTime{}
+		}
+	}
+
+	settled := payment
+	var refunds []db.Refund
+	err = s.execTx(ctx, func(q *db.Queries) error {
+		target := paymentTarget{checkoutID: payment.CheckoutID, orderID: payment.OrderID, tipID: payment.TipID}
+		if err := lockPaymentTarget(ctx, q, target); err != nil {
+			return err
+		}
+
+		var err error
+		settled, err = q.SettlePayment(ctx, settle)
+		if err == sql.ErrNoRows {
+			settled, err = q.GetPayment(ctx, payment.ID)
+			return err
+		} else if err != nil {
+			return err
+		}
+
+		if settled.Status == utils.PaymentSucceeded && !settled.WalletAccountID.Valid {
+			paid, err := q.CountSucceededPayments(ctx, db.CountSucceededPaymentsParams{
+				CheckoutID: target.checkoutID,
+				OrderID:    target.orderID,
+				TipID:      target.tipID,
+			})
+			if err != nil {
+				return err
+			}
+			if paid > 1 {
+				var refund db.Refund
+				settled, refund, err = refundDuplicate(ctx, q, settled)
+				refunds = append(refunds, refund)
+				return err
+			}
+		}
+
+		if err := applyPayment(ctx, q, settled); err != nil {
+			return err
+		}
+		if settled.Status != utils.PaymentSucceeded {
+			return nil
+		}
+
+		refunds, err = refundCancelledOrders(ctx, q, settled)
+		return err
+	})
+	if err == nil && len(refunds) > 0 {
+		go func() {
+			for _, refund := range refunds {
+				if _, err := s.sendRefund(context.Background(), refund); err != nil {
+					log.Printf("could not send refund %s for payment %s: %v", refund.ID, payment.ID, err)
+				}
+			}
+		}()
+	}
+	return settled, err
+}
+
+// refundCancelledOrders gives back in full what a payment paid for orders
+// cancelled or rejected while it was still pending. They were unpaid when
+// they were cancelled, so no refund was opened for them then.
+func refundCancelledOrders(ctx context.Context, q *db.Queries, payment db.Payment) ([]db.Refund, error) {
+	var orders []db.Order
+	switch {
+	case payment.CheckoutID.Valid:
+		var err error
+		orders, err = q.ListCheckoutOrders(ctx, payment.CheckoutID)
+		if err != nil {
+			return nil, err
+		}
+	case payment.OrderID.Valid:
+		order, err := q.GetOrder(ctx, payment.OrderID.String)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	refunds := []db.Refund{}
+	for _, order := range orders {
+		if order.Status != utils.OrderCancelled && order.Status != utils.OrderRejected {
+			continue
+		}
+
+		if err := q.SetOrderPaymentStatus(ctx, db.SetOrderPaymentStatusParams{
+			ID:            order.ID,
+			PaymentStatus: utils.OrderPaid,
+		}); err != nil {
+			return nil, err
+		}
+
+		refund, err := openRefund(ctx, q, newRefund{
+			order:    order,
+			source:   utils.RefundForCancellation,
+			reason:   "Cancelled before the payment came through",
+			refundTo: utils.RefundToOriginal,
+		})
+		if err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+	return refunds, nil
+}
+
+// refundDuplicate flags a payment made for something already paid for and
+// opens a refund of all of it. The money is posted as taken into sales,
+// where the refund takes it back out, so the ledger shows both.
+func refundDuplicate(ctx context.Context, q *db.Queries, payment db.Payment) (db.Payment, db.Refund, error) {
+	flagged, err := q.FlagDuplicatePayment(ctx, payment.ID)
+	if err != nil {
+		return payment, db.Refund{}, err
+	}
+
+	if _, err := postLedger(ctx, q, utils.LedgerPayment, "Duplicate payment "+payment.Reference, sql.NullString{String: payment.ID, Valid: true},
+		utils.LedgerLine{Account: utils.AccountProvider, Amount: utils.Kobo(0).Sub(payment.Amount)},
+		utils.LedgerLine{Account: utils.AccountSales, Amount: payment.Amount},
+	); err != nil {
+		return flagged, db.Refund{}, err
+	}
+
+	// Refunds belong to an order; a duplicate is filed under the order it
+	// was for, or the first of its checkout's.
+	var orderId string
+	switch {
+	case payment.OrderID.Valid:
+		orderId = payment.OrderID.String
+	case payment.TipID.Valid:
+		tip, err := q.GetOrderTip(ctx, payment.TipID.String)
+		if err != nil {
+			return flagged, db.Refund{}, err
+		}
+		orderId = tip.OrderID
+	case payment.CheckoutID.Valid:
+		orders, err := q.ListCheckoutOrders(ctx, payment.CheckoutID)
+		if err != nil {
+			return flagged, db.Refund{}, err
+		}
+		if len(orders) == 0 {
+			return flagged, db.Refund{}, sql.ErrNoRows
+		}
+		orderId = orders[0].ID
+	}
+
+	id, err := utils.NewID()
+	if err != nil {
+		return flagged, db.Refund{}, err
+	}
+
+	refund, err := q.CreateRefund(ctx, db.CreateRefundParams{
+		ID:        id,
+		OrderID:   orderId,
+		PaymentID: payment.ID,
+		Source:    utils.RefundForDuplicate,
+		Reason:    "Paid for more than once",
+		Amount:    payment.Amount,
+		TipAmount: utils.Kobo(0),
+		RefundTo:  utils.RefundToOriginal,
+		Status:    utils.RefundPending,
+	})
+	return flagged, refund, err
+}
+
+// applyPayment marks what a settled payment was for as paid, or as failed
+// so the customer can try again. Tips paid at checkout are captured with
+// it, and the money is posted to the ledger. A top-up only credits the
+// wallet.
+func applyPayment(ctx context.Context, q *db.Queries, payment db.Payment) error {
+	status := utils.OrderPaid
+	if payment.Status != utils.PaymentSucceeded {
+		status = utils.OrderPaymentFailed
+	} else if err := postPayment(ctx, q, payment); err != nil {
+		return err
+	}
+
+	switch {
+	case payment.CheckoutID.Valid:
+		if err := q.SetCheckoutPaymentStatus(ctx, db.SetCheckoutPaymentStatusParams{
+			CheckoutID:    payment.CheckoutID,
+			PaymentStatus: status,
+		}); err != nil {
+			return err
+		}
+		if status == utils.OrderPaid {
+			return q.CaptureCheckoutTips(ctx, payment.CheckoutID.String)
+		}
+
+	case payment.OrderID.Valid:
+		return q.SetOrderPaymentStatus(ctx, db.SetOrderPaymentStatusParams{
+			ID:            payment.OrderID.String,
+			PaymentStatus: status,
+		})
+
+	case payment.TipID.Valid:
+		if status == utils.OrderPaid {
+			return q.SetTipStatus(ctx, db.SetTipStatusParams{
+				ID:     payment.TipID.String,
+				Status: utils.TipCaptured,
+			})
+		}
+	}
+	return nil
+}
+
+func newPaymentParams(id, userId, provider string, target paymentTarget) db.CreatePaymentParams {
+	return db.CreatePaymentParams{
+		ID:              id,
+		Reference:       "RNK-" + strings.ToUpper(id),
+		UserID:          userId,
+		CheckoutID:      target.checkoutID,
+		OrderID:         target.orderID,
+		TipID:           target.tipID,
+		WalletAccountID: target.walletID,
+		Provider:        provider,
+		Amount:          target.amount,
+		Currency:        target.amount.Currency(),
+	}
+}
+
+func newPaymentResponse(payment db.Payment) PaymentResponse {
+	return PaymentResponse{
+		Payment:    payment,
+		CheckoutID: nullString(payment.CheckoutID),
+		OrderID:    nullString(payment.OrderID),
+		TipID:      nullString(payment.TipID),
+		WalletID:   nullString(payment.WalletAccountID),
+		PaidAt:     nullTime(payment.PaidAt),
+	}
+}
@@ -0,0 +1,598 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type Product struct {
+	server *Server
+}
+
+type CreateProductParams struct {
+	ShopID      string       `json:"shop_id" binding:"required"`
+	Name        string       `json:"name" binding:"required,max=100"`
+	Description string       `json:"description"`
+	Category    string       `json:"category" binding:"max=50"`
+	Price       *utils.Money `json:"price" binding:"required,isPositive"`
+	ImageUrls   []string     `json:"image_urls" binding:"omitempty,max=5,isImageURL"`
+	DietaryTags []string     `json:"dietary_tags" binding:"omitempty,dive,dietaryTag"`
+	SpiceLevel  int16        `json:"spice_level" binding:"min=0,max=3"`
+	Allergens   []string     `json:"allergens" binding:"omitempty,dive,allergen"`
+}
+
+type UpdateProductParams struct {
+	Name        string       `json:"name" binding:"required,max=100"`
+	Description string       `json:"description"`
+	Category    string       `json:"category" binding:"max=50"`
+	Price       *utils.Money `json:"price" binding:"required,isPositive"`
+	ImageUrls   []string     `json:"image_urls" binding:"omitempty,max=5,isImageURL"`
+	IsAvailable *bool        `json:"is_available" binding:"required"`
+	DietaryTags []string     `json:"dietary_tags" binding:"omitempty,dive,dietaryTag"`
+	SpiceLevel  int16        `json:"spice_level" binding:"min=0,max=3"`
+	Allergens   []string     `json:"allergens" binding:"omitempty,dive,allergen"`
+}
+
+type CreateProductOptionParams struct {
+	GroupName  string      `json:"group_name" binding:"required,max=50"`
+	Name       string      `json:"name" binding:"required,max=100"`
+	PriceDelta utils.Money `json:"price_delta" binding:"omitempty,isPositive"`
+}
+
+type UpdateProductOptionParams struct {
+	IsAvailable *bool `json:"is_available" binding:"required"`
+}
+
+// ListProductsParams are the filters shared by every product listing. Tags
+// and allergens can be repeated or comma separated.
+type ListProductsParams struct {
+	ShopID           string   `form:"shop_id"`
+	Category         string   `form:"category"`
+	Search           string   `form:"q"`
+	DietaryTags      []string `form:"tags"`
+	ExcludeAllergens []string `form:"exclude_allergens"`
+	MaxSpiceLevel    *int16   `form:"max_spice" binding:"omitempty,min=0,max=3"`
+	Available        bool     `form:"available"`
+	PageID           int32    `form:"page_id" binding:"omitempty,min=1"`
+	PageSize         int32    `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// ProductResponse flags products containing allergens from the signed in
+// user's profile, and whether they favourited it.
+type ProductResponse struct {
+	db.Product
+	UnsafeAllergens []string `json:"unsafe_allergens"`
+	AllergenWarning bool     `json:"allergen_warning"`
+	IsFavourite     bool     `json:"is_favourite"`
+}
+
+func (p Product) router(server *Server) {
+	p.server = server
+
+	serverGroup := server.router.Group("/products")
+	serverGroup.POST("", AuthenticatedMiddleware(), p.createProduct)
+	serverGroup.GET("", OptionalAuthMiddleware(), p.listProducts)
+	serverGroup.GET("/search", OptionalAuthMiddleware(), p.listProducts)
+	serverGroup.GET("/:id", OptionalAuthMiddleware(), p.getProduct)
+	serverGroup.PUT("/:id", AuthenticatedMiddleware(), p.updateProduct)
+	serverGroup.DELETE("/:id", AuthenticatedMiddleware(), p.deleteProduct)
+	serverGroup.GET("/:id/options", p.listProductOptions)
+	serverGroup.POST("/:id/options", AuthenticatedMiddleware(), p.createProductOption)
+	serverGroup.PUT("/:id/options/:option_id", AuthenticatedMiddleware(), p.updateProductOption)
+	serverGroup.DELETE("/:id/options/:option_id", AuthenticatedMiddleware(), p.deleteProductOption)
+
+	server.router.GET("/shops/:id/products", OptionalAuthMiddleware(), p.listShopProducts)
+}
+
+func (p *Product) createProduct(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := CreateProductParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	shop, ok := p.server.ownedShop(ctx, input.ShopID, userId)
+	if !ok {
+		return
+	}
+
+	if len(input.ImageUrls) > 0 && !p.server.verifyImageURLs(ctx, input.ImageUrls) {
+		return
+	}
+
+	id, err := utils.NewID()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Failed to generate CUID": err.Error(),
+		})
+		return
+	}
+
+	product, err := p.server.queries.CreateProduct(context.Background(), db.CreateProductParams{
+		ID:          id,
+		ShopID:      shop.ID,
+		Name:        strings.TrimSpace(input.Name),
+		Description: input.Description,
+		Category:    strings.ToLower(strings.TrimSpace(input.Category)),
+		Price:       *input.Price,
+		ImageUrls:   nonNil(input.ImageUrls),
+		DietaryTags: utils.Dedupe(input.DietaryTags),
+		SpiceLevel:  input.SpiceLevel,
+		Allergens:   utils.Dedupe(input.Allergens),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"statusCode": http.StatusCreated,
+		"status":     "success",
+		"message":    "product created successfully",
+		"data":       product,
+	})
+}
+
+func (p *Product) listProducts(ctx *gin.Context) {
+	p.list(ctx, "")
+}
+
+func (p *Product) listShopProducts(ctx *gin.Context) {
+	p.list(ctx, ctx.Param("id"))
+}
+
+func (p *Product) list(ctx *gin.Context, shopId string) {
+	input := ListProductsParams{}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if shopId == "" {
+		shopId = input.ShopID
+	}
+
+	tags := splitList(input.DietaryTags)
+	if !allIn(tags, utils.IsDietaryTag) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": "tags must be any of: " + strings.Join(utils.DietaryTags, " "),
+		})
+		return
+	}
+
+	exclude := splitList(input.ExcludeAllergens)
+	if !allIn(exclude, utils.IsAllergen) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": "exclude_allergens must be any of: " + strings.Join(utils.Allergens, " "),
+		})
+		return
+	}
+
+	profile, ok := p.server.allergenProfile(ctx)
+	if !ok {
+		return
+	}
+	if profile.HideUnsafe {
+		exclude = utils.Dedupe(append(exclude, profile.Allergens...))
+	}
+
+	maxSpice := int16(utils.MaxSpiceLevel)
+	if input.MaxSpiceLevel != nil {
+		maxSpice = *input.MaxSpiceLevel
+	}
+
+	limit, offset := pagination(input.PageID, input.PageSize)
+	search := strings.TrimSpace(input.Search)
+	category := strings.ToLower(strings.TrimSpace(input.Category))
+
+	products, err := p.server.queries.ListProducts(context.Background(), db.ListProductsParams{
+		ShopID:           sql.NullString{String: shopId, Valid: shopId != ""},
+		Category:         sql.NullString{String: category, Valid: category != ""},
+		Search:           sql.NullString{String: search, Valid: search != ""},
+		DietaryTags:      tags,
+		ExcludeAllergens: exclude,
+		MaxSpiceLevel:    maxSpice,
+		OnlyAvailable:    input.Available,
+		Limit:            limit,
+		Offset:           offset,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ids := make([]string, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+
+	favourites, ok := p.server.favouriteProductIDs(ctx, ids)
+	if !ok {
+		return
+	}
+
+	response := make([]ProductResponse, 0, len(products))
+	for _, product := range products {
+		response = append(response, newProductResponse(product, profile, favourites[product.ID]))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "products fetched successfully",
+		"data":       response,
+	})
+}
+
+func (p *Product) getProduct(ctx *gin.Context) {
+	product, err := p.server.queries.GetProduct(context.Background(), ctx.Param("id"))
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"Error":      err.Error(),
+			"message":    "The requested product does not exist.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	profile, ok := p.server.allergenProfile(ctx)
+	if !ok {
+		return
+	}
+
+	favourites, ok := p.server.favouriteProductIDs(ctx, []string{product.ID})
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "product fetched successfully",
+		"data":       newProductResponse(product, profile, favourites[product.ID]),
+	})
+}
+
+func (p *Product) updateProduct(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := UpdateProductParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	product, ok := p.ownedProduct(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	if len(input.ImageUrls) > 0 && !p.server.verifyImageURLs(ctx, input.ImageUrls) {
+		return
+	}
+
+	updated, err := p.server.queries.UpdateProduct(context.Background(), db.UpdateProductParams{
+		ID:          product.ID,
+		Name:        strings.TrimSpace(input.Name),
+		Description: input.Description,
+		Category:    strings.ToLower(strings.TrimSpace(input.Category)),
+		Price:       *input.Price,
+		ImageUrls:   nonNil(input.ImageUrls),
+		IsAvailable: *input.IsAvailable,
+		DietaryTags: utils.Dedupe(input.DietaryTags),
+		SpiceLevel:  input.SpiceLevel,
+		Allergens:   utils.Dedupe(input.Allergens),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "product updated successfully",
+		"data":    updated,
+	})
+}
+
+func (p *Product) deleteProduct(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	product, ok := p.ownedProduct(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	if err := p.server.queries.DeleteProduct(context.Background(), product.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "product deleted successfully",
+	})
+}
+
+func (p *Product) listProductOptions(ctx *gin.Context) {
+	options, err := p.server.queries.ListProductOptions(context.Background(), ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "product options fetched successfully",
+		"data":       options,
+	})
+}
+
+func (p *Product) createProductOption(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := CreateProductOptionParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	product, ok := p.ownedProduct(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	id, err := utils.NewID()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Failed to generate CUID": err.Error(),
+		})
+		return
+	}
+
+	option, err := p.server.queries.CreateProductOption(context.Background(), db.CreateProductOptionParams{
+		ID:         id,
+		ProductID:  product.ID,
+		GroupName:  strings.TrimSpace(input.GroupName),
+		Name:       strings.TrimSpace(input.Name),
+		PriceDelta: input.PriceDelta,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"statusCode": http.StatusCreated,
+		"status":     "success",
+		"message":    "product option created successfully",
+		"data":       option,
+	})
+}
+
+func (p *Product) updateProductOption(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := UpdateProductOptionParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	product, ok := p.ownedProduct(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	option, err := p.server.queries.UpdateProductOptionAvailability(context.Background(), db.UpdateProductOptionAvailabilityParams{
+		ID:          ctx.Param("option_id"),
+		ProductID:   product.ID,
+		IsAvailable: *input.IsAvailable,
+	})
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"Error":      err.Error(),
+			"message":    "The requested option does not exist.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "product option updated successfully",
+		"data":    option,
+	})
+}
+
+func (p *Product) deleteProductOption(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	product, ok := p.ownedProduct(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	err := p.server.queries.DeleteProductOption(context.Background(), db.DeleteProductOptionParams{
+		ID:        ctx.Param("option_id"),
+		ProductID: product.ID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "product option deleted successfully",
+	})
+}
+
+func (p *Product) ownedProduct(ctx *gin.Context, productId, userId string) (db.Product, bool) {
+	product, err := p.server.queries.GetProduct(context.Background(), productId)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"Error":      err.Error(),
+			"message":    "The requested product does not exist.",
+		})
+		return db.Product{}, false
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return db.Product{}, false
+	}
+
+	if _, ok := p.server.ownedShop(ctx, product.ShopID, userId); !ok {
+		return db.Product{}, false
+	}
+
+	return product, true
+}
+
+// allergenProfile returns the signed in user's profile, or an empty one for
+// anonymous requests and users who never set one.
+func (s *Server) allergenProfile(ctx *gin.Context) (db.AllergenProfile, bool) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		return db.AllergenProfile{}, true
+	}
+
+	profile, err := s.queries.GetAllergenProfile(context.Background(), userId)
+	if err == sql.ErrNoRows {
+		return db.AllergenProfile{UserID: userId}, true
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return db.AllergenProfile{}, false
+	}
+
+	return profile, true
+}
+
+func newProductResponse(product db.Product, profile db.AllergenProfile, favourite bool) ProductResponse {
+	unsafe := utils.UnsafeAllergens(product.Allergens, profile.Allergens)
+
+	return ProductResponse{
+		Product:         product,
+		UnsafeAllergens: unsafe,
+		AllergenWarning: len(unsafe) > 0,
+		IsFavourite:     favourite,
+	}
+}
+
+// splitList accepts both ?tags=a&tags=b and ?tags=a,b.
+func splitList(values []string) []string {
+	out := []string{}
+	for _, value := range values {
+		for _, v := range strings.Split(value, ",") {
+			v = strings.ToLower(strings.TrimSpace(v))
+			if v != "" {
+				out = append(out, v)
+			}
+		}
+	}
+	return utils.Dedupe(out)
+}
+
+func allIn(values []string, valid func(string) bool) bool {
+	for _, value := range values {
+		if !valid(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+	"gopkg.in/gomail.v2"
+)
+
+// receiptPaymentMethod is printed for orders with no successful payment,
+// such as those placed before payments were taken.
+const receiptPaymentMethod = "Not recorded"
+
+// getReceipt downloads a delivered order's receipt as a PDF.
+func (o *Order) getReceipt(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	order, ok := o.server.customerOrder(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	if order.Status != utils.OrderDelivered {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"statusCode": http.StatusConflict,
+			"message":    "A receipt is available once the order has been delivered.",
+		})
+		return
+	}
+
+	receipt, err := o.server.orderReceipt(context.Background(), order)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, receiptFilename(receipt)))
+	ctx.Data(http.StatusOK, "application/pdf", utils.RenderReceipt(receipt))
+}
+
+// orderReceipt gathers what goes on an order's receipt from the order as it
+// was stored, so later menu or tax changes never alter it.
+func (s *Server) orderReceipt(ctx context.Context, order db.Order) (utils.Receipt, error) {
+	receipt := utils.Receipt{}
+
+	response, err := s.orderResponse(ctx, order, utils.ActorCustomer)
+	if err != nil {
+		return receipt, err
+	}
+
+	shop, err := s.queries.GetShop(ctx, order.ShopID)
+	if err != nil {
+		return receipt, err
+	}
+
+	user, err := s.queries.GetUserById(ctx, order.UserID)
+	if err != nil {
+		return receipt, err
+	}
+
+	deliveredAt, err := s.queries.GetOrderDeliveredAt(ctx, order.ID)
+	if err == sql.ErrNoRows {
+		// Orders delivered before status history was kept.
+		deliveredAt = order.UpdatedAt
+	} else if err != nil {
+		return receipt, err
+	}
+
+	method := receiptPaymentMethod
+	payment, err := s.queries.GetOrderPayment(ctx, order.ID)
+	if err != nil && err != sql.ErrNoRows {
+		return receipt, err
+	} else if err == nil {
+		method = utils.PaymentChannel(payment.Channel, payment.Provider)
+	}
+
+	receipt = utils.Receipt{
+		ShopName:        shop.Name,
+		ShopAddress:     shop.Address,
+		ShopPhone:       shop.Phone,
+		OrderID:         order.ID,
+		PlacedAt:        order.CreatedAt.In(s.location),
+		DeliveredAt:     deliveredAt.In(s.location),
+		CustomerName:    user.Firstname + " " + user.Lastname,
+		DeliveryAddress: order.DeliveryAddress,
+		PaymentMethod:   method,
+		Subtotal:        order.Subtotal,
+		ServiceCharge:   order.ServiceCharge,
+		VAT:             order.Vat,
+		DeliveryFee:     order.DeliveryFee,
+		Discount:        order.Discount,
+		Total:           order.Total,
+	}
+
+	// Tips added after delivery were charged on their own, so they are
+	// added to what the receipt says was paid once the charge has gone
+	// through.
+	receipt.Tip = utils.Kobo(0)
+	for _, tip := range response.Tips {
+		if tip.Stage == utils.TipAfterDelivery {
+			if tip.Status != utils.TipCaptured {
+				continue
+			}
+			receipt.Total = receipt.Total.Add(tip.Amount)
+		}
+		receipt.Tip = receipt.Tip.Add(tip.Amount)
+	}
+
+	if response.Invoice != nil {
+		receipt.InvoiceNumber = response.Invoice.InvoiceNumber
+		receipt.VATRate = utils.FormatBasisPoints(int64(response.Invoice.VatBasisPoints))
+		receipt.PricesIncludeVAT = response.Invoice.PricesIncludeVat
+	}
+
+	for _, item := range response.Items {
+		line := utils.ReceiptLine{
+			Name:      item.ProductName,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			LineTotal: item.LineTotal,
+			Options:   []string{},
+			Note:      item.Note,
+			VATExempt: item.VATExempt,
+		}
+		for _, option := range item.Options {
+			text := fmt.Sprintf("%s: %s", option.GroupName, option.Name)
+			if !option.PriceDelta.IsZero() {
+				text += fmt.Sprintf(" (+%s)", option.PriceDelta)
+			}
+			line.Options = append(line.Options, text)
+		}
+		receipt.Lines = append(receipt.Lines, line)
+	}
+
+	return receipt, nil
+}
+
+func receiptFilename(receipt utils.Receipt) string {
+	if receipt.InvoiceNumber != "" {
+		return "receipt-" + receipt.InvoiceNumber + ".pdf"
+	}
+	return "receipt-" + receipt.OrderID + ".pdf"
+}
+
+// emailReceipt sends the customer their receipt once the order has been
+// delivered. The order is already done, so failures are only logged.
+func (s *Server) emailReceipt(order db.Order) {
+	sender := s.config2.GoogleUsername
+	if sender == "" {
+		return
+	}
+
+	ctx := context.Background()
+
+	receipt, err := s.orderReceipt(ctx, order)
+	if err != nil {
+		log.Printf("could not build receipt for order %s: %v", order.ID, err)
+		return
+	}
+
+	user, err := s.queries.GetUserById(ctx, order.UserID)
+	if err != nil {
+		log.Printf("could not load customer for order %s: %v", order.ID, err)
+		return
+	}
+
+	pdf := utils.RenderReceipt(receipt)
+
+	message := gomail.NewMessage()
+	message.SetHeader("From", sender)
+	message.SetHeader("To", user.Email)
+	message.SetHeader("Subject", fmt.Sprintf("Your receipt from %s", receipt.ShopName))
+	message.SetBody("text/plain", fmt.Sprintf("Hi %s,\n\nYour order from %s has been delivered. Your receipt is attached.\n\nTotal paid: %s %s\n\nThanks,\nThe Ra'Nkan team\n",
+		user.Firstname, receipt.ShopName, receipt.Total.Currency(), receipt.Total))
+	message.Attach(receiptFilename(receipt),
+		gomail.SetCopyFunc(func(w io.Writer) error {
+			_, err := w.Write(pdf)
+			return err
+		}),
+		gomail.SetHeader(map[string][]string{"Content-Type": {"application/pdf"}}),
+	)
+
+	dialer := gomail.NewDialer("smtp.gmail.com", 587, sender, s.config2.GooglePassword)
+	if err := dialer.DialAndSend(message); err != nil {
+		log.Printf("could not email receipt for order %s: %v", order.ID, err)
+	}
+}
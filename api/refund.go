@@ -0,0 +1,825 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type Refund struct {
+	server *Server
+}
+
+type RefundItemParams struct {
+	OrderItemID string `json:"order_item_id" binding:"required,max=50"`
+	Quantity    int32  `json:"quantity" binding:"required,min=1"`
+}
+
+// CreateRefundParams refunds either an amount or line items. With neither,
+// whatever is left to refund on the order is refunded.
+type CreateRefundParams struct {
+	Amount   *utils.Money       `json:"amount" binding:"omitempty,isPositive"`
+	Items    []RefundItemParams `json:"items" binding:"omitempty,max=100,dive"`
+	RefundTo string             `json:"refund_to" binding:"omitempty,oneof=original wallet"`
+	Reason   string             `json:"reason" binding:"required,max=500"`
+}
+
+type ListRefundsParams struct {
+	Status   string `form:"status" binding:"omitempty,oneof=awaiting_approval rejected pending processing refunded failed"`
+	PageID   int32  `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32  `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type RefundResponse struct {
+	db.Refund
+	RequestedBy *string         `json:"requested_by"`
+	ReviewedBy  *string         `json:"reviewed_by"`
+	Items       []db.RefundItem `json:"items"`
+}
+
+// refundError is a refund that cannot be given as asked; the message is
+// for the admin asking.
+type refundError struct {
+	message string
+}
+
+func (e refundError) Error() string {
+	return e.message
+}
+
+var (
+	errNoPayment           = errors.New("order has no payment to refund")
+	errRefundStatusChanged = errors.New("refund status changed")
+)
+
+func (r Refund) router(server *Server) {
+	r.server = server
+
+	server.router.GET("/orders/:id/refunds", AuthenticatedMiddleware(), r.listOrderRefunds)
+
+	adminGroup := server.router.Group("/admin", AuthenticatedMiddleware(), AdminMiddleware())
+	adminGroup.POST("/orders/:id/refunds", IdempotencyMiddleware(), r.createRefund)
+	adminGroup.GET("/refunds", r.listRefunds)
+	adminGroup.GET("/refunds/:id", r.getRefund)
+	adminGroup.POST("/refunds/:id/approve", r.approveRefund)
+	adminGroup.POST("/refunds/:id/reject", r.rejectRefund)
+}
+
+func (r *Refund) listOrderRefunds(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	order, ok := r.server.customerOrder(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	refunds, err := r.server.queries.ListOrderRefunds(context.Background(), order.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	response := []RefundResponse{}
+	for _, refund := range refunds {
+		item, err := r.server.refundResponse(context.Background(), refund)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"Error": err.Error(),
+			})
+			return
+		}
+		response = append(response, item)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "refunds fetched successfully",
+		"data":       response,
+	})
+}
+
+// createRefund gives money back on an order. Refunds up to the approval
+// threshold go out straight away; larger ones wait for a second admin.
+func (r *Refund) createRefund(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := CreateRefundParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if input.Amount != nil && len(input.Items) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": "refund either an amount or items, not both",
+		})
+		return
+	}
+
+	order, err := r.server.queries.GetOrder(context.Background(), ctx.Param("id"))
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"message":    "The requested order does not exist.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	refundTo := input.RefundTo
+	if refundTo == "" {
+		refundTo = utils.RefundToOriginal
+	}
+
+	var refund db.Refund
+	err = r.server.execTx(ctx, func(q *db.Queries) error {
+		var err error
+		refund, err = openRefund(ctx, q, newRefund{
+			order:       order,
+			source:      utils.RefundBySupport,
+			requestedBy: userId,
+			reason:      input.Reason,
+			amount:      input.Amount,
+			items:       input.Items,
+			refundTo:    refundTo,
+			threshold:   &r.server.refundApproval,
+		})
+		return err
+	})
+	var refundErr refundError
+	if errors.Is(err, errNoPayment) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"statusCode": http.StatusConflict,
+			"message":    "This order has no payment to refund.",
+		})
+		return
+	} else if errors.As(err, &refundErr) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    refundErr.message,
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if refund.Status == utils.RefundPending {
+		refund, err = r.server.sendRefund(context.Background(), refund)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"Error": err.Error(),
+			})
+			return
+		}
+	}
+
+	r.server.respondRefund(ctx, http.StatusCreated, "refund created successfully", refund)
+}
+
+func (r *Refund) listRefunds(ctx *gin.Context) {
+	input := ListRefundsParams{}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	limit, offset := pagination(input.PageID, input.PageSize)
+
+	refunds, err := r.server.queries.ListRefunds(context.Background(), db.ListRefundsParams{
+		Status: input.Status,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "refunds fetched successfully",
+		"data":       refunds,
+	})
+}
+
+func (r *Refund) getRefund(ctx *gin.Context) {
+	refund, ok := r.server.findRefund(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	r.server.respondRefund(ctx, http.StatusOK, "refund fetched successfully", refund)
+}
+
+// approveRefund sends a refund that was over the threshold. Whoever asked
+// for it cannot approve it.
+func (r *Refund) approveRefund(ctx *gin.Context) {
+	r.reviewRefund(ctx, utils.RefundPending)
+}
+
+func (r *Refund) rejectRefund(ctx *gin.Context) {
+	r.reviewRefund(ctx, utils.RefundRejected)
+}
+
+func (r *Refund) reviewRefund(ctx *gin.Context, status string) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	refund, ok := r.server.findRefund(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	if status == utils.RefundPending && refund.RequestedBy.String == userId {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"message": "A refund must be approved by someone other than who asked for it.",
+		})
+		return
+	}
+
+	refund, err := r.server.queries.ReviewRefund(context.Background(), db.ReviewRefundParams{
+		ID:         refund.ID,
+		Status:     status,
+		ReviewedBy: sql.NullString{String: userId, Valid: true},
+	})
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"statusCode": http.StatusConflict,
+			"message":    "This refund is not awaiting approval.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	message := "refund rejected successfully"
+	if status == utils.RefundPending {
+		message = "refund approved successfully"
+		refund, err = r.server.sendRefund(context.Background(), refund)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"Error": err.Error(),
+			})
+			return
+		}
+	}
+
+	r.server.respondRefund(ctx, http.StatusOK, message, refund)
+}
+
+func (s *Server) findRefund(ctx *gin.Context, id string) (db.Refund, bool) {
+	refund, err := s.queries.GetRefund(context.Background(), id)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"message":    "The requested refund does not exist.",
+		})
+		return refund, false
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return refund, false
+	}
+	return refund, true
+}
+
+func (s *Server) respondRefund(ctx *gin.Context, status int, message string, refund db.Refund) {
+	response, err := s.refundResponse(context.Background(), refund)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(status, gin.H{
+		"statusCode": status,
+		"status":     "success",
+		"message":    message,
+		"data":       response,
+	})
+}
+
+// newRefund is a refund being asked for. A nil amount with no items
+// refunds everything left on the order; a nil threshold never needs
+// approval.
+type newRefund struct {
+	order       db.Order
+	source      string
+	requestedBy string
+	reason      string
+	amount      *utils.Money
+	items       []RefundItemParams
+	refundTo    string
+	threshold   *utils.Money
+}
+
+// openRefund records a refund against what is left to refund on the
+// order. The order row is locked first so two refunds cannot both claim
+// the same money. Nothing is sent from here; pending refunds go out with
+// sendRefund once the transaction commits.
+func openRefund(ctx context.Context, q *db.Queries, req newRefund) (db.Refund, error) {
+	order, err := q.LockOrder(ctx, req.order.ID)
+	if err != nil {
+		return db.Refund{}, err
+	}
+
+	payment, err := q.GetOrderPayment(ctx, order.ID)
+	if err == sql.ErrNoRows {
+		return db.Refund{}, errNoPayment
+	} else if err != nil {
+		return db.Refund{}, err
+	}
+
+	totals, err := q.GetOrderRefundTotals(ctx, order.ID)
+	if err != nil {
+		return db.Refund{}, err
+	}
+	left := order.Total.Sub(totals.Committed)
+
+	amount := left
+	items := []db.CreateRefundItemParams{}
+
+	switch {
+	case req.amount != nil:
+		amount = *req.amount
+
+	case len(req.items) > 0:
+		items, err = refundItems(ctx, q, order, req.items)
+		if err != nil {
+			return db.Refund{}, err
+		}
+		amount = utils.Kobo(0)
+		for _, item := range items {
+			amount = amount.Add(item.Amount)
+		}
+	}
+
+	if left.Cmp(utils.Kobo(0)) <= 0 {
+		return db.Refund{}, refundError{"This order has already been refunded in full."}
+	}
+	if amount.Cmp(left) > 0 {
+		return db.Refund{}, refundError{fmt.Sprintf("Only %s is left to refund on this order.", left)}
+	}
+	if amount.IsZero() {
+		return db.Refund{}, refundError{"There is nothing to refund."}
+	}
+
+	refundTo := req.refundTo
+	if payment.Provider == utils.WalletProvider {
+		refundTo = utils.RefundToWallet
+	}
+
+	status := utils.RefundPending
+	if req.threshold != nil && utils.NeedsApproval(amount, *req.threshold) {
+		status = utils.RefundAwaitingApproval
+	}
+
+	_, tip := utils.SplitRefund(amount, totals.Committed, order.Total, order.Tip)
+
+	id, err := utils.NewID()
+	if err != nil {
+		return db.Refund{}, err
+	}
+
+	refund, err := q.CreateRefund(ctx, db.CreateRefundParams{
+		ID:          id,
+		OrderID:     order.ID,
+		PaymentID:   payment.ID,
+		Source:      req.source,
+		RequestedBy: sql.NullString{String: req.requestedBy, Valid: req.requestedBy != ""},
+		Reason:      req.reason,
+		Amount:      amount,
+		TipAmount:   tip,
+		RefundTo:    refundTo,
+		Status:      status,
+	})
+	if err != nil {
+		return refund, err
+	}
+
+	for _, item := range items {
+		item.RefundID = refund.ID
+		if _, err := q.CreateRefundItem(ctx, item); err != nil {
+			return refund, err
+		}
+	}
+	return refund, nil
+}
+
+// refundItems prices the line items being refunded. Each line can only be
+// refunded up to the quantity that was ordered, across all its refunds.
+func refundItems(ctx context.Context, q *db.Queries, order db.Order, requested []RefundItemParams) ([]db.CreateRefundItemParams, error) {
+	lines, err := q.ListOrderItems(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	refunded, err := q.ListRefundedQuantities(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	done := map[string]int64{}
+	for _, row := range refunded {
+		done[row.OrderItemID] = row.Quantity
+	}
+
+	wanted := map[string]int32{}
+	ids := []string{}
+	for _, item := range requested {
+		if _, ok := wanted[item.OrderItemID]; !ok {
+			ids = append(ids, item.OrderItemID)
+		}
+		wanted[item.OrderItemID] += item.Quantity
+	}
+
+	// A free delivery coupon came off the delivery fee, not the food.
+	goods := order.Total.Sub(order.DeliveryFee).Sub(order.Tip)
+	coupon, ok, err := orderCoupon(ctx, q, order)
+	if err != nil {
+		return nil, err
+	}
+	if ok && coupon.Kind == utils.CouponFreeDelivery {
+		goods = goods.Add(order.Discount)
+	}
+	items := []db.CreateRefundItemParams{}
+
+	for _, id := range ids {
+		var line *db.OrderItem
+		for i := range lines {
+			if lines[i].ID == id {
+				line = &lines[i]
+				break
+			}
+		}
+		if line == nil {
+			return nil, refundError{fmt.Sprintf("Item %s is not part of this order.", id)}
+		}
+
+		quantity := wanted[id]
+		if int64(quantity)+done[id] > int64(line.Quantity) {
+			return nil, refundError{fmt.Sprintf("Only %d of %s can still be refunded.", int64(line.Quantity)-done[id], line.ProductName)}
+		}
+
+		items = append(items, db.CreateRefundItemParams{
+			OrderItemID: id,
+			Quantity:    quantity,
+			Amount:      utils.ItemRefund(line.LineTotal, quantity, line.Quantity, goods, order.Subtotal),
+		})
+	}
+	return items, nil
+}
+
+// sendRefund gives a pending refund back. The ledger and the payment's
+// refunded amount move first, so the money is never given back twice; a
+// refund into the wallet is then done, while one to the original method
+// is handed to the provider. Refunds come out of the platform's sales, not
+// the shop's settlement.
+func (s *Server) sendRefund(ctx context.Context, refund db.Refund) (db.Refund, error) {
+	payment, err := s.queries.GetPayment(ctx, refund.PaymentID)
+	if err != nil {
+		return refund, err
+	}
+
+	toWallet := refund.RefundTo == utils.RefundToWallet || payment.Provider == utils.WalletProvider
+	if !toWallet && payment.Provider != s.payments.Name() {
+		return s.updateRefund(ctx, refund, utils.RefundResult{
+			Status:  utils.RefundFailed,
+			Message: fmt.Sprintf("the payment was taken through %s, which is not the configured provider", payment.Provider),
+		})
+	}
+
+	sent := refund
+	err = s.execTx(ctx, func(q *db.Queries) error {
+		to := utils.RefundProcessing
+		if toWallet {
+			to = utils.RefundCompleted
+		}
+
+		var err error
+		sent, err = moveRefund(ctx, q, refund, utils.RefundPending, utils.RefundResult{Status: to})
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.AdjustPaymentRefunded(ctx, db.AdjustPaymentRefundedParams{
+			ID:     payment.ID,
+			Amount: refund.Amount,
+		}); err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Constraint == "payment_refund_not_above_amount" {
+				return utils.ErrRefundTooLarge
+			}
+			return err
+		}
+
+		if err := postRefund(ctx, q, refund, payment, toWallet, false); err != nil {
+			return err
+		}
+
+		if toWallet && refund.Source != utils.RefundForDuplicate {
+			return finishOrderRefund(ctx, q, refund.OrderID)
+		}
+		return nil
+	})
+	if errors.Is(err, errRefundStatusChanged) {
+		return s.queries.GetRefund(ctx, refund.ID)
+	} else if errors.Is(err, utils.ErrRefundTooLarge) {
+		return s.updateRefund(ctx, refund, utils.RefundResult{Status: utils.RefundFailed, Message: err.Error()})
+	} else if err != nil {
+		return refund, err
+	}
+
+	if toWallet {
+		return sent, nil
+	}
+	return s.requestRefund(ctx, sent, payment)
+}
+
+// requestRefund asks the provider to refund a processing refund. The
+// refund's ID goes with it as the idempotency key, so asking again after a
+// request whose outcome was lost cannot refund twice.
+func (s *Server) requestRefund(ctx context.Context, refund db.Refund, payment db.Payment) (db.Refund, error) {
+	result, err := s.payments.Refund(ctx, utils.RefundRequest{
+		ID:                refund.ID,
+		Reference:         payment.Reference,
+		ProviderReference: payment.ProviderReference,
+		Amount:            refund.Amount,
+	})
+	if utils.Declined(err) || errors.Is(err, utils.ErrPaymentNotFound) {
+		result = utils.RefundResult{Status: utils.RefundFailed, Message: err.Error()}
+	} else if err != nil {
+		// The provider may have taken the refund, so it stays processing
+		// and is not given back.
+		log.Printf("could not send refund %s: %v", refund.ID, err)
+		return refund, nil
+	}
+	return s.updateRefund(ctx, refund, result)
+}
+
+// updateRefund records where a refund has got to. Pending refunds can only
+// fail from here, before anything was posted; a refund that fails at the
+// provider is taken back off the ledger and the payment, once.
+func (s *Server) updateRefund(ctx context.Context, refund db.Refund, result utils.RefundResult) (db.Refund, error) {
+	if result.Status == refund.Status && result.ProviderReference == refund.ProviderReference {
+		return refund, nil
+	}
+
+	updated := refund
+	err := s.execTx(ctx, func(q *db.Queries) error {
+		var err error
+
+		updated, err = moveRefund(ctx, q, refund, refund.Status, result)
+		if err != nil || refund.Status != utils.RefundProcessing {
+			return err
+		}
+
+		switch result.Status {
+		case utils.RefundCompleted:
+			// A duplicate payment never counted towards the order, so
+			// giving it back leaves the order as it was.
+			if refund.Source == utils.RefundForDuplicate {
+				return nil
+			}
+			return finishOrderRefund(ctx, q, refund.OrderID)
+
+		case utils.RefundFailed:
+			payment, err := q.AdjustPaymentRefunded(ctx, db.AdjustPaymentRefundedParams{
+				ID:     refund.PaymentID,
+				Amount: utils.Kobo(0).Sub(refund.Amount),
+			})
+			if err != nil {
+				return err
+			}
+			return postRefund(ctx, q, refund, payment, false, true)
+		}
+		return nil
+	})
+	if errors.Is(err, errRefundStatusChanged) {
+		return s.queries.GetRefund(ctx, refund.ID)
+	}
+	return updated, err
+}
+
+// moveRefund moves a refund on from status and keeps the order's
+// cancellation, if the refund is for one, in step with it.
+func moveRefund(ctx context.Context, q *db.Queries, refund db.Refund, from string, result utils.RefundResult) (db.Refund, error) {
+	updated, err := q.SetRefundStatus(ctx, db.SetRefundStatusParams{
+		ID:                refund.ID,
+		FromStatus:        from,
+		ToStatus:          result.Status,
+		ProviderReference: result.ProviderReference,
+		FailureReason:     result.Message,
+	})
+	if err == sql.ErrNoRows {
+		return refund, errRefundStatusChanged
+	} else if err != nil {
+		return refund, err
+	}
+
+	if refund.Source != utils.RefundForCancellation || from == result.Status {
+		return updated, nil
+	}
+
+	_, err = q.UpdateRefundStatus(ctx, db.UpdateRefundStatusParams{
+		OrderID:    refund.OrderID,
+		FromStatus: from,
+		ToStatus:   result.Status,
+	})
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	return updated, err
+}
+
+// postRefund writes a refund to the ledger: out of sales, and out of tips
+// for its tip part, back to the wallet or the provider it came from.
+// reverse takes a refund that failed back off again.
+func postRefund(ctx context.Context, q *db.Queries, refund db.Refund, payment db.Payment, toWallet, reverse bool) error {
+	destination := utils.AccountProvider
+	if toWallet {
+		wallet, err := walletAccount(ctx, q, payment.UserID)
+		if err != nil {
+			return err
+		}
+		destination = wallet.ID
+	}
+
+	lines := []utils.LedgerLine{
+		{Account: utils.AccountSales, Amount: refund.TipAmount.Sub(refund.Amount)},
+		{Account: utils.AccountTips, Amount: utils.Kobo(0).Sub(refund.TipAmount)},
+		{Account: destination, Amount: refund.Amount},
+	}
+
+	description := "Refund for order " + refund.OrderID
+	if reverse {
+		description = "Failed refund for order " + refund.OrderID
+		for i := range lines {
+			lines[i].Amount = utils.Kobo(0).Sub(lines[i].Amount)
+		}
+	}
+
+	_, err := postLedger(ctx, q, utils.LedgerRefund, description, sql.NullString{}, lines...)
+	return err
+}
+
+// finishOrderRefund marks the order refunded once refunds add up to all of
+// it, partially refunded until then. Checkout tips go back with the last of
+// the money.
+func finishOrderRefund(ctx context.Context, q *db.Queries, orderId string) error {
+	order, err := q.GetOrder(ctx, orderId)
+	if err != nil {
+		return err
+	}
+
+	totals, err := q.GetOrderRefundTotals(ctx, orderId)
+	if err != nil {
+		return err
+	}
+
+	status := utils.OrderPartiallyRefunded
+	if totals.Refunded.Cmp(order.Total) >= 0 {
+		status = utils.OrderRefunded
+		if err := q.RefundOrderTips(ctx, orderId); err != nil {
+			return err
+		}
+	}
+
+	return q.SetOrderRefundStatus(ctx, db.SetOrderRefundStatusParams{
+		ID:            orderId,
+		PaymentStatus: status,
+	})
+}
+
+// retryRefunds sends refunds left pending, such as when the server stopped
+// between approving a refund and sending it, and asks the provider about
+// refunds still in flight.
+func (s *Server) retryRefunds(ctx context.Context) {
+	pending, err := s.queries.ListRefundsByStatus(ctx, db.ListRefundsByStatusParams{
+		Status: utils.RefundPending,
+		Limit:  schedulerBatch,
+	})
+	if err != nil {
+		log.Printf("scheduler: could not list pending refunds: %v", err)
+		return
+	}
+
+	for _, refund := range pending {
+		if _, err := s.sendRefund(ctx, refund); err != nil {
+			log.Printf("scheduler: could not send refund %s: %v", refund.ID, err)
+		}
+	}
+
+	processing, err := s.queries.ListRefundsByStatus(ctx, db.ListRefundsByStatusParams{
+		Status: utils.RefundProcessing,
+		Limit:  schedulerBatch,
+	})
+	if err != nil {
+		log.Printf("scheduler: could not list refunds: %v", err)
+		return
+	}
+
+	for _, refund := range processing {
+		// Without a provider reference the provider never answered, and
+		// may never have been asked if this instance stopped in between.
+		// The refund is claimed first so only one instance sends it again.
+		if refund.ProviderReference == "" {
+			if time.Since(refund.UpdatedAt) < utils.RefundLostAfter {
+				continue
+			}
+
+			claimed, err := s.queries.ClaimRefund(ctx, db.ClaimRefundParams{
+				ID:        refund.ID,
+				UpdatedAt: refund.UpdatedAt,
+			})
+			if err == sql.ErrNoRows {
+				continue
+			}
+
+			var payment db.Payment
+			if err == nil {
+				payment, err = s.queries.GetPayment(ctx, refund.PaymentID)
+			}
+			if err == nil {
+				_, err = s.requestRefund(ctx, claimed, payment)
+			}
+			if err != nil {
+				log.Printf("scheduler: could not resend refund %s: %v", refund.ID, err)
+			}
+			continue
+		}
+
+		result, err := s.payments.RefundStatus(ctx, refund.ProviderReference)
+		if err != nil {
+			log.Printf("scheduler: could not check refund %s: %v", refund.ID, err)
+			continue
+		}
+
+		if _, err := s.updateRefund(ctx, refund, result); err != nil {
+			log.Printf("scheduler: could not update refund %s: %v", refund.ID, err)
+		}
+	}
+}
+
+func (s *Server) refundResponse(ctx context.Context, refund db.Refund) (RefundResponse, error) {
+	items, err := s.queries.ListRefundItems(ctx, refund.ID)
+	if err != nil {
+		return RefundResponse{}, err
+	}
+
+	return RefundResponse{
+		Refund:      refund,
+		RequestedBy: nullString(refund.RequestedBy),
+		ReviewedBy:  nullString(refund.ReviewedBy),
+		Items:       items,
+	}, nil
+}
@@ -0,0 +1,250 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	reorderUnchanged      = "unchanged"
+	reorderPriceChanged   = "price_changed"
+	reorderOptionsChanged = "options_changed"
+	reorderDropped        = "dropped"
+)
+
+// ReorderChange describes what happened to one line of the old order.
+type ReorderChange struct {
+	ProductID      *string      `json:"product_id"`
+	Name           string       `json:"name"`
+	Quantity       int32        `json:"quantity"`
+	Status         string       `json:"status"`
+	Reason         string       `json:"reason,omitempty"`
+	OldUnitPrice   utils.Money  `json:"old_unit_price"`
+	NewUnitPrice   *utils.Money `json:"new_unit_price"`
+	DroppedOptions []string     `json:"dropped_options"`
+}
+
+type ReorderResponse struct {
+	Cart         CartResponse    `json:"cart"`
+	Changes      []ReorderChange `json:"changes"`
+	OldSubtotal  utils.Money     `json:"old_subtotal"`
+	ReplacedCart bool            `json:"replaced_cart"`
+}
+
+// reorder rebuilds the user's cart from a past order at today's prices. Items
+// and options that can no longer be bought are left out, and the response
+// says line by line what is different from last time.
+func (o *Order) reorder(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	order, ok := o.server.customerOrder(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	items, err := o.server.queries.ListOrderItems(context.Background(), order.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	cart, changes, err := o.server.rebuildCart(context.Background(), order, items)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	replaced := false
+	stored, err := updateCart(context.Background(), cartKey(userId), func(existing *storedCart) error {
+		replaced = len(existing.Items) > 0
+		*existing = cart
+		return nil
+	})
+	if !cartError(ctx, err) {
+		return
+	}
+
+	priced, err := o.server.priceCart(context.Background(), stored)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	// Price changes are only known once the rebuilt lines are priced.
+	newPrices := map[string]utils.Money{}
+	for _, line := range priced.Lines {
+		newPrices[line.ID] = line.UnitPrice
+	}
+	for i := range changes {
+		if changes[i].Status == reorderDropped {
+			continue
+		}
+
+		price := newPrices[changes[i].lineId]
+		changes[i].NewUnitPrice = &price
+		if changes[i].Status == reorderUnchanged && price.Cmp(changes[i].OldUnitPrice) != 0 {
+			changes[i].Status = reorderPriceChanged
+		}
+	}
+
+	response := ReorderResponse{
+		Cart:         priced,
+		Changes:      []ReorderChange{},
+		OldSubtotal:  order.Subtotal,
+		ReplacedCart: replaced,
+	}
+	for _, change := range changes {
+		response.Changes = append(response.Changes, change.ReorderChange)
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"statusCode": http.StatusCreated,
+		"status":     "success",
+		"message":    "cart rebuilt from order",
+		"data":       response,
+	})
+}
+
+type reorderLine struct {
+	ReorderChange
+	lineId string
+}
+
+// rebuildCart works out which of an order's items and options can still be
+// bought and builds a cart from them.
+func (s *Server) rebuildCart(ctx context.Context, order db.Order, items []db.OrderItem) (storedCart, []reorderLine, error) {
+	cart := storedCart{Items: []CartItem{}}
+	changes := []reorderLine{}
+
+	itemIds := []string{}
+	productIds := []string{}
+	for _, item := range items {
+		itemIds = append(itemIds, item.ID)
+		if item.ProductID.Valid {
+			productIds = append(productIds, item.ProductID.String)
+		}
+	}
+
+	products, err := s.queries.ListProductsByIDs(ctx, utils.Dedupe(productIds))
+	if err != nil {
+		return cart, nil, err
+	}
+	productsById := map[string]db.Product{}
+	for _, product := range products {
+		productsById[product.ID] = product
+	}
+
+	orderedOptions, err := s.queries.ListOrderItemOptions(ctx, itemIds)
+	if err != nil {
+		return cart, nil, err
+	}
+
+	optionIds := []string{}
+	optionsByItem := map[string][]db.OrderItemOption{}
+	for _, option := range orderedOptions {
+		optionsByItem[option.OrderItemID] = append(optionsByItem[option.OrderItemID], option)
+		if option.OptionID.Valid {
+			optionIds = append(optionIds, option.OptionID.String)
+		}
+	}
+
+	currentOptions := map[string]db.ProductOption{}
+	if len(optionIds) > 0 {
+		options, err := s.queries.ListProductOptionsByIDs(ctx, utils.Dedupe(optionIds))
+		if err != nil {
+			return cart, nil, err
+		}
+		for _, option := range options {
+			currentOptions[option.ID] = option
+		}
+	}
+
+	for _, item := range items {
+		change := reorderLine{ReorderChange: ReorderChange{
+			ProductID:      nullString(item.ProductID),
+			Name:           item.ProductName,
+			Quantity:       item.Quantity,
+			Status:         reorderUnchanged,
+			OldUnitPrice:   item.UnitPrice,
+			DroppedOptions: []string{},
+		}}
+
+		product, ok := productsById[item.ProductID.String]
+		switch {
+		case !item.ProductID.Valid || !ok:
+			change.Status = reorderDropped
+			change.Reason = "This product is no longer on the menu."
+		case product.ShopID != order.ShopID:
+			change.Status = reorderDropped
+			change.Reason = "This product is no longer sold by this shop."
+		case !product.IsAvailable:
+			change.Status = reorderDropped
+			change.Reason = "This product is currently unavailable."
+		}
+		if change.Status == reorderDropped {
+			changes = append(changes, change)
+			continue
+		}
+
+		keep := []string{}
+		for _, option := range optionsByItem[item.ID] {
+			current, ok := currentOptions[option.OptionID.String]
+			if !option.OptionID.Valid || !ok || current.ProductID != product.ID || !current.IsAvailable {
+				change.DroppedOptions = append(change.DroppedOptions, option.Name)
+				continue
+			}
+			keep = append(keep, current.ID)
+		}
+		sort.Strings(keep)
+
+		if len(change.DroppedOptions) > 0 {
+			change.Status = reorderOptionsChanged
+			change.Reason = "Some options are no longer available."
+		}
+
+		lineId, err := utils.NewID()
+		if err != nil {
+			return cart, nil, err
+		}
+
+		item := CartItem{
+			ID:        lineId,
+			ProductID: product.ID,
+			ShopID:    product.ShopID,
+			Quantity:  item.Quantity,
+			OptionIDs: keep,
+			Note:      item.Note,
+		}
+		if err := addCartLine(&cart, item); err != nil {
+			return cart, nil, err
+		}
+
+		// Lines that now look the same, because an option was dropped from
+		// one of them, are merged by addCartLine.
+		for _, line := range cart.Items {
+			if line.ProductID == item.ProductID && line.Note == item.Note && sameOptions(line.OptionIDs, item.OptionIDs) {
+				change.lineId = line.ID
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	return cart, changes, nil
+}
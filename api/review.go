@@ -0,0 +1,644 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type Review struct {
+	server *Server
+}
+
+const (
+	reviewPublished = "published"
+	reviewHidden    = "hidden"
+)
+
+type ReviewItemInput struct {
+	ProductID string `json:"product_id" binding:"required"`
+	Rating    int16  `json:"rating" binding:"required,min=1,max=5"`
+	Comment   string `json:"comment" binding:"max=1000"`
+}
+
+type CreateReviewParams struct {
+	ShopID         string            `json:"shop_id" binding:"required"`
+	OrderID        string            `json:"order_id" binding:"required"`
+	ShopRating     int16             `json:"shop_rating" binding:"required,min=1,max=5"`
+	DeliveryRating *int16            `json:"delivery_rating" binding:"omitempty,min=1,max=5"`
+	Comment        string            `json:"comment" binding:"max=2000"`
+	Items          []ReviewItemInput `json:"items" binding:"omitempty,max=50,dive"`
+}
+
+type ReplyReviewParams struct {
+	Reply string `json:"reply" binding:"required,max=2000"`
+}
+
+type ReportReviewParams struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+type ModerateReviewParams struct {
+	Action string `json:"action" binding:"required,oneof=hide publish"`
+}
+
+type ListReviewsParams struct {
+	PageID   int32 `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32 `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type ReviewResponse struct {
+	ID             string          `json:"id"`
+	UserID         string          `json:"user_id"`
+	ShopID         string          `json:"shop_id"`
+	OrderID        *string         `json:"order_id"`
+	ShopRating     int16           `json:"shop_rating"`
+	DeliveryRating *int16          `json:"delivery_rating"`
+	Comment        string          `json:"comment"`
+	VendorReply    *string         `json:"vendor_reply"`
+	RepliedAt      *time.Time      `json:"replied_at"`
+	Status         string          `json:"status"`
+	Items          []db.ReviewItem `json:"items"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+type ProductReviewResponse struct {
+	ID          string    `json:"id"`
+	ReviewID    string    `json:"review_id"`
+	UserID      string    `json:"user_id"`
+	Rating      int16     `json:"rating"`
+	Comment     string    `json:"comment"`
+	VendorReply *string   `json:"vendor_reply"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (r Review) router(server *Server) {
+	r.server = server
+
+	serverGroup := server.router.Group("/reviews")
+	serverGroup.POST("", AuthenticatedMiddleware(), IdempotencyMiddleware(), r.createReview)
+	serverGroup.POST("/:id/reply", AuthenticatedMiddleware(), r.replyToReview)
+	serverGroup.POST("/:id/report", AuthenticatedMiddleware(), r.reportReview)
+
+	server.router.GET("/shops/:id/reviews", r.listShopReviews)
+	server.router.GET("/products/:id/reviews", r.listProductReviews)
+
+	adminGroup := server.router.Group("/admin/reviews", AuthenticatedMiddleware(), AdminMiddleware())
+	adminGroup.GET("/reported", r.listReportedReviews)
+	adminGroup.GET("/:id", r.getReviewForModeration)
+	adminGroup.PUT("/:id/moderate", r.moderateReview)
+}
+
+func (r *Review) createReview(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := CreateReviewParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	shop, err := r.server.queries.GetShop(context.Background(), input.ShopID)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"Error":      err.Error(),
+			"message":    "The requested shop does not exist.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if shop.OwnerID == userId {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": "Forbidden: you cannot review your own shop",
+		})
+		return
+	}
+
+	order, ordered, ok := r.server.reviewableOrder(ctx, input.OrderID, userId, shop.ID)
+	if !ok {
+		return
+	}
+
+	seen := map[string]bool{}
+	for _, item := range input.Items {
+		if !ordered[item.ProductID] {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"Error": "product " + item.ProductID + " was not part of this order",
+			})
+			return
+		}
+		if seen[item.ProductID] {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"Error": "each product can only be rated once per review",
+			})
+			return
+		}
+		seen[item.ProductID] = true
+
+		product, err := r.server.queries.GetProduct(context.Background(), item.ProductID)
+		if err == sql.ErrNoRows || (err == nil && product.ShopID != shop.ID) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"Error": "product " + item.ProductID + " does not belong to this shop",
+			})
+			return
+		} else if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"Error": err.Error(),
+			})
+			return
+		}
+	}
+
+	reviewId, err := utils.NewID()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Failed to generate CUID": err.Error(),
+		})
+		return
+	}
+
+	var review db.Review
+	items := []db.ReviewItem{}
+
+	err = r.server.execTx(ctx, func(q *db.Queries) error {
+		var err error
+
+		deliveryRating := sql.NullInt16{}
+		if input.DeliveryRating != nil {
+			deliveryRating = sql.NullInt16{Int16: *input.DeliveryRating, Valid: true}
+		}
+
+		review, err = q.CreateReview(ctx, db.CreateReviewParams{
+			ID:             reviewId,
+			UserID:         userId,
+			ShopID:         shop.ID,
+			OrderID:        sql.NullString{String: order.ID, Valid: true},
+			ShopRating:     input.ShopRating,
+			DeliveryRating: deliveryRating,
+			Comment:        strings.TrimSpace(input.Comment),
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, item := range input.Items {
+			itemId, err := utils.NewID()
+			if err != nil {
+				return err
+			}
+
+			reviewItem, err := q.CreateReviewItem(ctx, db.CreateReviewItemParams{
+				ID:        itemId,
+				ReviewID:  review.ID,
+				ProductID: item.ProductID,
+				Rating:    item.Rating,
+				Comment:   strings.TrimSpace(item.Comment),
+			})
+			if err != nil {
+				return err
+			}
+			items = append(items, reviewItem)
+		}
+
+		return refreshRatings(ctx, q, review)
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"Error": "you have already reviewed this order",
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"statusCode": http.StatusCreated,
+		"status":     "success",
+		"message":    "review created successfully",
+		"data":       newReviewResponse(review, items),
+	})
+}
+
+// reviewableOrder loads the order a review is for. Only the customer who
+// placed it can review it, and only once it has been delivered by shopId.
+// It also returns the products on the order, the only ones the review may
+// rate.
+func (s *Server) reviewableOrder(ctx *gin.Context, orderId, userId, shopId string) (db.Order, map[string]bool, bool) {
+	order, ok := s.customerOrder(ctx, orderId, userId)
+	if !ok {
+		return order, nil, false
+	}
+
+	if order.ShopID != shopId || order.Status != utils.OrderDelivered {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    "You can only review a delivered order from this shop.",
+		})
+		return order, nil, false
+	}
+
+	items, err := s.queries.ListOrderItems(context.Background(), order.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return order, nil, false
+	}
+
+	ordered := map[string]bool{}
+	for _, item := range items {
+		if item.ProductID.Valid {
+			ordered[item.ProductID.String] = true
+		}
+	}
+	return order, ordered, true
+}
+
+func (r *Review) listShopReviews(ctx *gin.Context) {
+	input := ListReviewsParams{}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	limit, offset := pagination(input.PageID, input.PageSize)
+
+	reviews, err := r.server.queries.ListShopReviews(context.Background(), db.ListShopReviewsParams{
+		ShopID: ctx.Param("id"),
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	response := make([]ReviewResponse, 0, len(reviews))
+	for _, review := range reviews {
+		items, err := r.server.queries.ListReviewItems(context.Background(), review.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"Error": err.Error(),
+			})
+			return
+		}
+		response = append(response, newReviewResponse(review, items))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "reviews fetched successfully",
+		"data":       response,
+	})
+}
+
+func (r *Review) listProductReviews(ctx *gin.Context) {
+	input := ListReviewsParams{}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	limit, offset := pagination(input.PageID, input.PageSize)
+
+	reviews, err := r.server.queries.ListProductReviews(context.Background(), db.ListProductReviewsParams{
+		ProductID: ctx.Param("id"),
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	response := make([]ProductReviewResponse, 0, len(reviews))
+	for _, review := range reviews {
+		response = append(response, ProductReviewResponse{
+			ID:          review.ID,
+			ReviewID:    review.ReviewID,
+			UserID:      review.UserID,
+			Rating:      review.Rating,
+			Comment:     review.Comment,
+			VendorReply: nullString(review.VendorReply),
+			CreatedAt:   review.CreatedAt,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "reviews fetched successfully",
+		"data":       response,
+	})
+}
+
+func (r *Review) replyToReview(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := ReplyReviewParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	review, ok := r.getReview(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	if _, ok := r.server.ownedShop(ctx, review.ShopID, userId); !ok {
+		return
+	}
+
+	review, err := r.server.queries.ReplyToReview(context.Background(), db.ReplyToReviewParams{
+		ID:          review.ID,
+		VendorReply: sql.NullString{String: strings.TrimSpace(input.Reply), Valid: true},
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "reply saved successfully",
+		"data":    newReviewResponse(review, nil),
+	})
+}
+
+func (r *Review) reportReview(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := ReportReviewParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	review, ok := r.getReview(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	id, err := utils.NewID()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Failed to generate CUID": err.Error(),
+		})
+		return
+	}
+
+	report, err := r.server.queries.CreateReviewReport(context.Background(), db.CreateReviewReportParams{
+		ID:         id,
+		ReviewID:   review.ID,
+		ReporterID: userId,
+		Reason:     strings.TrimSpace(input.Reason),
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"Error": "you have already reported this review",
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"statusCode": http.StatusCreated,
+		"status":     "success",
+		"message":    "review reported successfully",
+		"data":       report,
+	})
+}
+
+func (r *Review) listReportedReviews(ctx *gin.Context) {
+	input := ListReviewsParams{}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	limit, offset := pagination(input.PageID, input.PageSize)
+
+	reviews, err := r.server.queries.ListReportedReviews(context.Background(), db.ListReportedReviewsParams{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "reported reviews fetched successfully",
+		"data":       reviews,
+	})
+}
+
+func (r *Review) getReviewForModeration(ctx *gin.Context) {
+	review, ok := r.getReview(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	items, err := r.server.queries.ListReviewItems(context.Background(), review.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	reports, err := r.server.queries.ListReviewReports(context.Background(), review.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "review fetched successfully",
+		"data": gin.H{
+			"review":  newReviewResponse(review, items),
+			"reports": reports,
+		},
+	})
+}
+
+// moderateReview hides or re-publishes a review and closes its open reports.
+// Ratings are recalculated since hidden reviews don't count.
+func (r *Review) moderateReview(ctx *gin.Context) {
+	input := ModerateReviewParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	review, ok := r.getReview(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	status := reviewPublished
+	if input.Action == "hide" {
+		status = reviewHidden
+	}
+
+	err := r.server.execTx(ctx, func(q *db.Queries) error {
+		var err error
+
+		review, err = q.SetReviewStatus(ctx, db.SetReviewStatusParams{
+			ID:     review.ID,
+			Status: status,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := q.ResolveReviewReports(ctx, review.ID); err != nil {
+			return err
+		}
+
+		return refreshRatings(ctx, q, review)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "review moderated successfully",
+		"data":    newReviewResponse(review, nil),
+	})
+}
+
+func (r *Review) getReview(ctx *gin.Context, id string) (db.Review, bool) {
+	review, err := r.server.queries.GetReview(context.Background(), id)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"Error":      err.Error(),
+			"message":    "The requested review does not exist.",
+		})
+		return db.Review{}, false
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return db.Review{}, false
+	}
+
+	return review, true
+}
+
+// refreshRatings recomputes the cached average and count on the shop and on
+// every product rated in the review.
+func refreshRatings(ctx context.Context, q *db.Queries, review db.Review) error {
+	if err := q.RefreshShopRating(ctx, review.ShopID); err != nil {
+		return err
+	}
+	return q.RefreshReviewProductRatings(ctx, review.ID)
+}
+
+func newReviewResponse(review db.Review, items []db.ReviewItem) ReviewResponse {
+	if items == nil {
+		items = []db.ReviewItem{}
+	}
+
+	return ReviewResponse{
+		ID:             review.ID,
+		UserID:         review.UserID,
+		ShopID:         review.ShopID,
+		OrderID:        nullString(review.OrderID),
+		ShopRating:     review.ShopRating,
+		DeliveryRating: nullInt16(review.DeliveryRating),
+		Comment:        review.Comment,
+		VendorReply:    nullString(review.VendorReply),
+		RepliedAt:      nullTime(review.RepliedAt),
+		Status:         review.Status,
+		Items:          items,
+		CreatedAt:      review.CreatedAt,
+		UpdatedAt:      review.UpdatedAt,
+	}
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type Rider struct {
+	server *Server
+}
+
+type RiderLocationParams struct {
+	Lat *float64 `json:"lat" binding:"required,min=-90,max=90"`
+	Lng *float64 `json:"lng" binding:"required,min=-180,max=180"`
+}
+
+type RiderUpdateOrderStatusParams struct {
+	Status string `json:"status" binding:"required,oneof=picked_up delivered"`
+	Note   string `json:"note" binding:"max=500"`
+}
+
+func (r Rider) router(server *Server) {
+	r.server = server
+
+	serverGroup := server.router.Group("/rider/orders", AuthenticatedMiddleware(), RiderMiddleware())
+	serverGroup.GET("/available", r.listAvailableOrders)
+	serverGroup.GET("", r.listRiderOrders)
+	serverGroup.PUT("/:id/status", r.updateOrderStatus)
+	serverGroup.POST("/:id/location", r.updateLocation)
+
+	server.router.GET("/rider/earnings", AuthenticatedMiddleware(), RiderMiddleware(), r.getEarnings)
+}
+
+// listAvailableOrders lists orders that are ready and not yet claimed by a
+// rider. Picking one up claims it.
+func (r *Rider) listAvailableOrders(ctx *gin.Context) {
+	input := ListOrdersParams{}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	limit, offset := pagination(input.PageID, input.PageSize)
+
+	orders, err := r.server.queries.ListAvailableDeliveries(context.Background(), db.ListAvailableDeliveriesParams{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "available orders fetched successfully",
+		"data":       newOrderSummaries(orders),
+	})
+}
+
+func (r *Rider) listRiderOrders(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := ListOrdersParams{}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	limit, offset := pagination(input.PageID, input.PageSize)
+
+	orders, err := r.server.queries.ListRiderOrders(context.Background(), db.ListRiderOrdersParams{
+		RiderID: sql.NullString{String: userId, Valid: true},
+		Limit:   limit,
+		Offset:  offset,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "rider orders fetched successfully",
+		"data":       newOrderSummaries(orders),
+	})
+}
+
+func (r *Rider) updateOrderStatus(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := RiderUpdateOrderStatusParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	order, err := r.server.queries.GetOrder(context.Background(), ctx.Param("id"))
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"Error":      err.Error(),
+			"message":    "The requested order does not exist.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	// Unclaimed orders are open to any rider; once claimed only that rider
+	// may move them on.
+	if order.RiderID.Valid && order.RiderID.String != userId {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": "Forbidden: this order is assigned to another rider",
+		})
+		return
+	}
+
+	r.server.advanceOrder(ctx, order, orderChange{To: input.Status, ActorID: userId, Actor: utils.ActorRider, Note: input.Note})
+}
+
+// updateLocation is called by the rider app while an order is on the road and
+// is pushed to whoever is tracking the order.
+func (r *Rider) updateLocation(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := RiderLocationParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	order, err := r.server.queries.GetOrder(context.Background(), ctx.Param("id"))
+	if err == sql.ErrNoRows || (err == nil && order.RiderID.String != userId) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"message":    "The requested order does not exist.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if order.Status != utils.OrderPickedUp {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    "Location is only shared while an order is being delivered.",
+		})
+		return
+	}
+
+	if err := publishRiderLocation(order.ID, *input.Lat, *input.Lng); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "location updated",
+	})
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+)
+
+const (
+	schedulerInterval = time.Minute
+	schedulerBatch    = 100
+)
+
+// runScheduler releases scheduled orders to their shops once they are due,
+// and keeps settlements, payouts and refunds moving.
+// Every instance runs it; the status update only succeeds once per order, so
+// instances racing for the same order is harmless.
+func (s *Server) runScheduler(ctx context.Context) {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		s.releaseScheduledOrders(ctx)
+		s.lockExpiredGroupOrders(ctx)
+		s.closeSettlements(ctx)
+		s.refreshPayouts(ctx)
+		s.retryRefunds(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) releaseScheduledOrders(ctx context.Context) {
+	for {
+		orders, err := s.queries.ListDueScheduledOrders(ctx, db.ListDueScheduledOrdersParams{
+			ReleaseAt: sql.NullTime{Time: time.Now(), Valid: true},
+			Limit:     schedulerBatch,
+		})
+		if err != nil {
+			log.Printf("scheduler: could not list due orders: %v", err)
+			return
+		}
+
+		released := 0
+		for _, order := range orders {
+			_, err := s.transitionOrder(ctx, order, orderChange{To: utils.OrderPending, Actor: utils.ActorSystem, Note: "released for scheduled delivery"})
+			if err == nil || errors.Is(err, errOrderStatusChanged) {
+				released++
+			} else {
+				log.Printf("scheduler: could not release order %s: %v", order.ID, err)
+			}
+		}
+
+		// Stop on a short batch, or when nothing moved so a persistent error
+		// cannot spin here.
+		if len(orders) < schedulerBatch || released == 0 {
+			return
+		}
+	}
+}
+
+// lockExpiredGroupOrders stops group orders taking items once their deadline
+// has passed. The host still has to submit them.
+func (s *Server) lockExpiredGroupOrders(ctx context.Context) {
+	if _, err := s.queries.LockExpiredGroupOrders(ctx, time.Now()); err != nil {
+		log.Printf("scheduler: could not lock expired group orders: %v", err)
+	}
+}
@@ -1,9 +1,12 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"net/http"
+	"reflect"
+	"time"
 
 	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
 	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
@@ -16,9 +19,31 @@ import (
 )
 
 type Server struct {
-	queries *db.Queries
-	router  *gin.Engine
-	config2 *utils.Config
+	conn       *sql.DB
+	queries    *db.Queries
+	router     *gin.Engine
+	config2    *utils.Config
+	imageStore utils.ImageStore
+	imageURLs  *utils.ImageURLVerifier
+	location   *time.Location
+	release    time.Duration
+
+	cancellation utils.CancellationPolicy
+	delivery     utils.DeliveryPricing
+	tax          utils.TaxRules
+
+	// riderCommission is the percentage of each delivery fee the platform
+	// keeps. Tips are never included.
+	riderCommission int64
+
+	payments utils.PaymentProvider
+
+	vendorFees utils.VendorFees
+	payouts    utils.PayoutProvider
+
+	// refundApproval is the largest support refund that goes out without a
+	// second admin approving it.
+	refundApproval utils.Money
 }
 
 var tokenManager *utils.JWTToken
@@ -39,35 +64,165 @@ func NewServer(envPath string) *Server {
 
 	q := db.New(conn)
 
+	tokenManager = utils.NewJWTToken(config2)
+
+	imageStore, err := utils.NewImageStore(config2)
+	if err != nil {
+		panic(fmt.Sprintf("Could not set up image store: %v", err))
+	}
+
 	gin.SetMode(gin.ReleaseMode)
 
-	g := gin.Default()
+	g := gin.New()
+	g.Use(requestLogger(), gin.Recovery())
 
 	g.MaxMultipartMemory = 8 << 20
 
 	g.Use(cors.Default())
 
+	if local, ok := imageStore.(*utils.LocalStore); ok {
+		g.Static(local.BaseURL(), local.Dir())
+	}
+
+	timezone := config2.Timezone
+	if timezone == "" {
+		timezone = utils.DefaultTimezone
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		panic(fmt.Sprintf("Unknown TIMEZONE %q: %v", timezone, err))
+	}
+
+	release := utils.DefaultScheduleRelease
+	if config2.ScheduleRelease > 0 {
+		release = time.Duration(config2.ScheduleRelease) * time.Minute
+	}
+
+	cancellationFee := int64(utils.DefaultCancellationFee)
+	if config2.CancellationFee > 0 {
+		cancellationFee = int64(config2.CancellationFee)
+	}
+
+	riderCommission := int64(utils.DefaultRiderCommission)
+	if config2.RiderCommission > 0 {
+		riderCommission = int64(config2.RiderCommission)
+	}
+
+	vendorFees := utils.VendorFees{
+		CommissionPercent:        utils.DefaultVendorCommission,
+		ProcessingFeeBasisPoints: utils.DefaultProcessingFeeBasisPoints,
+	}
+	if config2.VendorCommission > 0 {
+		vendorFees.CommissionPercent = int64(config2.VendorCommission)
+	}
+	if config2.ProcessingFee > 0 {
+		vendorFees.ProcessingFeeBasisPoints = int64(config2.ProcessingFee)
+	}
+
+	refundApproval := utils.DefaultRefundApproval
+	if config2.RefundApproval > 0 {
+		refundApproval = utils.Naira(int64(config2.RefundApproval))
+	}
+
+	delivery := utils.DefaultDeliveryPricing()
+	if config2.DeliveryPricing != "" {
+		delivery, err = utils.LoadDeliveryPricing(config2.DeliveryPricing)
+		if err != nil {
+			panic(fmt.Sprintf("Could not load DELIVERY_PRICING_FILE: %v", err))
+		}
+	}
+
+	tax := utils.DefaultTaxRules()
+	if config2.TaxRules != "" {
+		tax, err = utils.LoadTaxRules(config2.TaxRules)
+		if err != nil {
+			panic(fmt.Sprintf("Could not load TAX_RULES_FILE: %v", err))
+		}
+	}
+
+	cancellation := utils.NewCancellationPolicy(cancellationFee)
+	if config2.CancellationRules != "" {
+		cancellation, err = utils.LoadCancellationPolicy(config2.CancellationRules)
+		if err != nil {
+			panic(fmt.Sprintf("Could not load CANCELLATION_POLICY_FILE: %v", err))
+		}
+	}
+
+	payments, err := utils.NewPaymentProvider(config2)
+	if err != nil {
+		panic(fmt.Sprintf("Could not set up payment provider: %v", err))
+	}
+
+	payouts, err := utils.NewPayoutProvider(config2)
+	if err != nil {
+		panic(fmt.Sprintf("Could not set up payout provider: %v", err))
+	}
+
 	Rdb = redis.NewClient(&redis.Options{
 		Addr:     config2.RedisAddress,
 		Password: config2.RedisPassword,
 		DB:       0, // use default DB
 	})
 
-	return &Server{
-		queries: q,
-		router:  g,
-		config2: config2,
+	server := &Server{
+		conn:       conn,
+		queries:    q,
+		router:     g,
+		config2:    config2,
+		imageStore: imageStore,
+		imageURLs:  utils.NewImageURLVerifier(Rdb),
+		location:   location,
+		release:    release,
+
+		cancellation: cancellation,
+		delivery:     delivery,
+		tax:          tax,
+
+		riderCommission: riderCommission,
+
+		payments: payments,
+
+		vendorFees: vendorFees,
+		payouts:    payouts,
+
+		refundApproval: refundApproval,
 	}
 
+	server.setupRouter()
+	return server
 }
 
-func (s *Server) Start(port int) {
+// execTx runs fn inside a single database transaction, rolling back if fn
+// returns an error.
+func (s *Server) execTx(ctx context.Context, fn func(*db.Queries) error) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(s.queries.WithTx(tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("tx err: %v, rollback err: %v", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// setupRouter registers the custom validators and every route.
+func (s *Server) setupRouter() {
 
 	if V, ok := binding.Validator.Engine().(*validator.Validate); ok {
 
 		V.RegisterValidation("passwordStrength", ValidatePassword)
 		V.RegisterValidation("isImageURL", ImageURLValidation)
 		V.RegisterValidation("isPositive", PriceValidation)
+		V.RegisterValidation("dietaryTag", DietaryTagValidation)
+		V.RegisterValidation("allergen", AllergenValidation)
+		V.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+			return field.Interface().(utils.Money).Kobo()
+		}, utils.Money{})
 
 	}
 
@@ -79,12 +234,34 @@ func (s *Server) Start(port int) {
 
 	User{}.router(s)
 	Auth{}.router(s)
+	Upload{}.router(s)
 	// Category{}.router(s)
 	// SubCategory{}.router(s)
-	// Shop{}.router(s)
-	// Product{}.router(s)
+	Shop{}.router(s)
+	Product{}.router(s)
+	Review{}.router(s)
+	Favourite{}.router(s)
+	Cart{}.router(s)
 	// Oauth{}.router(s)
-	// Order{}.router(s)
+	Order{}.router(s)
+	VendorOrder{}.router(s)
+	Rider{}.router(s)
+	Tracking{}.router(s)
+	GroupOrder{}.router(s)
+	Payment{}.router(s)
+	Wallet{}.router(s)
+	Settlement{}.router(s)
+	Refund{}.router(s)
+	Coupon{}.router(s)
+}
+
+func (s *Server) Start(port int) {
+	go s.runScheduler(context.Background())
 
 	s.router.Run(fmt.Sprintf(":%d", port))
 }
+
+// ServeHTTP lets tests send requests to the server without starting it.
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.router.ServeHTTP(w, req)
+}
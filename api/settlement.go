@@ -0,0 +1,818 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type Settlement struct {
+	server *Server
+}
+
+type BankAccountParams struct {
+	BankCode      string `json:"bank_code" binding:"required,numeric,min=3,max=6"`
+	AccountNumber string `json:"account_number" binding:"required,numeric,len=10"`
+}
+
+// PayoutParams asks for amount to be paid out, or the whole available
+// balance when it is left out.
+type PayoutParams struct {
+	Amount *utils.Money `json:"amount" binding:"omitempty,isPositive"`
+}
+
+type SettlementReportParams struct {
+	ShopID   string     `form:"shop_id" binding:"max=50"`
+	From     *time.Time `form:"from"`
+	To       *time.Time `form:"to"`
+	PageID   int32      `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32      `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// ShopBalanceResponse is what a shop can be paid now and what is still
+// waiting for its week to be settled.
+type ShopBalanceResponse struct {
+	Available     utils.Money         `json:"available"`
+	Pending       utils.Money         `json:"pending"`
+	PendingOrders int64               `json:"pending_orders"`
+	BankAccount   *db.ShopBankAccount `json:"bank_account"`
+}
+
+type SettlementResponse struct {
+	db.Settlement
+	Earnings []db.VendorEarning `json:"earnings"`
+}
+
+// SettlementReportResponse totals the settlements in a range for admins.
+type SettlementReportResponse struct {
+	From        time.Time                 `json:"from"`
+	To          time.Time                 `json:"to"`
+	Totals      db.GetSettlementReportRow `json:"totals"`
+	PaidOut     utils.Money               `json:"paid_out"`
+	Settlements []db.Settlement           `json:"settlements"`
+}
+
+var errNothingToSettle = errors.New("no earnings to settle")
+
+func (st Settlement) router(server *Server) {
+	st.server = server
+
+	serverGroup := server.router.Group("/shops/:id", AuthenticatedMiddleware())
+	serverGroup.GET("/balance", st.getShopBalance)
+	serverGroup.GET("/settlements", st.listShopSettlements)
+	serverGroup.GET("/settlements/:settlement_id", st.getShopSettlement)
+	serverGroup.PUT("/bank_account", st.updateBankAccount)
+	serverGroup.GET("/payouts", st.listPayouts)
+	serverGroup.POST("/payouts", IdempotencyMiddleware(), st.requestPayout)
+
+	adminGroup := server.router.Group("/admin/settlements", AuthenticatedMiddleware(), AdminMiddleware())
+	adminGroup.GET("", st.settlementReport)
+	adminGroup.GET("/:id", st.getSettlement)
+	adminGroup.POST("/run", st.runSettlements)
+}
+
+func (st *Settlement) getShopBalance(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	shop, ok := st.server.ownedShop(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	account, err := shopAccount(context.Background(), st.server.queries, shop.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	pending, err := st.server.queries.GetUnsettledEarnings(context.Background(), shop.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	response := ShopBalanceResponse{
+		Available:     account.Balance,
+		Pending:       pending.Net,
+		PendingOrders: pending.Orders,
+	}
+
+	bank, err := st.server.queries.GetShopBankAccount(context.Background(), shop.ID)
+	if err != nil && err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	} else if err == nil {
+		response.BankAccount = &bank
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "balance fetched successfully",
+		"data":       response,
+	})
+}
+
+func (st *Settlement) listShopSettlements(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := ListOrdersParams{}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	shop, ok := st.server.ownedShop(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	limit, offset := pagination(input.PageID, input.PageSize)
+
+	settlements, err := st.server.queries.ListShopSettlements(context.Background(), db.ListShopSettlementsParams{
+		ShopID: shop.ID,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "settlements fetched successfully",
+		"data":       settlements,
+	})
+}
+
+func (st *Settlement) getShopSettlement(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	shop, ok := st.server.ownedShop(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	st.server.respondSettlement(ctx, ctx.Param("settlement_id"), shop.ID)
+}
+
+func (st *Settlement) getSettlement(ctx *gin.Context) {
+	st.server.respondSettlement(ctx, ctx.Param("id"), "")
+}
+
+// respondSettlement writes a settlement with the orders in it. A shopId
+// limits it to that shop's settlements.
+func (s *Server) respondSettlement(ctx *gin.Context, settlementId, shopId string) {
+	settlement, err := s.queries.GetSettlement(context.Background(), settlementId)
+	if err == nil && shopId != "" && settlement.ShopID != shopId {
+		err = sql.ErrNoRows
+	}
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"message":    "The requested settlement does not exist.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	earnings, err := s.queries.ListSettlementEarnings(context.Background(), sql.NullString{String: settlement.ID, Valid: true})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "settlement fetched successfully",
+		"data":       SettlementResponse{Settlement: settlement, Earnings: earnings},
+	})
+}
+
+// updateBankAccount verifies the account with the payout provider before
+// saving it, so payouts only ever go to an account the bank recognises.
+func (st *Settlement) updateBankAccount(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := BankAccountParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	shop, ok := st.server.ownedShop(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	verified, err := st.server.payouts.VerifyAccount(context.Background(), utils.BankAccount{
+		BankCode:      input.BankCode,
+		AccountNumber: input.AccountNumber,
+	})
+	if errors.Is(err, utils.ErrAccountNotFound) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    "We could not verify that bank account.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	bank, err := st.server.queries.UpsertShopBankAccount(context.Background(), db.UpsertShopBankAccountParams{
+		ShopID:        shop.ID,
+		BankCode:      input.BankCode,
+		AccountNumber: input.AccountNumber,
+		AccountName:   verified.AccountName,
+		Provider:      st.server.payouts.Name(),
+		RecipientCode: verified.RecipientCode,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "bank account verified successfully",
+		"data":       bank,
+	})
+}
+
+func (st *Settlement) listPayouts(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := ListOrdersParams{}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	shop, ok := st.server.ownedShop(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	limit, offset := pagination(input.PageID, input.PageSize)
+
+	payouts, err := st.server.queries.ListShopPayouts(context.Background(), db.ListShopPayoutsParams{
+		ShopID: shop.ID,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "payouts fetched successfully",
+		"data":       payouts,
+	})
+}
+
+// requestPayout takes the money out of the shop's balance first and then
+// sends the transfer, so two requests can never pay out the same money. If
+// the transfer fails the money goes back.
+func (st *Settlement) requestPayout(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := PayoutParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	shop, ok := st.server.ownedShop(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	bank, err := st.server.queries.GetShopBankAccount(context.Background(), shop.ID)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    "Add a verified bank account before requesting a payout.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	account, err := shopAccount(context.Background(), st.server.queries, shop.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	amount := account.Balance
+	if input.Amount != nil {
+		amount = *input.Amount
+	}
+
+	if amount.Cmp(utils.MinPayout) < 0 {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    fmt.Sprintf("Payouts must be at least %s.", utils.MinPayout),
+		})
+		return
+	}
+
+	id, err := utils.NewID()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Failed to generate CUID": err.Error(),
+		})
+		return
+	}
+
+	var payout db.Payout
+	err = st.server.execTx(ctx, func(q *db.Queries) error {
+		var err error
+
+		payout, err = q.CreatePayout(ctx, db.CreatePayoutParams{
+			ID:            id,
+			ShopID:        shop.ID,
+			RequestedBy:   sql.NullString{String: userId, Valid: true},
+			Amount:        amount,
+			BankCode:      bank.BankCode,
+			AccountNumber: bank.AccountNumber,
+			AccountName:   bank.AccountName,
+			Provider:      st.server.payouts.Name(),
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = postLedger(ctx, q, utils.LedgerPayout, "Payout "+payout.ID, sql.NullString{},
+			utils.LedgerLine{Account: account.ID, Amount: utils.Kobo(0).Sub(amount)},
+			utils.LedgerLine{Account: utils.AccountProvider, Amount: amount},
+		)
+		return err
+	})
+	if errors.Is(err, utils.ErrInsufficientFunds) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    "The payout is more than your available balance.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	result, err := st.server.payouts.Transfer(context.Background(), utils.TransferRequest{
+		Reference:     payout.ID,
+		Amount:        payout.Amount,
+		RecipientCode: bank.RecipientCode,
+		Reason:        fmt.Sprintf("%s payout", shop.Name),
+	})
+	if utils.Declined(err) {
+		result = utils.TransferResult{Status: utils.PayoutFailed, Message: err.Error()}
+	} else if err != nil {
+		// The transfer may have gone through; refreshPayouts finds out.
+		log.Printf("could not send payout %s: %v", payout.ID, err)
+		result = utils.TransferResult{Status: utils.PayoutProcessing}
+	}
+
+	payout, err = st.server.updatePayout(context.Background(), payout, result)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"statusCode": http.StatusCreated,
+		"status":     "success",
+		"message":    "payout requested successfully",
+		"data":       payout,
+	})
+}
+
+// settlementReport lists settlements across shops, or for one shop, with
+// their totals and what was paid out over the same range. It covers the
+// last 30 days unless from and to are given.
+func (st *Settlement) settlementReport(ctx *gin.Context) {
+	input := SettlementReportParams{}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	to := time.Now()
+	if input.To != nil {
+		to = *input.To
+	}
+	from := to.AddDate(0, 0, -30)
+	if input.From != nil {
+		from = *input.From
+	}
+
+	if !from.Before(to) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": "from must be before to",
+		})
+		return
+	}
+
+	limit, offset := pagination(input.PageID, input.PageSize)
+
+	settlements, err := st.server.queries.ListSettlements(context.Background(), db.ListSettlementsParams{
+		ShopID:   input.ShopID,
+		FromTime: from,
+		ToTime:   to,
+		Limit:    limit,
+		Offset:   offset,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	totals, err := st.server.queries.GetSettlementReport(context.Background(), db.GetSettlementReportParams{
+		ShopID:   input.ShopID,
+		FromTime: from,
+		ToTime:   to,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	paidOut, err := st.server.queries.GetPaidOutTotal(context.Background(), db.GetPaidOutTotalParams{
+		ShopID:   input.ShopID,
+		FromTime: from,
+		ToTime:   to,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "settlement report fetched successfully",
+		"data": SettlementReportResponse{
+			From:        from.In(st.server.location),
+			To:          to.In(st.server.location),
+			Totals:      totals,
+			PaidOut:     paidOut,
+			Settlements: settlements,
+		},
+	})
+}
+
+// runSettlements closes every finished week now instead of waiting for the
+// scheduler.
+func (st *Settlement) runSettlements(ctx *gin.Context) {
+	closed := st.server.closeSettlements(context.Background())
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    fmt.Sprintf("%d settlements closed", closed),
+		"data":       gin.H{"closed": closed},
+	})
+}
+
+// recordVendorEarning works out what a delivered order earned its shop.
+// Unpaid orders earn nothing until payments catch up with them. A shop's own
+// coupon comes out of what it earns; the platform's coupons do not, and the
+// discount is moved from promotions to sales to pay the shop in full.
+func (s *Server) recordVendorEarning(ctx context.Context, q *db.Queries, order db.Order) error {
+	if order.PaymentStatus != utils.OrderPaid {
+		return nil
+	}
+
+	gross := order.Total.Sub(order.DeliveryFee).Sub(order.Tip)
+
+	coupon, ok, err := orderCoupon(ctx, q, order)
+	if err != nil {
+		return err
+	}
+	if ok && !coupon.ShopID.Valid {
+		gross = gross.Add(order.Discount)
+
+		_, err := postLedger(ctx, q, utils.LedgerAdjustment, fmt.Sprintf("Coupon %s on order %s", coupon.Code, order.ID), sql.NullString{},
+			utils.LedgerLine{Account: utils.AccountPromotions, Amount: utils.Kobo(0).Sub(order.Discount)},
+			utils.LedgerLine{Account: utils.AccountSales, Amount: order.Discount},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	earning := s.vendorFees.Earning(gross, order.Subtotal)
+
+	_, err = q.CreateVendorEarning(ctx, db.CreateVendorEarningParams{
+		OrderID:                  order.ID,
+		ShopID:                   order.ShopID,
+		Gross:                    earning.Gross,
+		Commission:               earning.Commission,
+		ProcessingFee:            earning.ProcessingFee,
+		Net:                      earning.Net,
+		CommissionPercent:        int32(s.vendorFees.CommissionPercent),
+		ProcessingFeeBasisPoints: int32(s.vendorFees.ProcessingFeeBasisPoints),
+	})
+	return err
+}
+
+// closeSettlements gathers earnings from weeks that have ended into one
+// settlement per shop and week, and credits each shop's balance. Every
+// instance runs it; a week can only be settled once per shop, so a second
+// instance gets nothing. It returns how many settlements it closed.
+func (s *Server) closeSettlements(ctx context.Context) int {
+	current, _ := utils.SettlementPeriod(time.Now().In(s.location))
+	closed := 0
+
+	for {
+		shops, err := s.queries.ListUnsettledShops(ctx, db.ListUnsettledShopsParams{
+			Before: current,
+			Limit:  schedulerBatch,
+		})
+		if err != nil {
+			log.Printf("scheduler: could not list unsettled shops: %v", err)
+			return closed
+		}
+
+		settled := 0
+		for _, shop := range shops {
+			start, end := utils.SettlementPeriod(shop.FirstEarnedAt.In(s.location))
+
+			err := s.closeSettlement(ctx, shop.ShopID, start, end)
+			var pqErr *pq.Error
+			if err == nil {
+				settled++
+			} else if errors.As(err, &pqErr) && pqErr.Code == "23505" || errors.Is(err, errNothingToSettle) {
+				continue
+			} else {
+				log.Printf("scheduler: could not settle shop %s for %s: %v", shop.ShopID, start.Format("2006-01-02"), err)
+			}
+		}
+		closed += settled
+
+		if len(shops) < schedulerBatch || settled == 0 {
+			return closed
+		}
+	}
+}
+
+func (s *Server) closeSettlement(ctx context.Context, shopId string, start, end time.Time) error {
+	id, err := utils.NewID()
+	if err != nil {
+		return err
+	}
+
+	return s.execTx(ctx, func(q *db.Queries) error {
+		// Earnings that arrive after their week was closed, such as an
+		// order whose payment was confirmed late, join that week's
+		// settlement rather than holding up the weeks after it.
+		settlement, err := q.GetShopSettlementForPeriod(ctx, db.GetShopSettlementForPeriodParams{
+			ShopID:      shopId,
+			PeriodStart: start,
+		})
+		if err == sql.ErrNoRows {
+			settlement, err = q.CreateSettlement(ctx, db.CreateSettlementParams{
+				ID:          id,
+				ShopID:      shopId,
+				PeriodStart: start,
+				PeriodEnd:   end,
+			})
+		}
+		if err != nil {
+			return err
+		}
+
+		earnings, err := q.AttachSettlementEarnings(ctx, db.AttachSettlementEarningsParams{
+			SettlementID: settlement.ID,
+			ShopID:       shopId,
+			PeriodStart:  start,
+			PeriodEnd:    end,
+		})
+		if err != nil {
+			return err
+		}
+		if len(earnings) == 0 {
+			return errNothingToSettle
+		}
+
+		total := utils.VendorEarning{Gross: utils.Kobo(0), Commission: utils.Kobo(0), ProcessingFee: utils.Kobo(0), Net: utils.Kobo(0)}
+		for _, earning := range earnings {
+			total.Gross = total.Gross.Add(earning.Gross)
+			total.Commission = total.Commission.Add(earning.Commission)
+			total.ProcessingFee = total.ProcessingFee.Add(earning.ProcessingFee)
+			total.Net = total.Net.Add(earning.Net)
+		}
+
+		if _, err := q.AddSettlementTotals(ctx, db.AddSettlementTotalsParams{
+			ID:            settlement.ID,
+			OrderCount:    int32(len(earnings)),
+			Gross:         total.Gross,
+			Commission:    total.Commission,
+			ProcessingFee: total.ProcessingFee,
+			Net:           total.Net,
+		}); err != nil {
+			return err
+		}
+
+		if total.Gross.IsZero() {
+			return nil
+		}
+
+		account, err := shopAccount(ctx, q, shopId)
+		if err != nil {
+			return err
+		}
+
+		_, err = postLedger(ctx, q, utils.LedgerSettlement, "Settlement "+settlement.ID, sql.NullString{},
+			utils.LedgerLine{Account: utils.AccountSales, Amount: utils.Kobo(0).Sub(total.Gross)},
+			utils.LedgerLine{Account: account.ID, Amount: total.Net},
+			utils.LedgerLine{Account: utils.AccountCommission, Amount: total.Commission.Add(total.ProcessingFee)},
+		)
+		return err
+	})
+}
+
+// updatePayout records where a payout's transfer has got to. A failed
+// transfer puts the money back in the shop's balance, once.
+func (s *Server) updatePayout(ctx context.Context, payout db.Payout, result utils.TransferResult) (db.Payout, error) {
+	if result.Status == utils.PayoutProcessing && payout.Status == utils.PayoutProcessing {
+		return payout, nil
+	}
+
+	updated := payout
+	err := s.execTx(ctx, func(q *db.Queries) error {
+		var err error
+
+		updated, err = q.UpdatePayoutStatus(ctx, db.UpdatePayoutStatusParams{
+			ID:                payout.ID,
+			Status:            result.Status,
+			ProviderReference: result.ProviderReference,
+			FailureReason:     result.Message,
+		})
+		if err == sql.ErrNoRows {
+			updated, err = q.GetPayout(ctx, payout.ID)
+			return err
+		} else if err != nil {
+			return err
+		}
+
+		if result.Status != utils.PayoutFailed {
+			return nil
+		}
+
+		account, err := shopAccount(ctx, q, payout.ShopID)
+		if err != nil {
+			return err
+		}
+
+		_, err = postLedger(ctx, q, utils.LedgerPayout, "Payout "+payout.ID+" failed", sql.NullString{},
+			utils.LedgerLine{Account: utils.AccountProvider, Amount: utils.Kobo(0).Sub(payout.Amount)},
+			utils.LedgerLine{Account: account.ID, Amount: payout.Amount},
+		)
+		return err
+	})
+	return updated, err
+}
+
+// refreshPayouts asks the provider about transfers still in flight. One the
+// provider has still not heard of after utils.PayoutLostAfter never reached
+// it, and its payout is failed so the shop gets the money back.
+func (s *Server) refreshPayouts(ctx context.Context) {
+	payouts, err := s.queries.ListProcessingPayouts(ctx, schedulerBatch)
+	if err != nil {
+		log.Printf("scheduler: could not list payouts: %v", err)
+		return
+	}
+
+	for _, payout := range payouts {
+		result, err := s.payouts.TransferStatus(ctx, payout.ID)
+		if errors.Is(err, utils.ErrPaymentNotFound) && time.Since(payout.CreatedAt) > utils.PayoutLostAfter {
+			result, err = utils.TransferResult{Status: utils.PayoutFailed, Message: "The transfer never reached the provider."}, nil
+		}
+		if err != nil {
+			log.Printf("scheduler: could not check payout %s: %v", payout.ID, err)
+			continue
+		}
+
+		if _, err := s.updatePayout(ctx, payout, result); err != nil {
+			log.Printf("scheduler: could not update payout %s: %v", payout.ID, err)
+		}
+	}
+}
+
+// shopAccount returns the shop's ledger account, opening it on first use.
+func shopAccount(ctx context.Context, q *db.Queries, shopId string) (db.LedgerAccount, error) {
+	id, err := utils.NewID()
+	if err != nil {
+		return db.LedgerAccount{}, err
+	}
+
+	return q.GetOrCreateShopAccount(ctx, db.GetOrCreateShopAccountParams{
+		ID:     id,
+		ShopID: sql.NullString{String: shopId, Valid: true},
+	})
+}
@@ -0,0 +1,457 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type Shop struct {
+	server *Server
+}
+
+type CreateShopParams struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description"`
+	Address     string `json:"address" binding:"required,max=300"`
+	Phone       string `json:"phone" binding:"required,len=11"`
+	ImageUrl    string `json:"image_url" binding:"omitempty,url"`
+}
+
+type UpdateShopParams struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description"`
+	Address     string `json:"address" binding:"required,max=300"`
+	Phone       string `json:"phone" binding:"required,len=11"`
+	ImageUrl    string `json:"image_url" binding:"omitempty,url"`
+	IsOpen      *bool  `json:"is_open" binding:"required"`
+}
+
+type ShopResponse struct {
+	db.Shop
+	IsFavourite bool `json:"is_favourite"`
+}
+
+type ShopHoursWindow struct {
+	Weekday *int16 `json:"weekday" binding:"required,min=0,max=6"`
+	Opens   string `json:"opens" binding:"required,len=5"`
+	Closes  string `json:"closes" binding:"required,len=5"`
+}
+
+type UpdateShopHoursParams struct {
+	LeadTimeMinutes *int32            `json:"lead_time_minutes" binding:"required,min=0,max=1440"`
+	Hours           []ShopHoursWindow `json:"hours" binding:"max=28,dive"`
+}
+
+type ShopHoursResponse struct {
+	LeadTimeMinutes int32             `json:"lead_time_minutes"`
+	Timezone        string            `json:"timezone"`
+	Hours           []ShopHoursWindow `json:"hours"`
+}
+
+type ListShopsParams struct {
+	Search   string `form:"q"`
+	PageID   int32  `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32  `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+func (s Shop) router(server *Server) {
+	s.server = server
+
+	serverGroup := server.router.Group("/shops")
+	serverGroup.POST("", AuthenticatedMiddleware(), s.createShop)
+	serverGroup.GET("", OptionalAuthMiddleware(), s.listShops)
+	serverGroup.GET("/:id", OptionalAuthMiddleware(), s.getShop)
+	serverGroup.PUT("/:id", AuthenticatedMiddleware(), s.updateShop)
+	serverGroup.GET("/:id/hours", s.getShopHours)
+	serverGroup.PUT("/:id/hours", AuthenticatedMiddleware(), s.updateShopHours)
+	serverGroup.GET("/:id/location", s.getShopLocation)
+	serverGroup.PUT("/:id/location", AuthenticatedMiddleware(), s.updateShopLocation)
+	serverGroup.PUT("/:id/service_charge", AuthenticatedMiddleware(), s.updateShopServiceCharge)
+}
+
+func (s *Shop) createShop(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := CreateShopParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	id, err := utils.NewID()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Failed to generate CUID": err.Error(),
+		})
+		return
+	}
+
+	shop, err := s.server.queries.CreateShop(context.Background(), db.CreateShopParams{
+		ID:          id,
+		OwnerID:     userId,
+		Name:        strings.TrimSpace(input.Name),
+		Description: input.Description,
+		Address:     input.Address,
+		Phone:       input.Phone,
+		ImageUrl:    input.ImageUrl,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"statusCode": http.StatusCreated,
+		"status":     "success",
+		"message":    "shop created successfully",
+		"data":       shop,
+	})
+}
+
+func (s *Shop) listShops(ctx *gin.Context) {
+	input := ListShopsParams{}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	limit, offset := pagination(input.PageID, input.PageSize)
+
+	shops, err := s.server.queries.ListShops(context.Background(), db.ListShopsParams{
+		Search: sql.NullString{String: strings.TrimSpace(input.Search), Valid: strings.TrimSpace(input.Search) != ""},
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ids := make([]string, 0, len(shops))
+	for _, shop := range shops {
+		ids = append(ids, shop.ID)
+	}
+
+	favourites, ok := s.server.favouriteShopIDs(ctx, ids)
+	if !ok {
+		return
+	}
+
+	response := make([]ShopResponse, 0, len(shops))
+	for _, shop := range shops {
+		response = append(response, ShopResponse{Shop: shop, IsFavourite: favourites[shop.ID]})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "shops fetched successfully",
+		"data":       response,
+	})
+}
+
+func (s *Shop) getShop(ctx *gin.Context) {
+	shop, err := s.server.queries.GetShop(context.Background(), ctx.Param("id"))
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"Error":      err.Error(),
+			"message":    "The requested shop does not exist.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	favourites, ok := s.server.favouriteShopIDs(ctx, []string{shop.ID})
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "shop fetched successfully",
+		"data":       ShopResponse{Shop: shop, IsFavourite: favourites[shop.ID]},
+	})
+}
+
+func (s *Shop) updateShop(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := UpdateShopParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	shop, ok := s.server.ownedShop(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	updated, err := s.server.queries.UpdateShop(context.Background(), db.UpdateShopParams{
+		ID:          shop.ID,
+		Name:        strings.TrimSpace(input.Name),
+		Description: input.Description,
+		Address:     input.Address,
+		Phone:       input.Phone,
+		ImageUrl:    input.ImageUrl,
+		IsOpen:      *input.IsOpen,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "shop updated successfully",
+		"data":    updated,
+	})
+}
+
+func (s *Shop) getShopHours(ctx *gin.Context) {
+	shop, err := s.server.queries.GetShop(context.Background(), ctx.Param("id"))
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"Error":      err.Error(),
+			"message":    "The requested shop does not exist.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	hours, err := s.server.queries.ListShopHours(context.Background(), shop.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "shop hours fetched successfully",
+		"data":       s.server.newShopHoursResponse(shop, hours),
+	})
+}
+
+// updateShopHours replaces all of a shop's opening windows at once.
+func (s *Shop) updateShopHours(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := UpdateShopHoursParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	args := []db.CreateShopHoursParams{}
+	for _, window := range input.Hours {
+		opens, err := utils.ParseClock(window.Opens)
+		if err == nil && opens == 24*60 {
+			err = fmt.Errorf("a shop cannot open at %s", window.Opens)
+		}
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"Error": err.Error(),
+			})
+			return
+		}
+
+		closes, err := utils.ParseClock(window.Closes)
+		if err == nil && closes == 0 {
+			closes = 24 * 60
+		}
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"Error": err.Error(),
+			})
+			return
+		}
+
+		args = append(args, db.CreateShopHoursParams{
+			Weekday:      *window.Weekday,
+			OpensMinute:  int32(opens),
+			ClosesMinute: int32(closes),
+		})
+	}
+
+	shop, ok := s.server.ownedShop(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	hours := []db.ShopHour{}
+
+	err := s.server.execTx(ctx, func(q *db.Queries) error {
+		var err error
+
+		shop, err = q.UpdateShopLeadTime(ctx, db.UpdateShopLeadTimeParams{
+			ID:              shop.ID,
+			LeadTimeMinutes: *input.LeadTimeMinutes,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := q.DeleteShopHours(ctx, shop.ID); err != nil {
+			return err
+		}
+
+		for _, arg := range args {
+			arg.ShopID = shop.ID
+			hour, err := q.CreateShopHours(ctx, arg)
+			if err != nil {
+				return err
+			}
+			hours = append(hours, hour)
+		}
+		return nil
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"Error": "two opening windows start at the same time on the same day",
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "shop hours updated successfully",
+		"data":    s.server.newShopHoursResponse(shop, hours),
+	})
+}
+
+func (s *Server) newShopHoursResponse(shop db.Shop, hours []db.ShopHour) ShopHoursResponse {
+	response := ShopHoursResponse{
+		LeadTimeMinutes: shop.LeadTimeMinutes,
+		Timezone:        s.location.String(),
+		Hours:           []ShopHoursWindow{},
+	}
+
+	for _, hour := range hours {
+		weekday := hour.Weekday
+		response.Hours = append(response.Hours, ShopHoursWindow{
+			Weekday: &weekday,
+			Opens:   utils.FormatClock(int(hour.OpensMinute)),
+			Closes:  utils.FormatClock(int(hour.ClosesMinute)),
+		})
+	}
+	return response
+}
+
+// openingHours converts a shop's stored windows for utils.IsOpenAt.
+func openingHours(hours []db.ShopHour) []utils.OpeningHours {
+	windows := []utils.OpeningHours{}
+	for _, hour := range hours {
+		windows = append(windows, utils.OpeningHours{
+			Weekday:      time.Weekday(hour.Weekday),
+			OpensMinute:  int(hour.OpensMinute),
+			ClosesMinute: int(hour.ClosesMinute),
+		})
+	}
+	return windows
+}
+
+// ownedShop loads a shop and makes sure userId owns it, writing the error
+// response itself when it doesn't.
+func (s *Server) ownedShop(ctx *gin.Context, shopId, userId string) (db.Shop, bool) {
+	shop, err := s.queries.GetShop(context.Background(), shopId)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"Error":      err.Error(),
+			"message":    "The requested shop does not exist.",
+		})
+		return db.Shop{}, false
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return db.Shop{}, false
+	}
+
+	if shop.OwnerID != userId {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": "Forbidden: you do not own this shop",
+		})
+		return db.Shop{}, false
+	}
+
+	return shop, true
+}
+
+// pagination turns page_id/page_size into limit/offset with sane defaults.
+func pagination(pageID, pageSize int32) (int32, int32) {
+	if pageID < 1 {
+		pageID = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	return pageSize, (pageID - 1) * pageSize
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateServiceChargeParams sets a shop's service charge in basis points,
+// so 1000 is 10%.
+type UpdateServiceChargeParams struct {
+	ServiceChargeBasisPoints *int32 `json:"service_charge_basis_points" binding:"required,min=0,max=2500"`
+}
+
+// OrderInvoiceResponse is an order's tax invoice. Numbers run per shop.
+type OrderInvoiceResponse struct {
+	db.OrderInvoice
+	InvoiceNumber string `json:"invoice_number"`
+}
+
+// issueInvoice takes the shop's next invoice number and stores the tax
+// breakdown the order was charged with. It must run in the same transaction
+// as the order so a failed order gives its number back.
+func issueInvoice(ctx context.Context, q *db.Queries, order db.Order, tax utils.TaxBreakdown) error {
+	number, err := q.NextInvoiceNumber(ctx, order.ShopID)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateOrderInvoice(ctx, db.CreateOrderInvoiceParams{
+		OrderID:                  order.ID,
+		ShopID:                   order.ShopID,
+		Number:                   number,
+		VatBasisPoints:           int32(tax.VATBasisPoints),
+		PricesIncludeVat:         tax.PricesIncludeVAT,
+		ServiceChargeBasisPoints: int32(tax.ServiceChargeBasisPoints),
+		TaxableAmount:            tax.Taxable,
+		Vat:                      tax.VAT,
+		ServiceCharge:            tax.ServiceCharge,
+	})
+	return err
+}
+
+// updateShopServiceCharge changes the service charge added to the shop's
+// future orders. Orders already placed keep the charge they were invoiced
+// with.
+func (s *Shop) updateShopServiceCharge(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := UpdateServiceChargeParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	shop, ok := s.server.ownedShop(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	updated, err := s.server.queries.UpdateShopServiceCharge(context.Background(), db.UpdateShopServiceChargeParams{
+		ID:                       shop.ID,
+		ServiceChargeBasisPoints: *input.ServiceChargeBasisPoints,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"statusCode": http.StatusAccepted,
+		"status":     "success",
+		"message":    "shop service charge updated successfully",
+		"data":       updated,
+	})
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type TipOrderParams struct {
+	Amount utils.Money `json:"amount" binding:"required,isPositive"`
+}
+
+type RiderEarningsParams struct {
+	From *time.Time `form:"from"`
+	To   *time.Time `form:"to"`
+}
+
+// recordTip stores the tip already added to order's total so it can be
+// captured with the payment and paid to the rider.
+func recordTip(ctx context.Context, q *db.Queries, order db.Order, stage string) error {
+	if order.Tip.IsZero() {
+		return nil
+	}
+
+	id, err := utils.NewID()
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateOrderTip(ctx, db.CreateOrderTipParams{
+		ID:      id,
+		OrderID: order.ID,
+		UserID:  order.UserID,
+		Amount:  order.Tip,
+		Stage:   stage,
+	})
+	return err
+}
+
+// tipOrder lets a customer tip the rider after delivery, once per order and
+// only within utils.TipWindow. The tip is paid for on its own with a payment
+// for its tip_id, so it is not added to the order's total.
+func (o *Order) tipOrder(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := TipOrderParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if input.Amount.IsZero() || input.Amount.Cmp(utils.MaxTip) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": fmt.Sprintf("a tip must be more than 0 and at most %s", utils.MaxTip),
+		})
+		return
+	}
+
+	order, ok := o.server.customerOrder(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	if order.Status != utils.OrderDelivered || !order.RiderID.Valid {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"statusCode": http.StatusConflict,
+			"message":    "Only delivered orders can be tipped.",
+		})
+		return
+	}
+
+	deliveredAt, err := o.server.queries.GetOrderDeliveredAt(context.Background(), order.ID)
+	if err != nil && err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+	if err == sql.ErrNoRows || time.Since(deliveredAt) > utils.TipWindow {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"statusCode": http.StatusConflict,
+			"message":    "It is too late to tip for this order.",
+		})
+		return
+	}
+
+	id, err := utils.NewID()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Failed to generate CUID": err.Error(),
+		})
+		return
+	}
+
+	tip, err := o.server.queries.CreateOrderTip(context.Background(), db.CreateOrderTipParams{
+		ID:      id,
+		OrderID: order.ID,
+		UserID:  userId,
+		Amount:  input.Amount,
+		Stage:   utils.TipAfterDelivery,
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"Error": "you have already tipped for this order",
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"statusCode": http.StatusCreated,
+		"status":     "success",
+		"message":    "tip added successfully",
+		"data":       tip,
+	})
+}
+
+// getEarnings totals what the rider made from deliveries completed between
+// from and to, the last seven days by default. Commission comes out of the
+// delivery fees only.
+func (r *Rider) getEarnings(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := RiderEarningsParams{}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	to := time.Now()
+	if input.To != nil {
+		to = *input.To
+	}
+	from := to.Add(-7 * 24 * time.Hour)
+	if input.From != nil {
+		from = *input.From
+	}
+
+	if !from.Before(to) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": "from must be before to",
+		})
+		return
+	}
+
+	row, err := r.server.queries.GetRiderEarnings(context.Background(), db.GetRiderEarningsParams{
+		RiderID:  userId,
+		FromTime: from,
+		ToTime:   to,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	earnings := utils.NewRiderEarnings(row.Deliveries, row.DeliveryFees, row.Tips, r.server.riderCommission)
+	earnings.From = from.In(r.server.location)
+	earnings.To = to.In(r.server.location)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "earnings fetched successfully",
+		"data":       earnings,
+	})
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	orderEventStatus   = "status"
+	orderEventLocation = "location"
+
+	// riderLocationTTL keeps the last known position around long enough for
+	// a late subscriber, without showing a stale rider forever.
+	riderLocationTTL = 10 * time.Minute
+
+	// trackingHeartbeat keeps proxies from closing an idle stream.
+	trackingHeartbeat = 25 * time.Second
+)
+
+// OrderEvent is what subscribers receive. Status events carry Status,
+// location events carry Lat and Lng.
+type OrderEvent struct {
+	Type    string    `json:"type"`
+	OrderID string    `json:"order_id"`
+	Status  string    `json:"status,omitempty"`
+	Lat     *float64  `json:"lat,omitempty"`
+	Lng     *float64  `json:"lng,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+type Tracking struct {
+	server *Server
+}
+
+func (t Tracking) router(server *Server) {
+	t.server = server
+
+	server.router.GET("/orders/:id/track", tokenFromQuery(), AuthenticatedMiddleware(), t.trackOrder)
+}
+
+// trackOrder streams an order's status changes and rider location as
+// server-sent events until the order is finished or the client goes away.
+// Events are published through redis so it does not matter which instance
+// made the change.
+func (t *Tracking) trackOrder(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	order, ok := t.server.customerOrder(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	// Subscribe before reading the current state so nothing published in
+	// between is lost.
+	pubsub := Rdb.Subscribe(ctx.Request.Context(), orderChannel(order.ID))
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx.Request.Context()); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	order, err := t.server.queries.GetOrder(context.Background(), order.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	ctx.SSEvent(orderEventStatus, OrderEvent{
+		Type:    orderEventStatus,
+		OrderID: order.ID,
+		Status:  order.Status,
+		At:      order.UpdatedAt,
+	})
+	if location, ok := lastRiderLocation(ctx.Request.Context(), order.ID); ok {
+		ctx.SSEvent(orderEventLocation, location)
+	}
+	ctx.Writer.Flush()
+
+	if utils.IsFinalOrderStatus(order.Status) {
+		return
+	}
+
+	messages := pubsub.Channel()
+	heartbeat := time.NewTicker(trackingHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := ctx.Writer.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+			ctx.Writer.Flush()
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			event := OrderEvent{}
+			if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+				log.Printf("bad order event on %s: %v", message.Channel, err)
+				continue
+			}
+
+			ctx.SSEvent(event.Type, event)
+			ctx.Writer.Flush()
+
+			if event.Type == orderEventStatus && utils.IsFinalOrderStatus(event.Status) {
+				return
+			}
+		}
+	}
+}
+
+// tokenFromQuery lets browser EventSource clients, which cannot set headers,
+// pass their token as ?access_token=. A real Authorization header wins. The
+// token is redacted from the access log by requestLogger.
+func tokenFromQuery() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token := ctx.Query("access_token")
+		if token != "" && ctx.GetHeader("Authorization") == "" {
+			ctx.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+}
+
+func orderChannel(orderId string) string {
+	return "order:" + orderId + ":events"
+}
+
+func riderLocationKey(orderId string) string {
+	return "order:" + orderId + ":location"
+}
+
+// publishOrderStatus tells every tracking stream about a status change.
+// Tracking is best effort, so failures are logged and not returned.
+func publishOrderStatus(order db.Order) {
+	publishOrderEvent(OrderEvent{
+		Type:    orderEventStatus,
+		OrderID: order.ID,
+		Status:  order.Status,
+		At:      order.UpdatedAt,
+	})
+}
+
+func publishRiderLocation(orderId string, lat, lng float64) error {
+	event := OrderEvent{
+		Type:    orderEventLocation,
+		OrderID: orderId,
+		Lat:     &lat,
+		Lng:     &lng,
+		At:      time.Now(),
+	}
+
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if err := Rdb.Set(context.Background(), riderLocationKey(orderId), raw, riderLocationTTL).Err(); err != nil {
+		return err
+	}
+
+	return Rdb.Publish(context.Background(), orderChannel(orderId), raw).Err()
+}
+
+func publishOrderEvent(event OrderEvent) {
+	raw, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("could not encode order event for %s: %v", event.OrderID, err)
+		return
+	}
+
+	if err := Rdb.Publish(context.Background(), orderChannel(event.OrderID), raw).Err(); err != nil {
+		log.Printf("could not publish order event for %s: %v", event.OrderID, err)
+	}
+}
+
+func lastRiderLocation(ctx context.Context, orderId string) (OrderEvent, bool) {
+	event := OrderEvent{}
+
+	raw, err := Rdb.Get(ctx, riderLocationKey(orderId)).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("could not read rider location for %s: %v", orderId, err)
+		}
+		return event, false
+	}
+
+	if err := json.Unmarshal(raw, &event); err != nil {
+		return event, false
+	}
+	return event, true
+}
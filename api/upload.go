@@ -0,0 +1,202 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/nrednav/cuid2"
+)
+
+type Upload struct {
+	server *Server
+}
+
+const maxImagesPerUpload = 5
+
+type VerifyImageURLsParams struct {
+	URLs []string `json:"urls" binding:"required,min=1,max=10"`
+}
+
+type UploadedImage struct {
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	ContentType  string `json:"content_type"`
+	Size         int    `json:"size"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
+
+func (u Upload) router(server *Server) {
+	u.server = server
+
+	serverGroup := server.router.Group("/uploads")
+	serverGroup.POST("/images", AuthenticatedMiddleware(), u.uploadImages)
+	serverGroup.POST("/verify_urls", AuthenticatedMiddleware(), u.verifyURLs)
+}
+
+func (u *Upload) uploadImages(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	// leave some room for the multipart boundaries on top of the images
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImagesPerUpload*utils.MaxImageSize+(1<<20))
+
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"Error": "upload is too large",
+			})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	files := form.File["images"]
+	if len(files) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": "no image found in the images field",
+		})
+		return
+	}
+	if len(files) > maxImagesPerUpload {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": fmt.Sprintf("a maximum of %d images can be uploaded at once", maxImagesPerUpload),
+		})
+		return
+	}
+
+	generate, err := cuid2.Init(
+		cuid2.WithLength(32),
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Failed to generate CUID": err.Error(),
+		})
+		return
+	}
+
+	uploaded := []UploadedImage{}
+	saved := []string{}
+
+	for _, file := range files {
+		image, names, status, err := u.storeImage(ctx, file, userId+"/"+generate())
+		saved = append(saved, names...)
+		if err != nil {
+			// don't leave half an upload behind
+			for _, name := range saved {
+				u.server.imageStore.Delete(ctx, name)
+			}
+			ctx.JSON(status, gin.H{
+				"Error": fmt.Sprintf("%s: %s", file.Filename, err.Error()),
+			})
+			return
+		}
+		uploaded = append(uploaded, image)
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"statusCode": http.StatusCreated,
+		"status":     "success",
+		"message":    "images uploaded successfully",
+		"data":       uploaded,
+	})
+}
+
+// storeImage validates a single file and saves it with its thumbnail. It
+// returns the names that were stored so the caller can clean them up.
+func (u *Upload) storeImage(ctx *gin.Context, file *multipart.FileHeader, name string) (UploadedImage, []string, int, error) {
+	if file.Size > utils.MaxImageSize {
+		return UploadedImage{}, nil, http.StatusRequestEntityTooLarge, fmt.Errorf("image must not be more than %dMB", utils.MaxImageSize>>20)
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return UploadedImage{}, nil, http.StatusBadRequest, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, utils.MaxImageSize+1))
+	if err != nil {
+		return UploadedImage{}, nil, http.StatusBadRequest, err
+	}
+	if len(data) > utils.MaxImageSize {
+		return UploadedImage{}, nil, http.StatusRequestEntityTooLarge, fmt.Errorf("image must not be more than %dMB", utils.MaxImageSize>>20)
+	}
+
+	contentType, ext, err := utils.SniffImage(data)
+	if err != nil {
+		return UploadedImage{}, nil, http.StatusUnsupportedMediaType, err
+	}
+
+	width, height, err := utils.ImageDimensions(data)
+	if err != nil {
+		return UploadedImage{}, nil, http.StatusBadRequest, err
+	}
+
+	thumbnail, thumbnailType, err := utils.MakeThumbnail(data, contentType, utils.ThumbnailSize)
+	if err != nil {
+		return UploadedImage{}, nil, http.StatusBadRequest, err
+	}
+
+	thumbnailExt := "jpg"
+	if thumbnailType == "image/png" {
+		thumbnailExt = "png"
+	}
+
+	imageName := name + "." + ext
+	thumbnailName := name + "_thumb." + thumbnailExt
+
+	url, err := u.server.imageStore.Save(ctx, imageName, contentType, data)
+	if err != nil {
+		return UploadedImage{}, nil, http.StatusBadGateway, err
+	}
+
+	thumbnailURL, err := u.server.imageStore.Save(ctx, thumbnailName, thumbnailType, thumbnail)
+	if err != nil {
+		return UploadedImage{}, []string{imageName}, http.StatusBadGateway, err
+	}
+
+	return UploadedImage{
+		URL:          url,
+		ThumbnailURL: thumbnailURL,
+		ContentType:  contentType,
+		Size:         len(data),
+		Width:        width,
+		Height:       height,
+	}, []string{imageName, thumbnailName}, http.StatusCreated, nil
+}
+
+func (u *Upload) verifyURLs(ctx *gin.Context) {
+	input := VerifyImageURLsParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	results := u.server.imageURLs.Verify(ctx, input.URLs)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "image urls checked",
+		"data":       results,
+	})
+}
@@ -62,6 +62,15 @@ type DeleteUserParam struct {
 	ID string `json:"id"`
 }
 
+type UpdateUserRoleParams struct {
+	Role string `json:"role" binding:"required,oneof=standard rider admin"`
+}
+
+type UpdateAllergenProfileParams struct {
+	Allergens  []string `json:"allergens" binding:"omitempty,dive,allergen"`
+	HideUnsafe bool     `json:"hide_unsafe"`
+}
+
 func (u User) router(server *Server) {
 	u.server = server
 	serverGroup := server.router.Group("/users")
@@ -72,6 +81,10 @@ func (u User) router(server *Server) {
 	serverGroup.GET("/get_email", u.getUserEmail)
 	serverGroup.GET("/send_code_to_user", u.sendCodetoUser)
 	serverGroup.POST("/verify_code", u.verifyCode)
+	serverGroup.GET("/allergens", AuthenticatedMiddleware(), u.getAllergenProfile)
+	serverGroup.PUT("/allergens", AuthenticatedMiddleware(), u.updateAllergenProfile)
+
+	server.router.PUT("/admin/users/:id/role", AuthenticatedMiddleware(), AdminMiddleware(), u.updateUserRole)
 }
 
 //var VerificationCodes = make(map[int64]VerificationCode)
@@ -659,3 +672,102 @@ func (u *User) updatePassword(ctx *gin.Context) {
 		"data":    userResponse,
 	})
 }
+
+func (u *User) getAllergenProfile(ctx *gin.Context) {
+	if _, ok := currentUserID(ctx); !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	profile, ok := u.server.allergenProfile(ctx)
+	if !ok {
+		return
+	}
+
+	profile.Allergens = nonNil(profile.Allergens)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "allergen profile fetched successfully",
+		"data":    profile,
+	})
+}
+
+func (u *User) updateAllergenProfile(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := UpdateAllergenProfileParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	profile, err := u.server.queries.UpsertAllergenProfile(context.Background(), db.UpsertAllergenProfileParams{
+		UserID:     userId,
+		Allergens:  utils.Dedupe(input.Allergens),
+		HideUnsafe: input.HideUnsafe,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "allergen profile updated successfully",
+		"data":    profile,
+	})
+}
+
+// updateUserRole lets an admin make someone a rider or another admin. The new
+// role takes effect the next time the user logs in.
+func (u *User) updateUserRole(ctx *gin.Context) {
+	input := UpdateUserRoleParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	user, err := u.server.queries.UpdateUserRole(context.Background(), db.UpdateUserRoleParams{
+		ID:   ctx.Param("id"),
+		Role: input.Role,
+	})
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"Error":      err.Error(),
+			"message":    "The requested user does not exist.",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "user role updated successfully",
+		"data": gin.H{
+			"id":   user.ID,
+			"role": user.Role,
+		},
+	})
+}
@@ -6,16 +6,10 @@
  package api
 
  import (
-	 "log"
-	 "net/http"
-	 "net/url"
-	 "strconv"
-	 "strings"
-	 "sync"
-	 "time"
 	 "unicode"
 	 "unicode/utf8"
  
+	 "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
 	 "github.com/go-playground/validator/v10"
  )
  
@@ -53,32 +47,18 @@
 	 return hasDigit && hasSymbol && hasUpper && hasLower
  }
  
- // ImageURLValidation is a custom validator function to check if the URL points to an image.
+ // ImageURLValidation is a custom validator function to check that every entry
+ // is a well formed public http(s) url. It deliberately does no network I/O;
+ // handlers call verifyImageURLs to check the urls actually serve images.
  var ImageURLValidation validator.Func = func(fl validator.FieldLevel) bool {
  
-	 urlStrArray := fl.Field().Interface().([]string)
- 
-	 // Create a channel to receive results from goroutines
-	 imgCh := make(chan bool, len(urlStrArray))
-	 //defer close(imgCh)
- 
-	 // Use a WaitGroup to wait for all goroutines to finish
-	 var wg sync.WaitGroup
- 
-	 for _, urlStr := range urlStrArray {
-		 wg.Add(1)
-		 go isImageURL(urlStr, imgCh, &wg)
+	 urlStrArray, ok := fl.Field().Interface().([]string)
+	 if !ok {
+		 return false
 	 }
  
-	 // Close the channel when all goroutines finish
-	 go func() {
-		 wg.Wait()
-		 close(imgCh)
-	 }()
- 
-	 // Check the results from the channel
-	 for isImage := range imgCh {
-		 if !isImage {
+	 for _, urlStr := range urlStrArray {
+		 if utils.CheckImageURLSyntax(urlStr) != "" {
 			 return false
 		 }
 	 }
@@ -86,57 +66,31 @@
 	 return true
  }
  
- func isImageURL(urlStr string, ch chan<- bool, wg *sync.WaitGroup) {
- 
-	 defer wg.Done()
- 
-	 u, err := url.Parse(urlStr)
-	 if err != nil || u.Scheme == "" || u.Host == "" {
-		 ch <- false
-		 return
-	 }
- 
-	 client := http.Client{
-		 Timeout: 5 * time.Second, // Set a timeout for the HTTP request
-	 }
- 
-	 resp, err := client.Get(u.String())
-	 if err != nil {
-		 // Handle different types of errors (e.g., network error, timeout)
-		 // Later
-		 ch <- false
-		 return
-	 }
-	 defer resp.Body.Close()
- 
-	 // Check if the content type indicates an image
-	 contentType := resp.Header.Get("Content-Type")
-	 isImage := strings.HasPrefix(contentType, "image/")
- 
-	 // Check if the image is not more than 500KB
-	 isNotMoreThan500Kb := false
-	 contentLength := resp.Header.Get("Content-Length")
-	 if len(contentLength) > 0 {
-		 length, err := strconv.ParseInt(contentLength, 10, 64)
-		 if err == nil && length <= 500*1024 { // 500 KB in bytes
-			 isNotMoreThan500Kb = true
-		 }
+ // PriceValidation rejects negative amounts. Money fields reach it as kobo
+ // through the custom type registered in Start; a string that is not a price
+ // at all is reported as invalid instead of stopping the server.
+ var PriceValidation validator.Func = func(fl validator.FieldLevel) bool {
+	 switch price := fl.Field().Interface().(type) {
+	 case int64:
+		 return price >= 0
+	 case string:
+		 money, err := utils.ParseMoney(price)
+		 return err == nil && !money.IsNegative()
+	 default:
+		 return false
 	 }
- 
-	 ch <- isImage && isNotMoreThan500Kb
  }
  
- var PriceValidation validator.Func = func(fl validator.FieldLevel) bool {
-	 price := fl.Field().Interface().(string)
- 
-	 priceFloat, err := strconv.ParseFloat(price, 64)
-	 if err != nil {
-		 log.Fatal(err.Error())
-	 }
+ // DietaryTagValidation accepts only the tags in utils.DietaryTags. Use it
+ // after dive on a list of tags.
+ var DietaryTagValidation validator.Func = func(fl validator.FieldLevel) bool {
+	 tag, ok := fl.Field().Interface().(string)
+	 return ok && utils.IsDietaryTag(tag)
+ }
  
-	 if priceFloat < 0 {
-		 return false
-	 }
-	 return true
+ // AllergenValidation accepts only the allergens in utils.Allergens. Use it
+ // after dive on a list of allergens.
+ var AllergenValidation validator.Func = func(fl validator.FieldLevel) bool {
+	 allergen, ok := fl.Field().Interface().(string)
+	 return ok && utils.IsAllergen(allergen)
  }
- 
\ No newline at end of file
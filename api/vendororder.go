@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type VendorOrder struct {
+	server *Server
+}
+
+type ListShopOrdersParams struct {
+	Status   string `form:"status" binding:"omitempty,oneof=scheduled pending accepted preparing ready picked_up delivered cancelled rejected"`
+	PageID   int32  `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32  `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+func (v VendorOrder) router(server *Server) {
+	v.server = server
+
+	server.router.GET("/shops/:id/orders", AuthenticatedMiddleware(), v.listShopOrders)
+
+	serverGroup := server.router.Group("/vendor/orders", AuthenticatedMiddleware())
+	serverGroup.GET("/:id", v.getShopOrder)
+	serverGroup.PUT("/:id/status", v.updateOrderStatus)
+}
+
+func (v *VendorOrder) listShopOrders(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := ListShopOrdersParams{}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	shop, ok := v.server.ownedShop(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	limit, offset := pagination(input.PageID, input.PageSize)
+
+	orders, err := v.server.queries.ListShopOrders(context.Background(), db.ListShopOrdersParams{
+		ShopID: shop.ID,
+		Status: input.Status,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "shop orders fetched successfully",
+		"data":       newOrderSummaries(orders),
+	})
+}
+
+func (v *VendorOrder) getShopOrder(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	order, ok := v.server.vendorOrder(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	response, err := v.server.orderResponse(context.Background(), order, utils.ActorVendor)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "order fetched successfully",
+		"data":       response,
+	})
+}
+
+func (v *VendorOrder) updateOrderStatus(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := UpdateOrderStatusParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	order, ok := v.server.vendorOrder(ctx, ctx.Param("id"), userId)
+	if !ok {
+		return
+	}
+
+	v.server.advanceOrder(ctx, order, orderChange{To: input.Status, ActorID: userId, Actor: utils.ActorVendor, Note: input.Note})
+}
+
+// vendorOrder loads an order placed with a shop owned by userId.
+func (s *Server) vendorOrder(ctx *gin.Context, orderId, userId string) (db.Order, bool) {
+	order, err := s.queries.GetOrder(context.Background(), orderId)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"Error":      err.Error(),
+			"message":    "The requested order does not exist.",
+		})
+		return order, false
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return order, false
+	}
+
+	if _, ok := s.ownedShop(ctx, order.ShopID, userId); !ok {
+		return order, false
+	}
+
+	return order, true
+}
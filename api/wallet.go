@@ -0,0 +1,392 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type Wallet struct {
+	server *Server
+}
+
+type TopUpParams struct {
+	Amount utils.Money `json:"amount" binding:"required,isPositive"`
+}
+
+type WalletResponse struct {
+	ID        string      `json:"id"`
+	Balance   utils.Money `json:"balance"`
+	Currency  string      `json:"currency"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// StatementEntryResponse is one line of a wallet statement, newest first.
+type StatementEntryResponse struct {
+	ID            string      `json:"id"`
+	TransactionID string      `json:"transaction_id"`
+	Kind          string      `json:"kind"`
+	Description   string      `json:"description"`
+	Amount        utils.Money `json:"amount"`
+	BalanceAfter  utils.Money `json:"balance_after"`
+	PaymentID     *string     `json:"payment_id"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+func (w Wallet) router(server *Server) {
+	w.server = server
+
+	serverGroup := server.router.Group("/wallet", AuthenticatedMiddleware())
+	serverGroup.GET("", w.getWallet)
+	serverGroup.GET("/statement", w.getStatement)
+	serverGroup.POST("/topup", IdempotencyMiddleware(), w.topUp)
+}
+
+func (w *Wallet) getWallet(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	wallet, err := walletAccount(context.Background(), w.server.queries, userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "wallet fetched successfully",
+		"data":       newWalletResponse(wallet),
+	})
+}
+
+func (w *Wallet) getStatement(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := ListOrdersParams{}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	limit, offset := pagination(input.PageID, input.PageSize)
+
+	wallet, err := walletAccount(context.Background(), w.server.queries, userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	rows, err := w.server.queries.ListAccountStatement(context.Background(), db.ListAccountStatementParams{
+		AccountID: wallet.ID,
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	entries := []StatementEntryResponse{}
+	for _, row := range rows {
+		entries = append(entries, StatementEntryResponse{
+			ID:            row.ID,
+			TransactionID: row.TransactionID,
+			Kind:          row.Kind,
+			Description:   row.Description,
+			Amount:        row.Amount,
+			BalanceAfter:  row.BalanceAfter,
+			PaymentID:     nullString(row.PaymentID),
+			CreatedAt:     row.CreatedAt,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"statusCode": http.StatusOK,
+		"status":     "success",
+		"message":    "statement fetched successfully",
+		"data": gin.H{
+			"wallet":  newWalletResponse(wallet),
+			"entries": entries,
+		},
+	})
+}
+
+// topUp adds money to the wallet through the payment provider. The balance
+// only moves once the payment succeeds.
+func (w *Wallet) topUp(ctx *gin.Context) {
+	userId, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	input := TopUpParams{}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if input.Amount.Cmp(utils.MinTopUp) < 0 || input.Amount.Cmp(utils.MaxTopUp) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"Error": fmt.Sprintf("a top-up must be between %s and %s", utils.MinTopUp, utils.MaxTopUp),
+		})
+		return
+	}
+
+	wallet, err := walletAccount(context.Background(), w.server.queries, userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	w.server.startPayment(ctx, userId, paymentTarget{
+		walletID: sql.NullString{String: wallet.ID, Valid: true},
+		amount:   input.Amount,
+	})
+}
+
+// payFromWallet pays for target out of the wallet balance straight away.
+// The payment is recorded like any other so receipts and refunds treat it
+// the same.
+func (s *Server) payFromWallet(ctx *gin.Context, userId string, target paymentTarget) {
+	id, err := utils.NewID()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Failed to generate CUID": err.Error(),
+		})
+		return
+	}
+
+	var payment db.Payment
+	var refunds []db.Refund
+	err = s.execTx(ctx, func(q *db.Queries) error {
+		if err := checkPayable(ctx, q, target); err != nil {
+			return err
+		}
+
+		pending, err := q.CreatePayment(ctx, newPaymentParams(id, userId, utils.WalletProvider, target))
+		if err != nil {
+			return err
+		}
+
+		payment, err = q.SettlePayment(ctx, db.SettlePaymentParams{
+			ID:                pending.ID,
+			Status:            utils.PaymentSucceeded,
+			ProviderReference: pending.Reference,
+			Channel:           utils.WalletProvider,
+			PaidAt:            sql.NullTime{Time: time.Now(), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := applyPayment(ctx, q, payment); err != nil {
+			return err
+		}
+
+		refunds, err = refundCancelledOrders(ctx, q, payment)
+		return err
+	})
+	if errors.Is(err, utils.ErrInsufficientFunds) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"message":    "Your wallet balance is too low.",
+		})
+		return
+	} else if respondUnpayable(ctx, err) {
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	for _, refund := range refunds {
+		if _, err := s.sendRefund(ctx, refund); err != nil {
+			log.Printf("could not send refund %s for payment %s: %v", refund.ID, payment.ID, err)
+		}
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"statusCode": http.StatusCreated,
+		"status":     "success",
+		"message":    "payment completed successfully",
+		"data":       newPaymentResponse(payment),
+	})
+}
+
+// walletAccount returns the user's wallet, opening it on first use.
+func walletAccount(ctx context.Context, q *db.Queries, userId string) (db.LedgerAccount, error) {
+	id, err := utils.NewID()
+	if err != nil {
+		return db.LedgerAccount{}, err
+	}
+
+	return q.GetOrCreateWallet(ctx, db.GetOrCreateWalletParams{
+		ID:     id,
+		UserID: sql.NullString{String: userId, Valid: true},
+	})
+}
+
+// postLedger writes one balanced ledger transaction and moves the cached
+// balance of every account it touches. It must run inside a transaction so
+// the entries and balances are written together.
+func postLedger(ctx context.Context, q *db.Queries, kind, description string, paymentId sql.NullString, lines ...utils.LedgerLine) (db.LedgerTransaction, error) {
+	balanced, err := utils.BalanceLedger(lines)
+	if err != nil {
+		return db.LedgerTransaction{}, err
+	}
+
+	id, err := utils.NewID()
+	if err != nil {
+		return db.LedgerTransaction{}, err
+	}
+
+	transaction, err := q.CreateLedgerTransaction(ctx, db.CreateLedgerTransactionParams{
+		ID:          id,
+		Kind:        kind,
+		Description: description,
+		PaymentID:   paymentId,
+	})
+	if err != nil {
+		return transaction, err
+	}
+
+	for _, line := range balanced {
+		balance, err := q.AdjustLedgerBalance(ctx, db.AdjustLedgerBalanceParams{
+			ID:     line.Account,
+			Amount: line.Amount,
+		})
+		if err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Constraint == "account_balance_not_negative" {
+				return transaction, utils.ErrInsufficientFunds
+			}
+			return transaction, err
+		}
+
+		entryId, err := utils.NewID()
+		if err != nil {
+			return transaction, err
+		}
+
+		if _, err := q.CreateLedgerEntry(ctx, db.CreateLedgerEntryParams{
+			ID:            entryId,
+			TransactionID: transaction.ID,
+			AccountID:     line.Account,
+			Amount:        line.Amount,
+			BalanceAfter:  balance,
+		}); err != nil {
+			return transaction, err
+		}
+	}
+
+	return transaction, nil
+}
+
+// postPayment records where a successful payment's money came from and
+// went to. Money comes out of the wallet for wallet payments and in from
+// the provider otherwise. It goes to the wallet for a top-up; otherwise
+// tips are kept apart from sales so they can be paid to riders in full.
+func postPayment(ctx context.Context, q *db.Queries, payment db.Payment) error {
+	source := utils.AccountProvider
+	if payment.Provider == utils.WalletProvider {
+		wallet, err := walletAccount(ctx, q, payment.UserID)
+		if err != nil {
+			return err
+		}
+		source = wallet.ID
+	}
+
+	kind := utils.LedgerPayment
+	tip := utils.Kobo(0)
+	var description string
+
+	switch {
+	case payment.WalletAccountID.Valid:
+		kind = utils.LedgerTopUp
+		description = "Wallet top-up"
+
+	case payment.CheckoutID.Valid:
+		checkout, err := q.GetCheckout(ctx, payment.CheckoutID.String)
+		if err != nil {
+			return err
+		}
+		tip = checkout.Tip
+		description = "Payment for checkout " + checkout.ID
+
+	case payment.OrderID.Valid:
+		order, err := q.GetOrder(ctx, payment.OrderID.String)
+		if err != nil {
+			return err
+		}
+		tip = order.Tip
+		description = "Payment for order " + order.ID
+
+	case payment.TipID.Valid:
+		tip = payment.Amount
+		description = "Rider tip"
+	}
+
+	lines := []utils.LedgerLine{
+		{Account: source, Amount: utils.Kobo(0).Sub(payment.Amount)},
+		{Account: utils.AccountTips, Amount: tip},
+		{Account: utils.AccountSales, Amount: payment.Amount.Sub(tip)},
+	}
+	if payment.WalletAccountID.Valid {
+		lines = []utils.LedgerLine{
+			{Account: source, Amount: utils.Kobo(0).Sub(payment.Amount)},
+			{Account: payment.WalletAccountID.String, Amount: payment.Amount},
+		}
+	}
+
+	_, err := postLedger(ctx, q, kind, description, sql.NullString{String: payment.ID, Valid: true}, lines...)
+	return err
+}
+
+func newWalletResponse(wallet db.LedgerAccount) WalletResponse {
+	return WalletResponse{
+		ID:        wallet.ID,
+		Balance:   wallet.Balance,
+		Currency:  wallet.Currency,
+		UpdatedAt: wallet.UpdatedAt,
+	}
+}
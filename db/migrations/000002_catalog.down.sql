@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "products" CASCADE;
+DROP TABLE IF EXISTS "shops" CASCADE;
@@ -0,0 +1,29 @@
+CREATE TABLE "shops" (
+  "id" varchar(50) PRIMARY KEY,
+  "owner_id" varchar(50) NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "name" varchar(100) NOT NULL,
+  "description" text NOT NULL DEFAULT '',
+  "address" varchar(300) NOT NULL,
+  "phone" varchar(11) NOT NULL,
+  "image_url" varchar NOT NULL DEFAULT '',
+  "is_open" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "products" (
+  "id" varchar(50) PRIMARY KEY,
+  "shop_id" varchar(50) NOT NULL REFERENCES "shops" ("id") ON DELETE CASCADE,
+  "name" varchar(100) NOT NULL,
+  "description" text NOT NULL DEFAULT '',
+  "category" varchar(50) NOT NULL DEFAULT '',
+  "price" numeric(12,2) NOT NULL CHECK ("price" >= 0),
+  "image_urls" text[] NOT NULL DEFAULT '{}',
+  "is_available" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "shops" ("owner_id");
+CREATE INDEX ON "products" ("shop_id");
+CREATE INDEX ON "products" ("category");
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS "allergen_profiles" CASCADE;
+
+ALTER TABLE "products"
+  DROP COLUMN IF EXISTS "allergens",
+  DROP COLUMN IF EXISTS "spice_level",
+  DROP COLUMN IF EXISTS "dietary_tags";
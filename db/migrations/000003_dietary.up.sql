@@ -0,0 +1,14 @@
+ALTER TABLE "products"
+  ADD COLUMN "dietary_tags" text[] NOT NULL DEFAULT '{}',
+  ADD COLUMN "spice_level" smallint NOT NULL DEFAULT 0 CHECK ("spice_level" BETWEEN 0 AND 3),
+  ADD COLUMN "allergens" text[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX ON "products" USING GIN ("dietary_tags");
+CREATE INDEX ON "products" USING GIN ("allergens");
+
+CREATE TABLE "allergen_profiles" (
+  "user_id" varchar(50) PRIMARY KEY REFERENCES "users" ("id") ON DELETE CASCADE,
+  "allergens" text[] NOT NULL DEFAULT '{}',
+  "hide_unsafe" boolean NOT NULL DEFAULT false,
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS "review_reports" CASCADE;
+DROP TABLE IF EXISTS "review_items" CASCADE;
+DROP TABLE IF EXISTS "reviews" CASCADE;
+
+ALTER TABLE "products"
+  DROP COLUMN IF EXISTS "rating_count",
+  DROP COLUMN IF EXISTS "rating_avg";
+
+ALTER TABLE "shops"
+  DROP COLUMN IF EXISTS "rating_count",
+  DROP COLUMN IF EXISTS "rating_avg";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "role";
@@ -0,0 +1,48 @@
+ALTER TABLE "users" ADD COLUMN "role" varchar(20) NOT NULL DEFAULT 'standard';
+
+ALTER TABLE "shops"
+  ADD COLUMN "rating_avg" numeric(3,2) NOT NULL DEFAULT 0,
+  ADD COLUMN "rating_count" integer NOT NULL DEFAULT 0;
+
+ALTER TABLE "products"
+  ADD COLUMN "rating_avg" numeric(3,2) NOT NULL DEFAULT 0,
+  ADD COLUMN "rating_count" integer NOT NULL DEFAULT 0;
+
+CREATE TABLE "reviews" (
+  "id" varchar(50) PRIMARY KEY,
+  "user_id" varchar(50) NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "shop_id" varchar(50) NOT NULL REFERENCES "shops" ("id") ON DELETE CASCADE,
+  "order_id" varchar(50),
+  "shop_rating" smallint NOT NULL CHECK ("shop_rating" BETWEEN 1 AND 5),
+  "delivery_rating" smallint CHECK ("delivery_rating" BETWEEN 1 AND 5),
+  "comment" text NOT NULL DEFAULT '',
+  "vendor_reply" text,
+  "replied_at" timestamptz,
+  "status" varchar(20) NOT NULL DEFAULT 'published',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "review_items" (
+  "id" varchar(50) PRIMARY KEY,
+  "review_id" varchar(50) NOT NULL REFERENCES "reviews" ("id") ON DELETE CASCADE,
+  "product_id" varchar(50) NOT NULL REFERENCES "products" ("id") ON DELETE CASCADE,
+  "rating" smallint NOT NULL CHECK ("rating" BETWEEN 1 AND 5),
+  "comment" text NOT NULL DEFAULT '',
+  UNIQUE ("review_id", "product_id")
+);
+
+CREATE TABLE "review_reports" (
+  "id" varchar(50) PRIMARY KEY,
+  "review_id" varchar(50) NOT NULL REFERENCES "reviews" ("id") ON DELETE CASCADE,
+  "reporter_id" varchar(50) NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "reason" text NOT NULL,
+  "resolved" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  UNIQUE ("review_id", "reporter_id")
+);
+
+CREATE UNIQUE INDEX ON "reviews" ("user_id", "order_id");
+CREATE INDEX ON "reviews" ("shop_id", "status");
+CREATE INDEX ON "review_items" ("product_id");
+CREATE INDEX ON "review_reports" ("review_id") WHERE NOT "resolved";
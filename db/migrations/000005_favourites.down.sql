@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "favourite_products" CASCADE;
+DROP TABLE IF EXISTS "favourite_shops" CASCADE;
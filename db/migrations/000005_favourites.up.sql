@@ -0,0 +1,13 @@
+CREATE TABLE "favourite_shops" (
+  "user_id" varchar(50) NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "shop_id" varchar(50) NOT NULL REFERENCES "shops" ("id") ON DELETE CASCADE,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("user_id", "shop_id")
+);
+
+CREATE TABLE "favourite_products" (
+  "user_id" varchar(50) NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "product_id" varchar(50) NOT NULL REFERENCES "products" ("id") ON DELETE CASCADE,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("user_id", "product_id")
+);
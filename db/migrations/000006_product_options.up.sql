@@ -0,0 +1,11 @@
+CREATE TABLE "product_options" (
+  "id" varchar(50) PRIMARY KEY,
+  "product_id" varchar(50) NOT NULL REFERENCES "products" ("id") ON DELETE CASCADE,
+  "group_name" varchar(50) NOT NULL,
+  "name" varchar(100) NOT NULL,
+  "price_delta" numeric(12,2) NOT NULL DEFAULT 0 CHECK ("price_delta" >= 0),
+  "is_available" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "product_options" ("product_id");
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "order_item_options" CASCADE;
+DROP TABLE IF EXISTS "order_items" CASCADE;
+DROP TABLE IF EXISTS "orders" CASCADE;
@@ -0,0 +1,37 @@
+CREATE TABLE "orders" (
+  "id" varchar(50) PRIMARY KEY,
+  "user_id" varchar(50) NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "shop_id" varchar(50) NOT NULL REFERENCES "shops" ("id"),
+  "status" varchar(30) NOT NULL DEFAULT 'pending',
+  "subtotal" numeric(12,2) NOT NULL,
+  "total" numeric(12,2) NOT NULL,
+  "delivery_address" text NOT NULL,
+  "note" text NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "order_items" (
+  "id" varchar(50) PRIMARY KEY,
+  "order_id" varchar(50) NOT NULL REFERENCES "orders" ("id") ON DELETE CASCADE,
+  "product_id" varchar(50) REFERENCES "products" ("id") ON DELETE SET NULL,
+  "product_name" varchar(255) NOT NULL,
+  "unit_price" numeric(12,2) NOT NULL,
+  "quantity" integer NOT NULL CHECK ("quantity" > 0),
+  "line_total" numeric(12,2) NOT NULL,
+  "note" text NOT NULL DEFAULT ''
+);
+
+CREATE TABLE "order_item_options" (
+  "id" varchar(50) PRIMARY KEY,
+  "order_item_id" varchar(50) NOT NULL REFERENCES "order_items" ("id") ON DELETE CASCADE,
+  "option_id" varchar(50) REFERENCES "product_options" ("id") ON DELETE SET NULL,
+  "group_name" varchar(50) NOT NULL,
+  "name" varchar(100) NOT NULL,
+  "price_delta" numeric(12,2) NOT NULL
+);
+
+CREATE INDEX ON "orders" ("user_id", "created_at");
+CREATE INDEX ON "orders" ("shop_id", "status");
+CREATE INDEX ON "order_items" ("order_id");
+CREATE INDEX ON "order_item_options" ("order_item_id");
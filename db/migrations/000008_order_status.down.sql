@@ -0,0 +1,7 @@
+ALTER TABLE "reviews" DROP CONSTRAINT IF EXISTS "reviews_order_id_fkey";
+
+DROP TABLE IF EXISTS "order_status_history" CASCADE;
+
+ALTER TABLE "orders"
+  DROP CONSTRAINT IF EXISTS "orders_status_check",
+  DROP COLUMN IF EXISTS "rider_id";
@@ -0,0 +1,23 @@
+ALTER TABLE "orders"
+  ADD COLUMN "rider_id" varchar(50) REFERENCES "users" ("id") ON DELETE SET NULL,
+  ADD CONSTRAINT "orders_status_check" CHECK ("status" IN ('pending', 'accepted', 'preparing', 'ready', 'picked_up', 'delivered', 'cancelled', 'rejected'));
+
+-- Reviews written before orders existed may point at nothing, so existing
+-- rows are not checked.
+ALTER TABLE "reviews"
+  ADD CONSTRAINT "reviews_order_id_fkey" FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE SET NULL NOT VALID;
+
+CREATE TABLE "order_status_history" (
+  "id" varchar(50) PRIMARY KEY,
+  "order_id" varchar(50) NOT NULL REFERENCES "orders" ("id") ON DELETE CASCADE,
+  "from_status" varchar(30),
+  "to_status" varchar(30) NOT NULL,
+  "actor_id" varchar(50) REFERENCES "users" ("id") ON DELETE SET NULL,
+  "actor_role" varchar(20) NOT NULL,
+  "note" text NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "order_status_history" ("order_id", "created_at");
+CREATE INDEX ON "orders" ("rider_id");
+CREATE INDEX ON "orders" ("status") WHERE "rider_id" IS NULL;
@@ -0,0 +1,10 @@
+ALTER TABLE "orders"
+  DROP CONSTRAINT IF EXISTS "orders_status_check",
+  DROP COLUMN IF EXISTS "release_at",
+  DROP COLUMN IF EXISTS "scheduled_for";
+
+ALTER TABLE "orders" ADD CONSTRAINT "orders_status_check" CHECK ("status" IN ('pending', 'accepted', 'preparing', 'ready', 'picked_up', 'delivered', 'cancelled', 'rejected'));
+
+DROP TABLE IF EXISTS "shop_hours" CASCADE;
+
+ALTER TABLE "shops" DROP COLUMN IF EXISTS "lead_time_minutes";
@@ -0,0 +1,19 @@
+ALTER TABLE "shops" ADD COLUMN "lead_time_minutes" integer NOT NULL DEFAULT 30 CHECK ("lead_time_minutes" >= 0);
+
+-- Opening hours in minutes from midnight, shop local time. A shop with no
+-- rows is treated as open around the clock.
+CREATE TABLE "shop_hours" (
+  "shop_id" varchar(50) NOT NULL REFERENCES "shops" ("id") ON DELETE CASCADE,
+  "weekday" smallint NOT NULL CHECK ("weekday" BETWEEN 0 AND 6),
+  "opens_minute" integer NOT NULL CHECK ("opens_minute" BETWEEN 0 AND 1439),
+  "closes_minute" integer NOT NULL CHECK ("closes_minute" BETWEEN 1 AND 1440),
+  PRIMARY KEY ("shop_id", "weekday", "opens_minute")
+);
+
+ALTER TABLE "orders"
+  ADD COLUMN "scheduled_for" timestamptz,
+  ADD COLUMN "release_at" timestamptz,
+  DROP CONSTRAINT "orders_status_check",
+  ADD CONSTRAINT "orders_status_check" CHECK ("status" IN ('scheduled', 'pending', 'accepted', 'preparing', 'ready', 'picked_up', 'delivered', 'cancelled', 'rejected'));
+
+CREATE INDEX ON "orders" ("release_at") WHERE "status" = 'scheduled';
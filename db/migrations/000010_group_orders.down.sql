@@ -0,0 +1,5 @@
+ALTER TABLE "order_items" DROP COLUMN IF EXISTS "added_by";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "group_order_id";
+
+DROP TABLE IF EXISTS "group_order_members" CASCADE;
+DROP TABLE IF EXISTS "group_orders" CASCADE;
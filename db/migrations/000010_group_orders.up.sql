@@ -0,0 +1,30 @@
+CREATE TABLE "group_orders" (
+  "id" varchar(50) PRIMARY KEY,
+  "host_id" varchar(50) NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "shop_id" varchar(50) NOT NULL REFERENCES "shops" ("id") ON DELETE CASCADE,
+  "invite_code" varchar(50) NOT NULL UNIQUE,
+  "status" varchar(20) NOT NULL DEFAULT 'open' CHECK ("status" IN ('open', 'locked', 'submitted', 'cancelled')),
+  "payment_mode" varchar(20) NOT NULL CHECK ("payment_mode" IN ('host', 'split')),
+  "deadline" timestamptz NOT NULL,
+  "delivery_address" text NOT NULL,
+  "note" text NOT NULL DEFAULT '',
+  "order_id" varchar(50) REFERENCES "orders" ("id") ON DELETE SET NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "group_order_members" (
+  "group_order_id" varchar(50) NOT NULL REFERENCES "group_orders" ("id") ON DELETE CASCADE,
+  "user_id" varchar(50) NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "joined_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("group_order_id", "user_id")
+);
+
+ALTER TABLE "orders" ADD COLUMN "group_order_id" varchar(50) REFERENCES "group_orders" ("id") ON DELETE SET NULL;
+
+-- Who put each line in the order, for the per-person breakdown of group
+-- orders. For ordinary orders it is the customer.
+ALTER TABLE "order_items" ADD COLUMN "added_by" varchar(50) REFERENCES "users" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "group_orders" ("deadline") WHERE "status" = 'open';
+CREATE INDEX ON "group_order_members" ("user_id");
@@ -0,0 +1,15 @@
+CREATE TABLE "order_cancellations" (
+  "order_id" varchar(50) PRIMARY KEY REFERENCES "orders" ("id") ON DELETE CASCADE,
+  "cancelled_by" varchar(50) REFERENCES "users" ("id") ON DELETE SET NULL,
+  "actor_role" varchar(20) NOT NULL,
+  "reason_code" varchar(30) NOT NULL,
+  "note" text NOT NULL DEFAULT '',
+  "fee" numeric(12,2) NOT NULL DEFAULT 0 CHECK ("fee" >= 0),
+  "refund_amount" numeric(12,2) NOT NULL DEFAULT 0 CHECK ("refund_amount" >= 0),
+  "refund_to" varchar(20) NOT NULL DEFAULT 'original' CHECK ("refund_to" IN ('original', 'wallet')),
+  "refund_status" varchar(20) NOT NULL DEFAULT 'not_required' CHECK ("refund_status" IN ('not_required', 'pending', 'processing', 'refunded', 'failed')),
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "order_cancellations" ("refund_status", "created_at");
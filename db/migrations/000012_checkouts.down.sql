@@ -0,0 +1,5 @@
+ALTER TABLE "orders"
+  DROP COLUMN IF EXISTS "delivery_fee",
+  DROP COLUMN IF EXISTS "checkout_id";
+
+DROP TABLE IF EXISTS "checkouts" CASCADE;
@@ -0,0 +1,20 @@
+-- A checkout is what the customer pays for in one go. A cart with items
+-- from several shops becomes one checkout with an order per shop, and each
+-- of those orders goes through its own status lifecycle.
+CREATE TABLE "checkouts" (
+  "id" varchar(50) PRIMARY KEY,
+  "user_id" varchar(50) NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "subtotal" numeric(12,2) NOT NULL CHECK ("subtotal" >= 0),
+  "delivery_fee" numeric(12,2) NOT NULL DEFAULT 0 CHECK ("delivery_fee" >= 0),
+  "total" numeric(12,2) NOT NULL CHECK ("total" >= 0),
+  "delivery_address" text NOT NULL,
+  "note" text NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "orders"
+  ADD COLUMN "checkout_id" varchar(50) REFERENCES "checkouts" ("id") ON DELETE SET NULL,
+  ADD COLUMN "delivery_fee" numeric(12,2) NOT NULL DEFAULT 0 CHECK ("delivery_fee" >= 0);
+
+CREATE INDEX ON "checkouts" ("user_id", "created_at");
+CREATE INDEX ON "orders" ("checkout_id");
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "order_deliveries" CASCADE;
+DROP TABLE IF EXISTS "shop_locations" CASCADE;
@@ -0,0 +1,24 @@
+-- Kept apart from shops so shops without a pin on the map need no
+-- placeholder coordinates.
+CREATE TABLE "shop_locations" (
+  "shop_id" varchar(50) PRIMARY KEY REFERENCES "shops" ("id") ON DELETE CASCADE,
+  "latitude" double precision NOT NULL CHECK ("latitude" BETWEEN -90 AND 90),
+  "longitude" double precision NOT NULL CHECK ("longitude" BETWEEN -180 AND 180),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- How an order's delivery fee was worked out. Amounts are what was charged
+-- at the time and are not recalculated when pricing changes.
+CREATE TABLE "order_deliveries" (
+  "order_id" varchar(50) PRIMARY KEY REFERENCES "orders" ("id") ON DELETE CASCADE,
+  "latitude" double precision,
+  "longitude" double precision,
+  "distance_meters" integer,
+  "distance_fee" numeric(12,2) NOT NULL DEFAULT 0,
+  "small_order_fee" numeric(12,2) NOT NULL DEFAULT 0,
+  "surge_percent" integer NOT NULL DEFAULT 100,
+  "surge_fee" numeric(12,2) NOT NULL DEFAULT 0,
+  "free_delivery_discount" numeric(12,2) NOT NULL DEFAULT 0,
+  "fee" numeric(12,2) NOT NULL DEFAULT 0 CHECK ("fee" >= 0),
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
@@ -0,0 +1,53 @@
+ALTER TABLE "products"
+  ALTER COLUMN "price" TYPE numeric(12,2) USING "price" / 100.0;
+
+ALTER TABLE "product_options"
+  ALTER COLUMN "price_delta" DROP DEFAULT,
+  ALTER COLUMN "price_delta" TYPE numeric(12,2) USING "price_delta" / 100.0,
+  ALTER COLUMN "price_delta" SET DEFAULT 0;
+
+ALTER TABLE "orders"
+  ALTER COLUMN "subtotal" TYPE numeric(12,2) USING "subtotal" / 100.0,
+  ALTER COLUMN "total" TYPE numeric(12,2) USING "total" / 100.0,
+  ALTER COLUMN "delivery_fee" DROP DEFAULT,
+  ALTER COLUMN "delivery_fee" TYPE numeric(12,2) USING "delivery_fee" / 100.0,
+  ALTER COLUMN "delivery_fee" SET DEFAULT 0;
+
+ALTER TABLE "order_items"
+  ALTER COLUMN "unit_price" TYPE numeric(12,2) USING "unit_price" / 100.0,
+  ALTER COLUMN "line_total" TYPE numeric(12,2) USING "line_total" / 100.0;
+
+ALTER TABLE "order_item_options"
+  ALTER COLUMN "price_delta" TYPE numeric(12,2) USING "price_delta" / 100.0;
+
+ALTER TABLE "order_cancellations"
+  ALTER COLUMN "fee" DROP DEFAULT,
+  ALTER COLUMN "fee" TYPE numeric(12,2) USING "fee" / 100.0,
+  ALTER COLUMN "fee" SET DEFAULT 0,
+  ALTER COLUMN "refund_amount" DROP DEFAULT,
+  ALTER COLUMN "refund_amount" TYPE numeric(12,2) USING "refund_amount" / 100.0,
+  ALTER COLUMN "refund_amount" SET DEFAULT 0;
+
+ALTER TABLE "checkouts"
+  ALTER COLUMN "subtotal" TYPE numeric(12,2) USING "subtotal" / 100.0,
+  ALTER COLUMN "delivery_fee" DROP DEFAULT,
+  ALTER COLUMN "delivery_fee" TYPE numeric(12,2) USING "delivery_fee" / 100.0,
+  ALTER COLUMN "delivery_fee" SET DEFAULT 0,
+  ALTER COLUMN "total" TYPE numeric(12,2) USING "total" / 100.0;
+
+ALTER TABLE "order_deliveries"
+  ALTER COLUMN "distance_fee" DROP DEFAULT,
+  ALTER COLUMN "distance_fee" TYPE numeric(12,2) USING "distance_fee" / 100.0,
+  ALTER COLUMN "distance_fee" SET DEFAULT 0,
+  ALTER COLUMN "small_order_fee" DROP DEFAULT,
+  ALTER COLUMN "small_order_fee" TYPE numeric(12,2) USING "small_order_fee" / 100.0,
+  ALTER COLUMN "small_order_fee" SET DEFAULT 0,
+  ALTER COLUMN "surge_fee" DROP DEFAULT,
+  ALTER COLUMN "surge_fee" TYPE numeric(12,2) USING "surge_fee" / 100.0,
+  ALTER COLUMN "surge_fee" SET DEFAULT 0,
+  ALTER COLUMN "free_delivery_discount" DROP DEFAULT,
+  ALTER COLUMN "free_delivery_discount" TYPE numeric(12,2) USING "free_delivery_discount" / 100.0,
+  ALTER COLUMN "free_delivery_discount" SET DEFAULT 0,
+  ALTER COLUMN "fee" DROP DEFAULT,
+  ALTER COLUMN "fee" TYPE numeric(12,2) USING "fee" / 100.0,
+  ALTER COLUMN "fee" SET DEFAULT 0;
@@ -0,0 +1,56 @@
+-- Money is kept as a whole number of kobo. Existing naira amounts are
+-- multiplied out; they never had more than two decimal places.
+
+ALTER TABLE "products"
+  ALTER COLUMN "price" TYPE bigint USING round("price" * 100)::bigint;
+
+ALTER TABLE "product_options"
+  ALTER COLUMN "price_delta" DROP DEFAULT,
+  ALTER COLUMN "price_delta" TYPE bigint USING round("price_delta" * 100)::bigint,
+  ALTER COLUMN "price_delta" SET DEFAULT 0;
+
+ALTER TABLE "orders"
+  ALTER COLUMN "subtotal" TYPE bigint USING round("subtotal" * 100)::bigint,
+  ALTER COLUMN "total" TYPE bigint USING round("total" * 100)::bigint,
+  ALTER COLUMN "delivery_fee" DROP DEFAULT,
+  ALTER COLUMN "delivery_fee" TYPE bigint USING round("delivery_fee" * 100)::bigint,
+  ALTER COLUMN "delivery_fee" SET DEFAULT 0;
+
+ALTER TABLE "order_items"
+  ALTER COLUMN "unit_price" TYPE bigint USING round("unit_price" * 100)::bigint,
+  ALTER COLUMN "line_total" TYPE bigint USING round("line_total" * 100)::bigint;
+
+ALTER TABLE "order_item_options"
+  ALTER COLUMN "price_delta" TYPE bigint USING round("price_delta" * 100)::bigint;
+
+ALTER TABLE "order_cancellations"
+  ALTER COLUMN "fee" DROP DEFAULT,
+  ALTER COLUMN "fee" TYPE bigint USING round("fee" * 100)::bigint,
+  ALTER COLUMN "fee" SET DEFAULT 0,
+  ALTER COLUMN "refund_amount" DROP DEFAULT,
+  ALTER COLUMN "refund_amount" TYPE bigint USING round("refund_amount" * 100)::bigint,
+  ALTER COLUMN "refund_amount" SET DEFAULT 0;
+
+ALTER TABLE "checkouts"
+  ALTER COLUMN "subtotal" TYPE bigint USING round("subtotal" * 100)::bigint,
+  ALTER COLUMN "delivery_fee" DROP DEFAULT,
+  ALTER COLUMN "delivery_fee" TYPE bigint USING round("delivery_fee" * 100)::bigint,
+  ALTER COLUMN "delivery_fee" SET DEFAULT 0,
+  ALTER COLUMN "total" TYPE bigint USING round("total" * 100)::bigint;
+
+ALTER TABLE "order_deliveries"
+  ALTER COLUMN "distance_fee" DROP DEFAULT,
+  ALTER COLUMN "distance_fee" TYPE bigint USING round("distance_fee" * 100)::bigint,
+  ALTER COLUMN "distance_fee" SET DEFAULT 0,
+  ALTER COLUMN "small_order_fee" DROP DEFAULT,
+  ALTER COLUMN "small_order_fee" TYPE bigint USING round("small_order_fee" * 100)::bigint,
+  ALTER COLUMN "small_order_fee" SET DEFAULT 0,
+  ALTER COLUMN "surge_fee" DROP DEFAULT,
+  ALTER COLUMN "surge_fee" TYPE bigint USING round("surge_fee" * 100)::bigint,
+  ALTER COLUMN "surge_fee" SET DEFAULT 0,
+  ALTER COLUMN "free_delivery_discount" DROP DEFAULT,
+  ALTER COLUMN "free_delivery_discount" TYPE bigint USING round("free_delivery_discount" * 100)::bigint,
+  ALTER COLUMN "free_delivery_discount" SET DEFAULT 0,
+  ALTER COLUMN "fee" DROP DEFAULT,
+  ALTER COLUMN "fee" TYPE bigint USING round("fee" * 100)::bigint,
+  ALTER COLUMN "fee" SET DEFAULT 0;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "order_invoices" CASCADE;
+DROP TABLE IF EXISTS "shop_invoice_counters" CASCADE;
+
+ALTER TABLE "order_items" DROP COLUMN IF EXISTS "vat_exempt", DROP COLUMN IF EXISTS "vat";
+ALTER TABLE "checkouts" DROP COLUMN IF EXISTS "vat", DROP COLUMN IF EXISTS "service_charge";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "vat", DROP COLUMN IF EXISTS "service_charge";
+ALTER TABLE "shops" DROP COLUMN IF EXISTS "service_charge_basis_points";
@@ -0,0 +1,39 @@
+ALTER TABLE "shops"
+  ADD COLUMN "service_charge_basis_points" integer NOT NULL DEFAULT 0
+    CHECK ("service_charge_basis_points" BETWEEN 0 AND 2500);
+
+ALTER TABLE "orders"
+  ADD COLUMN "service_charge" bigint NOT NULL DEFAULT 0,
+  ADD COLUMN "vat" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "checkouts"
+  ADD COLUMN "service_charge" bigint NOT NULL DEFAULT 0,
+  ADD COLUMN "vat" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "order_items"
+  ADD COLUMN "vat" bigint NOT NULL DEFAULT 0,
+  ADD COLUMN "vat_exempt" boolean NOT NULL DEFAULT false;
+
+-- One row per shop holding the last invoice number handed out. Taking the
+-- next number locks the row until the order's transaction ends, so numbers
+-- are sequential and a rolled back order does not leave a gap.
+CREATE TABLE "shop_invoice_counters" (
+  "shop_id" varchar(50) PRIMARY KEY REFERENCES "shops" ("id") ON DELETE CASCADE,
+  "last_number" bigint NOT NULL
+);
+
+-- The tax invoice for an order, with the rates that applied when it was
+-- placed.
+CREATE TABLE "order_invoices" (
+  "order_id" varchar(50) PRIMARY KEY REFERENCES "orders" ("id") ON DELETE CASCADE,
+  "shop_id" varchar(50) NOT NULL REFERENCES "shops" ("id") ON DELETE CASCADE,
+  "number" bigint NOT NULL,
+  "vat_basis_points" integer NOT NULL,
+  "prices_include_vat" boolean NOT NULL,
+  "service_charge_basis_points" integer NOT NULL,
+  "taxable_amount" bigint NOT NULL,
+  "vat" bigint NOT NULL,
+  "service_charge" bigint NOT NULL,
+  "issued_at" timestamptz NOT NULL DEFAULT (now()),
+  UNIQUE ("shop_id", "number")
+);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "order_status_history_to_status_created_at_idx";
+ALTER TABLE "checkouts" DROP COLUMN IF EXISTS "tip";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "tip";
+DROP TABLE IF EXISTS "order_tips" CASCADE;
@@ -0,0 +1,18 @@
+-- A tip chosen at checkout is part of the order's total and is paid with
+-- it; one added after delivery is charged on its own. Either way the whole
+-- tip goes to the rider and commission is never taken from it.
+CREATE TABLE "order_tips" (
+  "id" varchar(50) PRIMARY KEY,
+  "order_id" varchar(50) NOT NULL REFERENCES "orders" ("id") ON DELETE CASCADE,
+  "user_id" varchar(50) NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "amount" bigint NOT NULL CHECK ("amount" > 0),
+  "stage" varchar(20) NOT NULL CHECK ("stage" IN ('checkout', 'after_delivery')),
+  "status" varchar(20) NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'captured', 'failed', 'refunded')),
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  UNIQUE ("order_id", "stage")
+);
+
+ALTER TABLE "orders" ADD COLUMN "tip" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "checkouts" ADD COLUMN "tip" bigint NOT NULL DEFAULT 0;
+
+CREATE INDEX ON "order_status_history" ("to_status", "created_at");
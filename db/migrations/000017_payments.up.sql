@@ -0,0 +1,32 @@
+-- Every attempt to take a payment through a provider. A payment is for
+-- exactly one thing: a checkout, a group order's order (which has no
+-- checkout), or a tip added after delivery. Reference is ours and is what
+-- the provider sends back in its webhooks.
+CREATE TABLE "payments" (
+  "id" varchar(50) PRIMARY KEY,
+  "reference" varchar(100) UNIQUE NOT NULL,
+  "user_id" varchar(50) NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "checkout_id" varchar(50) REFERENCES "checkouts" ("id") ON DELETE CASCADE,
+  "order_id" varchar(50) REFERENCES "orders" ("id") ON DELETE CASCADE,
+  "tip_id" varchar(50) REFERENCES "order_tips" ("id") ON DELETE CASCADE,
+  "provider" varchar(20) NOT NULL,
+  "provider_reference" varchar(100) NOT NULL DEFAULT '',
+  "amount" bigint NOT NULL CHECK ("amount" > 0),
+  "currency" varchar(3) NOT NULL DEFAULT 'NGN',
+  "status" varchar(20) NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'succeeded', 'failed')),
+  "channel" varchar(30) NOT NULL DEFAULT '',
+  "authorization_url" text NOT NULL DEFAULT '',
+  "failure_reason" text NOT NULL DEFAULT '',
+  "paid_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK (num_nonnulls("checkout_id", "order_id", "tip_id") = 1)
+);
+
+ALTER TABLE "orders" ADD COLUMN "payment_status" varchar(20) NOT NULL DEFAULT 'unpaid'
+  CHECK ("payment_status" IN ('unpaid', 'failed', 'paid'));
+
+CREATE INDEX ON "payments" ("checkout_id");
+CREATE INDEX ON "payments" ("order_id");
+CREATE INDEX ON "payments" ("tip_id");
+CREATE INDEX ON "payments" ("user_id", "created_at");
@@ -0,0 +1,8 @@
+DELETE FROM "payments" WHERE "wallet_account_id" IS NOT NULL;
+ALTER TABLE "payments"
+  DROP CONSTRAINT IF EXISTS "payments_check",
+  DROP COLUMN IF EXISTS "wallet_account_id",
+  ADD CONSTRAINT "payments_check" CHECK (num_nonnulls("checkout_id", "order_id", "tip_id") = 1);
+DROP TABLE IF EXISTS "ledger_entries" CASCADE;
+DROP TABLE IF EXISTS "ledger_transactions" CASCADE;
+DROP TABLE IF EXISTS "ledger_accounts" CASCADE;
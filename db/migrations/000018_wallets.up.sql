@@ -0,0 +1,59 @@
+-- Money held on the platform is kept in a double-entry ledger. Every
+-- movement is a transaction whose entries add up to zero, so money is only
+-- ever moved between accounts, never created. A customer's wallet is an
+-- account with a user; the rest are the platform's own accounts and are
+-- allowed to go negative:
+--
+--   provider  money received through payment providers
+--   sales     money paid for orders, owed on to shops
+--   tips      tips owed to riders
+--   cashback  money given away as cashback
+--
+-- balance is a cache of the sum of an account's entries, kept in step in
+-- the same database transaction that writes them.
+CREATE TABLE "ledger_accounts" (
+  "id" varchar(50) PRIMARY KEY,
+  "user_id" varchar(50) UNIQUE REFERENCES "users" ("id") ON DELETE SET NULL,
+  "kind" varchar(20) NOT NULL CHECK ("kind" IN ('wallet', 'system')),
+  "currency" varchar(3) NOT NULL DEFAULT 'NGN',
+  "balance" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "wallet_balance_not_negative" CHECK ("kind" <> 'wallet' OR "balance" >= 0)
+);
+
+INSERT INTO "ledger_accounts" ("id", "kind") VALUES
+  ('provider', 'system'),
+  ('sales', 'system'),
+  ('tips', 'system'),
+  ('cashback', 'system');
+
+CREATE TABLE "ledger_transactions" (
+  "id" varchar(50) PRIMARY KEY,
+  "kind" varchar(20) NOT NULL CHECK ("kind" IN ('topup', 'payment', 'refund', 'cashback', 'adjustment')),
+  "description" text NOT NULL DEFAULT '',
+  "payment_id" varchar(50) REFERENCES "payments" ("id") ON DELETE SET NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- A payment is posted to the ledger once per kind, however many times its
+-- webhook arrives.
+CREATE UNIQUE INDEX ON "ledger_transactions" ("payment_id", "kind") WHERE "payment_id" IS NOT NULL;
+
+CREATE TABLE "ledger_entries" (
+  "id" varchar(50) PRIMARY KEY,
+  "transaction_id" varchar(50) NOT NULL REFERENCES "ledger_transactions" ("id") ON DELETE RESTRICT,
+  "account_id" varchar(50) NOT NULL REFERENCES "ledger_accounts" ("id") ON DELETE RESTRICT,
+  "amount" bigint NOT NULL CHECK ("amount" <> 0),
+  "balance_after" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "ledger_entries" ("account_id", "created_at", "id");
+CREATE INDEX ON "ledger_entries" ("transaction_id");
+
+-- Wallet top-ups are paid for through the provider like anything else.
+ALTER TABLE "payments"
+  ADD COLUMN "wallet_account_id" varchar(50) REFERENCES "ledger_accounts" ("id") ON DELETE CASCADE,
+  DROP CONSTRAINT "payments_check",
+  ADD CONSTRAINT "payments_check" CHECK (num_nonnulls("checkout_id", "order_id", "tip_id", "wallet_account_id") = 1);
@@ -0,0 +1,21 @@
+DELETE FROM "ledger_entries" WHERE "transaction_id" IN (
+  SELECT "id" FROM "ledger_transactions" WHERE "kind" IN ('settlement', 'payout'));
+DELETE FROM "ledger_transactions" WHERE "kind" IN ('settlement', 'payout');
+ALTER TABLE "ledger_transactions"
+  DROP CONSTRAINT IF EXISTS "ledger_transactions_kind_check",
+  ADD CONSTRAINT "ledger_transactions_kind_check" CHECK ("kind" IN ('topup', 'payment', 'refund', 'cashback', 'adjustment'));
+
+DELETE FROM "ledger_entries" WHERE "account_id" IN (
+  SELECT "id" FROM "ledger_accounts" WHERE "kind" = 'shop' OR "id" = 'commission');
+DELETE FROM "ledger_accounts" WHERE "kind" = 'shop' OR "id" = 'commission';
+ALTER TABLE "ledger_accounts"
+  DROP CONSTRAINT IF EXISTS "account_balance_not_negative",
+  ADD CONSTRAINT "wallet_balance_not_negative" CHECK ("kind" <> 'wallet' OR "balance" >= 0),
+  DROP CONSTRAINT IF EXISTS "ledger_accounts_kind_check",
+  ADD CONSTRAINT "ledger_accounts_kind_check" CHECK ("kind" IN ('wallet', 'system')),
+  DROP COLUMN IF EXISTS "shop_id";
+
+DROP TABLE IF EXISTS "payouts" CASCADE;
+DROP TABLE IF EXISTS "shop_bank_accounts" CASCADE;
+DROP TABLE IF EXISTS "vendor_earnings" CASCADE;
+DROP TABLE IF EXISTS "settlements" CASCADE;
@@ -0,0 +1,85 @@
+-- What each delivered order earned its shop after commission and payment
+-- processing fees, with the rates used. Earnings are gathered into weekly
+-- settlements; only once a settlement closes does its money reach the
+-- shop's ledger account and become available to pay out.
+CREATE TABLE "settlements" (
+  "id" varchar(50) PRIMARY KEY,
+  "shop_id" varchar(50) NOT NULL REFERENCES "shops" ("id") ON DELETE CASCADE,
+  "period_start" timestamptz NOT NULL,
+  "period_end" timestamptz NOT NULL,
+  "order_count" integer NOT NULL DEFAULT 0,
+  "gross" bigint NOT NULL DEFAULT 0,
+  "commission" bigint NOT NULL DEFAULT 0,
+  "processing_fee" bigint NOT NULL DEFAULT 0,
+  "net" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  UNIQUE ("shop_id", "period_start"),
+  CHECK ("period_start" < "period_end")
+);
+
+CREATE TABLE "vendor_earnings" (
+  "order_id" varchar(50) PRIMARY KEY REFERENCES "orders" ("id") ON DELETE CASCADE,
+  "shop_id" varchar(50) NOT NULL REFERENCES "shops" ("id") ON DELETE CASCADE,
+  "gross" bigint NOT NULL,
+  "commission" bigint NOT NULL,
+  "processing_fee" bigint NOT NULL,
+  "net" bigint NOT NULL,
+  "commission_percent" integer NOT NULL,
+  "processing_fee_basis_points" integer NOT NULL,
+  "settlement_id" varchar(50) REFERENCES "settlements" ("id") ON DELETE SET NULL,
+  "earned_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "vendor_earnings" ("shop_id", "earned_at") WHERE "settlement_id" IS NULL;
+CREATE INDEX ON "vendor_earnings" ("settlement_id");
+CREATE INDEX ON "settlements" ("period_start");
+
+-- The bank account a shop is paid into, as confirmed by the payout
+-- provider.
+CREATE TABLE "shop_bank_accounts" (
+  "shop_id" varchar(50) PRIMARY KEY REFERENCES "shops" ("id") ON DELETE CASCADE,
+  "bank_code" varchar(10) NOT NULL,
+  "account_number" varchar(20) NOT NULL,
+  "account_name" varchar(200) NOT NULL,
+  "provider" varchar(20) NOT NULL,
+  "recipient_code" varchar(100) NOT NULL,
+  "verified_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- Money sent to a shop's bank account. The bank details are copied on so
+-- later changes to the account do not rewrite history.
+CREATE TABLE "payouts" (
+  "id" varchar(50) PRIMARY KEY,
+  "shop_id" varchar(50) NOT NULL REFERENCES "shops" ("id") ON DELETE CASCADE,
+  "requested_by" varchar(50) REFERENCES "users" ("id") ON DELETE SET NULL,
+  "amount" bigint NOT NULL CHECK ("amount" > 0),
+  "bank_code" varchar(10) NOT NULL,
+  "account_number" varchar(20) NOT NULL,
+  "account_name" varchar(200) NOT NULL,
+  "provider" varchar(20) NOT NULL,
+  "provider_reference" varchar(100) NOT NULL DEFAULT '',
+  "status" varchar(20) NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'processing', 'paid', 'failed')),
+  "failure_reason" text NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "payouts" ("shop_id", "created_at");
+CREATE INDEX ON "payouts" ("status") WHERE "status" = 'processing';
+
+-- Shops get ledger accounts like wallets; neither may go below zero. The
+-- commission account collects what the platform keeps, and paying a shop
+-- out moves money back to the provider account it came in through.
+ALTER TABLE "ledger_accounts"
+  ADD COLUMN "shop_id" varchar(50) UNIQUE REFERENCES "shops" ("id") ON DELETE SET NULL,
+  DROP CONSTRAINT "ledger_accounts_kind_check",
+  ADD CONSTRAINT "ledger_accounts_kind_check" CHECK ("kind" IN ('wallet', 'shop', 'system')),
+  DROP CONSTRAINT "wallet_balance_not_negative",
+  ADD CONSTRAINT "account_balance_not_negative" CHECK ("kind" = 'system' OR "balance" >= 0);
+
+INSERT INTO "ledger_accounts" ("id", "kind") VALUES ('commission', 'system');
+
+ALTER TABLE "ledger_transactions"
+  DROP CONSTRAINT "ledger_transactions_kind_check",
+  ADD CONSTRAINT "ledger_transactions_kind_check" CHECK ("kind" IN ('topup', 'payment', 'refund', 'cashback', 'adjustment', 'settlement', 'payout'));
@@ -0,0 +1,11 @@
+UPDATE "orders" SET "payment_status" = 'paid' WHERE "payment_status" IN ('partially_refunded', 'refunded');
+ALTER TABLE "orders"
+  DROP CONSTRAINT IF EXISTS "orders_payment_status_check",
+  ADD CONSTRAINT "orders_payment_status_check" CHECK ("payment_status" IN ('unpaid', 'failed', 'paid'));
+
+ALTER TABLE "payments"
+  DROP CONSTRAINT IF EXISTS "payment_refund_not_above_amount",
+  DROP COLUMN IF EXISTS "refunded_amount";
+
+DROP TABLE IF EXISTS "refund_items" CASCADE;
+DROP TABLE IF EXISTS "refunds" CASCADE;
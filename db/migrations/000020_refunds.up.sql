@@ -0,0 +1,46 @@
+-- Money given back to a customer for an order, all of it or part of it.
+-- Support refunds above the approval threshold wait for a second admin;
+-- refunds for cancellations follow the cancellation policy and need none.
+-- Refunds go back through the provider that took the payment, or into the
+-- customer's wallet. tip_amount is the part that comes out of the rider's
+-- checkout tip rather than sales.
+CREATE TABLE "refunds" (
+  "id" varchar(50) PRIMARY KEY,
+  "order_id" varchar(50) NOT NULL REFERENCES "orders" ("id") ON DELETE CASCADE,
+  "payment_id" varchar(50) NOT NULL REFERENCES "payments" ("id") ON DELETE CASCADE,
+  "source" varchar(20) NOT NULL CHECK ("source" IN ('support', 'cancellation')),
+  "requested_by" varchar(50) REFERENCES "users" ("id") ON DELETE SET NULL,
+  "reviewed_by" varchar(50) REFERENCES "users" ("id") ON DELETE SET NULL,
+  "reason" text NOT NULL DEFAULT '',
+  "amount" bigint NOT NULL CHECK ("amount" > 0),
+  "tip_amount" bigint NOT NULL DEFAULT 0 CHECK ("tip_amount" >= 0 AND "tip_amount" <= "amount"),
+  "refund_to" varchar(20) NOT NULL CHECK ("refund_to" IN ('original', 'wallet')),
+  "status" varchar(20) NOT NULL CHECK ("status" IN ('awaiting_approval', 'rejected', 'pending', 'processing', 'refunded', 'failed')),
+  "provider_reference" varchar(100) NOT NULL DEFAULT '',
+  "failure_reason" text NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- The line items a refund covers, when it was for items rather than an
+-- amount.
+CREATE TABLE "refund_items" (
+  "refund_id" varchar(50) NOT NULL REFERENCES "refunds" ("id") ON DELETE CASCADE,
+  "order_item_id" varchar(50) NOT NULL REFERENCES "order_items" ("id") ON DELETE CASCADE,
+  "quantity" integer NOT NULL CHECK ("quantity" > 0),
+  "amount" bigint NOT NULL CHECK ("amount" >= 0),
+  PRIMARY KEY ("refund_id", "order_item_id")
+);
+
+CREATE INDEX ON "refunds" ("order_id");
+CREATE INDEX ON "refunds" ("status", "created_at");
+CREATE INDEX ON "refund_items" ("order_item_id");
+
+-- A payment can never give back more than it took, however many orders it
+-- paid for.
+ALTER TABLE "payments" ADD COLUMN "refunded_amount" bigint NOT NULL DEFAULT 0,
+  ADD CONSTRAINT "payment_refund_not_above_amount" CHECK ("refunded_amount" >= 0 AND "refunded_amount" <= "amount");
+
+ALTER TABLE "orders"
+  DROP CONSTRAINT "orders_payment_status_check",
+  ADD CONSTRAINT "orders_payment_status_check" CHECK ("payment_status" IN ('unpaid', 'failed', 'paid', 'partially_refunded', 'refunded'));
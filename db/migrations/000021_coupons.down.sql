@@ -0,0 +1,13 @@
+ALTER TABLE "orders"
+  DROP COLUMN IF EXISTS "coupon_id",
+  DROP COLUMN IF EXISTS "discount";
+ALTER TABLE "checkouts"
+  DROP COLUMN IF EXISTS "coupon_id",
+  DROP COLUMN IF EXISTS "discount";
+
+DROP TABLE IF EXISTS "coupon_redemptions" CASCADE;
+DROP TABLE IF EXISTS "coupons" CASCADE;
+
+-- An account that has been posted to stays, so the ledger still adds up.
+DELETE FROM "ledger_accounts" WHERE "id" = 'promotions'
+  AND NOT EXISTS (SELECT 1 FROM "ledger_entries" WHERE "account_id" = 'promotions');
@@ -0,0 +1,55 @@
+-- Promo codes. A coupon takes a percentage or a fixed amount off the items
+-- it applies to, or pays for their delivery. Coupons with a shop only apply
+-- to that shop and are paid for by it; the rest are paid for by the
+-- platform. An empty categories list applies to every category.
+CREATE TABLE "coupons" (
+  "id" varchar(50) PRIMARY KEY,
+  "code" varchar(30) UNIQUE NOT NULL CHECK ("code" = upper("code")),
+  "description" varchar(200) NOT NULL DEFAULT '',
+  "kind" varchar(20) NOT NULL CHECK ("kind" IN ('percentage', 'fixed', 'free_delivery')),
+  "percent_off" integer NOT NULL DEFAULT 0 CHECK ("percent_off" BETWEEN 0 AND 100),
+  "amount_off" bigint NOT NULL DEFAULT 0 CHECK ("amount_off" >= 0),
+  "max_discount" bigint NOT NULL DEFAULT 0 CHECK ("max_discount" >= 0),
+  "min_order" bigint NOT NULL DEFAULT 0 CHECK ("min_order" >= 0),
+  "starts_at" timestamptz,
+  "ends_at" timestamptz,
+  "max_redemptions" integer CHECK ("max_redemptions" > 0),
+  "max_per_user" integer CHECK ("max_per_user" > 0),
+  "first_order_only" boolean NOT NULL DEFAULT false,
+  "shop_id" varchar(50) REFERENCES "shops" ("id") ON DELETE CASCADE,
+  "categories" text[] NOT NULL DEFAULT '{}',
+  "is_active" boolean NOT NULL DEFAULT true,
+  "redemption_count" integer NOT NULL DEFAULT 0,
+  "created_by" varchar(50) REFERENCES "users" ("id") ON DELETE SET NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "coupon_redemptions_within_limit" CHECK ("redemption_count" >= 0 AND ("max_redemptions" IS NULL OR "redemption_count" <= "max_redemptions")),
+  CHECK ("starts_at" IS NULL OR "ends_at" IS NULL OR "starts_at" < "ends_at")
+);
+
+-- One use of a coupon by one checkout. A use is released, and stops
+-- counting, when every order in the checkout is cancelled or rejected.
+CREATE TABLE "coupon_redemptions" (
+  "id" varchar(50) PRIMARY KEY,
+  "coupon_id" varchar(50) NOT NULL REFERENCES "coupons" ("id") ON DELETE CASCADE,
+  "user_id" varchar(50) NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "checkout_id" varchar(50) UNIQUE NOT NULL REFERENCES "checkouts" ("id") ON DELETE CASCADE,
+  "discount" bigint NOT NULL CHECK ("discount" >= 0),
+  "released_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "coupon_redemptions" ("coupon_id", "user_id");
+CREATE INDEX ON "coupons" ("shop_id");
+
+-- promotions pays for platform coupons: when a discounted order earns its
+-- shop the full price, the difference moves from here to sales.
+INSERT INTO "ledger_accounts" ("id", "kind") VALUES
+  ('promotions', 'system');
+
+ALTER TABLE "checkouts"
+  ADD COLUMN "discount" bigint NOT NULL DEFAULT 0,
+  ADD COLUMN "coupon_id" varchar(50) REFERENCES "coupons" ("id") ON DELETE SET NULL;
+ALTER TABLE "orders"
+  ADD COLUMN "discount" bigint NOT NULL DEFAULT 0,
+  ADD COLUMN "coupon_id" varchar(50) REFERENCES "coupons" ("id") ON DELETE SET NULL;
@@ -0,0 +1,7 @@
+DELETE FROM "refunds" WHERE "source" = 'duplicate';
+
+ALTER TABLE "refunds"
+  DROP CONSTRAINT "refunds_source_check",
+  ADD CONSTRAINT "refunds_source_check" CHECK ("source" IN ('support', 'cancellation'));
+
+ALTER TABLE "payments" DROP COLUMN IF EXISTS "duplicate";
@@ -0,0 +1,8 @@
+-- A payment that succeeds for something already paid for, such as a second
+-- provider attempt completing after the first, is kept but flagged and
+-- given back in full with a refund of its own.
+ALTER TABLE "payments" ADD COLUMN "duplicate" boolean NOT NULL DEFAULT false;
+
+ALTER TABLE "refunds"
+  DROP CONSTRAINT "refunds_source_check",
+  ADD CONSTRAINT "refunds_source_check" CHECK ("source" IN ('support', 'cancellation', 'duplicate'));
@@ -0,0 +1,3 @@
+ALTER TABLE "group_orders"
+  DROP CONSTRAINT "group_orders_payment_mode_check",
+  ADD CONSTRAINT "group_orders_payment_mode_check" CHECK ("payment_mode" IN ('host', 'split'));
@@ -0,0 +1,7 @@
+-- Members paying their own share of a group order was never wired up to
+-- payments; the host paid for every group order anyway.
+UPDATE "group_orders" SET "payment_mode" = 'host' WHERE "payment_mode" = 'split';
+
+ALTER TABLE "group_orders"
+  DROP CONSTRAINT "group_orders_payment_mode_check",
+  ADD CONSTRAINT "group_orders_payment_mode_check" CHECK ("payment_mode" IN ('host'));
@@ -0,0 +1,12 @@
+-- name: GetAllergenProfile :one
+SELECT * FROM allergen_profiles WHERE user_id = $1;
+
+-- name: UpsertAllergenProfile :one
+INSERT INTO allergen_profiles (
+    user_id,
+    allergens,
+    hide_unsafe
+) VALUES (
+    $1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE SET allergens = EXCLUDED.allergens, hide_unsafe = EXCLUDED.hide_unsafe, updated_at = now()
+RETURNING *;
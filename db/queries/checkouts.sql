@@ -0,0 +1,27 @@
+-- name: CreateCheckout :one
+INSERT INTO checkouts (
+    id,
+    user_id,
+    subtotal,
+    delivery_fee,
+    total,
+    delivery_address,
+    note,
+    service_charge,
+    vat,
+    tip,
+    discount,
+    coupon_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING *;
+
+-- name: GetCheckout :one
+SELECT * FROM checkouts WHERE id = $1 LIMIT 1;
+
+-- Taken before paying from a wallet, so two payments for the same checkout
+-- cannot both go through.
+-- name: LockCheckout :one
+SELECT * FROM checkouts WHERE id = $1 FOR UPDATE;
+
+-- name: ListCheckoutOrders :many
+SELECT * FROM orders WHERE checkout_id = $1 ORDER BY created_at, id;
@@ -0,0 +1,79 @@
+-- name: CreateCoupon :one
+INSERT INTO coupons (
+    id,
+    code,
+    description,
+    kind,
+    percent_off,
+    amount_off,
+    max_discount,
+    min_order,
+    starts_at,
+    ends_at,
+    max_redemptions,
+    max_per_user,
+    first_order_only,
+    shop_id,
+    categories,
+    created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) RETURNING *;
+
+-- name: GetCoupon :one
+SELECT * FROM coupons WHERE id = $1 LIMIT 1;
+
+-- name: GetCouponByCode :one
+SELECT * FROM coupons WHERE code = $1 LIMIT 1;
+
+-- name: ListCoupons :many
+SELECT * FROM coupons
+ORDER BY created_at DESC, id
+LIMIT $1 OFFSET $2;
+
+-- name: SetCouponActive :one
+UPDATE coupons SET is_active = $2, updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- Counting a use locks the coupon's row until the checkout commits, so
+-- checkouts racing for the last use, or for one customer's last use, queue
+-- up behind each other instead of both getting it.
+-- name: RedeemCoupon :one
+UPDATE coupons SET redemption_count = redemption_count + 1, updated_at = now()
+WHERE id = $1
+  AND is_active
+  AND (max_redemptions IS NULL OR redemption_count < max_redemptions)
+RETURNING *;
+
+-- name: CreateCouponRedemption :one
+INSERT INTO coupon_redemptions (
+    id,
+    coupon_id,
+    user_id,
+    checkout_id,
+    discount
+) VALUES (
+    $1, $2, $3, $4, $5) RETURNING *;
+
+-- name: CountUserRedemptions :one
+SELECT count(*) FROM coupon_redemptions
+WHERE coupon_id = $1 AND user_id = $2 AND released_at IS NULL;
+
+-- name: CountUserPlacedOrders :one
+SELECT count(*) FROM orders
+WHERE user_id = $1 AND status NOT IN ('cancelled', 'rejected');
+
+-- Runs after an order of the checkout is cancelled or rejected, and only
+-- releases the use once none of its orders are still going.
+-- name: ReleaseCheckoutRedemption :one
+UPDATE coupon_redemptions SET released_at = now()
+WHERE checkout_id = sqlc.arg('checkout_id') AND released_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM orders
+    WHERE orders.checkout_id = sqlc.arg('checkout_id')::varchar AND status NOT IN ('cancelled', 'rejected')
+  )
+RETURNING *;
+
+-- name: UnredeemCoupon :exec
+UPDATE coupons SET redemption_count = redemption_count - 1, updated_at = now()
+WHERE id = $1 AND redemption_count > 0;
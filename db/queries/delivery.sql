@@ -0,0 +1,34 @@
+-- name: UpsertShopLocation :one
+INSERT INTO shop_locations (
+    shop_id,
+    latitude,
+    longitude
+) VALUES (
+    $1, $2, $3)
+ON CONFLICT (shop_id) DO UPDATE
+SET latitude = EXCLUDED.latitude, longitude = EXCLUDED.longitude, updated_at = now()
+RETURNING *;
+
+-- name: GetShopLocation :one
+SELECT * FROM shop_locations WHERE shop_id = $1 LIMIT 1;
+
+-- name: DeleteShopLocation :exec
+DELETE FROM shop_locations WHERE shop_id = $1;
+
+-- name: CreateOrderDelivery :one
+INSERT INTO order_deliveries (
+    order_id,
+    latitude,
+    longitude,
+    distance_meters,
+    distance_fee,
+    small_order_fee,
+    surge_percent,
+    surge_fee,
+    free_delivery_discount,
+    fee
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING *;
+
+-- name: GetOrderDelivery :one
+SELECT * FROM order_deliveries WHERE order_id = $1 LIMIT 1;
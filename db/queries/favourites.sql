@@ -0,0 +1,32 @@
+-- name: AddFavouriteShop :exec
+INSERT INTO favourite_shops (user_id, shop_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;
+
+-- name: RemoveFavouriteShop :exec
+DELETE FROM favourite_shops WHERE user_id = $1 AND shop_id = $2;
+
+-- name: AddFavouriteProduct :exec
+INSERT INTO favourite_products (user_id, product_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;
+
+-- name: RemoveFavouriteProduct :exec
+DELETE FROM favourite_products WHERE user_id = $1 AND product_id = $2;
+
+-- name: ListFavouriteShops :many
+SELECT s.id, s.name, s.address, s.image_url, s.is_open, s.rating_avg, s.rating_count, f.created_at AS favourited_at
+FROM favourite_shops f
+JOIN shops s ON s.id = f.shop_id
+WHERE f.user_id = $1
+ORDER BY f.created_at DESC;
+
+-- name: ListFavouriteProducts :many
+SELECT p.id, p.shop_id, s.name AS shop_name, p.name, p.price, p.image_urls, p.is_available, s.is_open AS shop_is_open, f.created_at AS favourited_at
+FROM favourite_products f
+JOIN products p ON p.id = f.product_id
+JOIN shops s ON s.id = p.shop_id
+WHERE f.user_id = $1
+ORDER BY f.created_at DESC;
+
+-- name: FilterFavouriteShops :many
+SELECT shop_id FROM favourite_shops WHERE user_id = sqlc.arg('user_id') AND shop_id = ANY(sqlc.arg('shop_ids')::varchar[]);
+
+-- name: FilterFavouriteProducts :many
+SELECT product_id FROM favourite_products WHERE user_id = sqlc.arg('user_id') AND product_id = ANY(sqlc.arg('product_ids')::varchar[]);
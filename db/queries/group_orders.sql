@@ -0,0 +1,49 @@
+-- name: CreateGroupOrder :one
+INSERT INTO group_orders (
+    id,
+    host_id,
+    shop_id,
+    invite_code,
+    payment_mode,
+    deadline,
+    delivery_address,
+    note
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8) RETURNING *;
+
+-- name: GetGroupOrder :one
+SELECT * FROM group_orders WHERE id = $1 LIMIT 1;
+
+-- name: GetGroupOrderByInviteCode :one
+SELECT * FROM group_orders WHERE invite_code = $1 LIMIT 1;
+
+-- name: ListUserGroupOrders :many
+SELECT * FROM group_orders
+WHERE id IN (SELECT group_order_id FROM group_order_members WHERE user_id = $1)
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: SetGroupOrderStatus :one
+UPDATE group_orders SET status = sqlc.arg('to_status'), updated_at = now()
+WHERE id = sqlc.arg('id') AND status = sqlc.arg('from_status')
+RETURNING *;
+
+-- name: SubmitGroupOrder :one
+UPDATE group_orders SET status = 'submitted', order_id = $2, updated_at = now()
+WHERE id = $1 AND status IN ('open', 'locked')
+RETURNING *;
+
+-- name: LockExpiredGroupOrders :many
+UPDATE group_orders SET status = 'locked', updated_at = now()
+WHERE status = 'open' AND deadline <= $1
+RETURNING *;
+
+-- name: AddGroupOrderMember :exec
+INSERT INTO group_order_members (group_order_id, user_id) VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveGroupOrderMember :exec
+DELETE FROM group_order_members WHERE group_order_id = $1 AND user_id = $2;
+
+-- name: ListGroupOrderMembers :many
+SELECT * FROM group_order_members WHERE group_order_id = $1 ORDER BY joined_at;
@@ -0,0 +1,26 @@
+-- name: NextInvoiceNumber :one
+INSERT INTO shop_invoice_counters (
+    shop_id,
+    last_number
+) VALUES (
+    $1, 1)
+ON CONFLICT (shop_id) DO UPDATE
+SET last_number = shop_invoice_counters.last_number + 1
+RETURNING last_number;
+
+-- name: CreateOrderInvoice :one
+INSERT INTO order_invoices (
+    order_id,
+    shop_id,
+    number,
+    vat_basis_points,
+    prices_include_vat,
+    service_charge_basis_points,
+    taxable_amount,
+    vat,
+    service_charge
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING *;
+
+-- name: GetOrderInvoice :one
+SELECT * FROM order_invoices WHERE order_id = $1 LIMIT 1;
@@ -0,0 +1,64 @@
+-- Creates the user's wallet the first time it is asked for.
+-- name: GetOrCreateWallet :one
+INSERT INTO ledger_accounts (
+    id,
+    user_id,
+    kind
+) VALUES (
+    $1, $2, 'wallet')
+ON CONFLICT (user_id) DO UPDATE SET user_id = EXCLUDED.user_id
+RETURNING *;
+
+-- name: GetLedgerAccount :one
+SELECT * FROM ledger_accounts WHERE id = $1 LIMIT 1;
+
+-- name: CreateLedgerTransaction :one
+INSERT INTO ledger_transactions (
+    id,
+    kind,
+    description,
+    payment_id
+) VALUES (
+    $1, $2, $3, $4) RETURNING *;
+
+-- Moves an account's cached balance, locking the row until the transaction
+-- ends. A wallet that would go below zero fails its check constraint.
+-- name: AdjustLedgerBalance :one
+UPDATE ledger_accounts SET balance = balance + sqlc.arg('amount'), updated_at = now()
+WHERE id = sqlc.arg('id')
+RETURNING balance;
+
+-- name: CreateLedgerEntry :one
+INSERT INTO ledger_entries (
+    id,
+    transaction_id,
+    account_id,
+    amount,
+    balance_after
+) VALUES (
+    $1, $2, $3, $4, $5) RETURNING *;
+
+-- name: ListLedgerTransactionEntries :many
+SELECT * FROM ledger_entries WHERE transaction_id = $1 ORDER BY account_id;
+
+-- name: ListAccountStatement :many
+SELECT e.id, e.amount, e.balance_after, e.created_at, t.id AS transaction_id, t.kind, t.description, t.payment_id
+FROM ledger_entries e
+JOIN ledger_transactions t ON t.id = e.transaction_id
+WHERE e.account_id = $1
+ORDER BY e.created_at DESC, e.id DESC
+LIMIT $2 OFFSET $3;
+
+-- The balance worked out from the entries, to check the cached one.
+-- name: SumLedgerEntries :one
+SELECT COALESCE(sum(amount), 0)::bigint FROM ledger_entries WHERE account_id = $1;
+
+-- name: GetOrCreateShopAccount :one
+INSERT INTO ledger_accounts (
+    id,
+    shop_id,
+    kind
+) VALUES (
+    $1, $2, 'shop')
+ON CONFLICT (shop_id) DO UPDATE SET shop_id = EXCLUDED.shop_id
+RETURNING *;
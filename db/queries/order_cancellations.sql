@@ -0,0 +1,28 @@
+-- name: CreateOrderCancellation :one
+INSERT INTO order_cancellations (
+    order_id,
+    cancelled_by,
+    actor_role,
+    reason_code,
+    note,
+    fee,
+    refund_amount,
+    refund_to,
+    refund_status
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING *;
+
+-- name: GetOrderCancellation :one
+SELECT * FROM order_cancellations
+WHERE order_id = $1 LIMIT 1;
+
+-- name: ListPendingRefunds :many
+SELECT * FROM order_cancellations
+WHERE refund_status = 'pending'
+ORDER BY created_at
+LIMIT $1;
+
+-- name: UpdateRefundStatus :one
+UPDATE order_cancellations SET refund_status = sqlc.arg('to_status'), updated_at = now()
+WHERE order_id = sqlc.arg('order_id') AND refund_status = sqlc.arg('from_status')
+RETURNING *;
@@ -0,0 +1,109 @@
+-- name: CreateOrder :one
+INSERT INTO orders (
+    id,
+    user_id,
+    shop_id,
+    subtotal,
+    total,
+    delivery_address,
+    note,
+    status,
+    scheduled_for,
+    release_at,
+    group_order_id,
+    checkout_id,
+    delivery_fee,
+    service_charge,
+    vat,
+    tip,
+    discount,
+    coupon_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18) RETURNING *;
+
+-- name: CreateOrderItem :one
+INSERT INTO order_items (
+    id,
+    order_id,
+    product_id,
+    product_name,
+    unit_price,
+    quantity,
+    line_total,
+    note,
+    added_by,
+    vat,
+    vat_exempt
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING *;
+
+-- name: CreateOrderItemOption :one
+INSERT INTO order_item_options (
+    id,
+    order_item_id,
+    option_id,
+    group_name,
+    name,
+    price_delta
+) VALUES (
+    $1, $2, $3, $4, $5, $6) RETURNING *;
+
+-- name: GetOrder :one
+SELECT * FROM orders WHERE id = $1 LIMIT 1;
+
+-- name: ListUserOrders :many
+SELECT * FROM orders WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3;
+
+-- name: ListOrderItems :many
+SELECT * FROM order_items WHERE order_id = $1 ORDER BY id;
+
+-- name: ListOrderItemOptions :many
+SELECT * FROM order_item_options WHERE order_item_id = ANY(sqlc.arg('ids')::varchar[]) ORDER BY group_name, name;
+
+-- Shops and riders only see orders that have been paid for.
+-- name: ListShopOrders :many
+SELECT * FROM orders
+WHERE shop_id = sqlc.arg('shop_id')
+  AND payment_status NOT IN ('unpaid', 'failed')
+  AND (sqlc.arg('status')::varchar = '' OR status = sqlc.arg('status')::varchar)
+ORDER BY created_at DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListAvailableDeliveries :many
+SELECT * FROM orders
+WHERE status = 'ready' AND rider_id IS NULL AND payment_status NOT IN ('unpaid', 'failed')
+ORDER BY updated_at LIMIT $1 OFFSET $2;
+
+-- name: ListRiderOrders :many
+SELECT * FROM orders WHERE rider_id = $1 ORDER BY updated_at DESC LIMIT $2 OFFSET $3;
+
+-- name: UpdateOrderStatus :one
+UPDATE orders SET status = sqlc.arg('to_status'), updated_at = now()
+WHERE id = sqlc.arg('id') AND status = sqlc.arg('from_status')
+RETURNING *;
+
+-- name: AssignOrderRider :one
+UPDATE orders SET rider_id = $2, updated_at = now()
+WHERE id = $1 AND rider_id IS NULL
+RETURNING *;
+
+-- name: CreateOrderStatusHistory :one
+INSERT INTO order_status_history (
+    id,
+    order_id,
+    from_status,
+    to_status,
+    actor_id,
+    actor_role,
+    note
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7) RETURNING *;
+
+-- name: ListOrderStatusHistory :many
+SELECT * FROM order_status_history WHERE order_id = $1 ORDER BY created_at, id;
+
+-- Unpaid orders wait, and are released as soon as they are paid for.
+-- name: ListDueScheduledOrders :many
+SELECT * FROM orders
+WHERE status = 'scheduled' AND release_at <= $1 AND payment_status IN ('paid', 'partially_refunded')
+ORDER BY release_at LIMIT $2;
@@ -0,0 +1,82 @@
+-- name: CreatePayment :one
+INSERT INTO payments (
+    id,
+    reference,
+    user_id,
+    checkout_id,
+    order_id,
+    tip_id,
+    wallet_account_id,
+    provider,
+    amount,
+    currency
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING *;
+
+-- name: SetPaymentAuthorization :one
+UPDATE payments SET authorization_url = $2, updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetPayment :one
+SELECT * FROM payments WHERE id = $1 LIMIT 1;
+
+-- name: GetPaymentByReference :one
+SELECT * FROM payments WHERE reference = $1 LIMIT 1;
+
+-- name: ListUserPayments :many
+SELECT * FROM payments WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3;
+
+-- Only a pending payment can be settled, so a webhook and the customer
+-- checking at the same time settle it once between them.
+-- name: SettlePayment :one
+UPDATE payments SET
+    status = sqlc.arg('status'),
+    provider_reference = sqlc.arg('provider_reference'),
+    channel = sqlc.arg('channel'),
+    failure_reason = sqlc.arg('failure_reason'),
+    paid_at = sqlc.narg('paid_at'),
+    updated_at = now()
+WHERE id = sqlc.arg('id') AND status = 'pending'
+RETURNING *;
+
+-- name: CountSucceededPayments :one
+SELECT count(*) FROM payments
+WHERE status = 'succeeded'
+  AND (checkout_id = sqlc.narg('checkout_id') OR order_id = sqlc.narg('order_id') OR tip_id = sqlc.narg('tip_id'));
+
+-- The newest payment still waiting on the customer for a checkout, order
+-- or tip.
+-- name: GetPendingPayment :one
+SELECT * FROM payments
+WHERE status = 'pending'
+  AND (checkout_id = sqlc.narg('checkout_id') OR order_id = sqlc.narg('order_id') OR tip_id = sqlc.narg('tip_id'))
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: FlagDuplicatePayment :one
+UPDATE payments SET duplicate = true, updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- The payment an order was paid with, whether it went through the order's
+-- checkout or the order itself. Duplicates are only there to be refunded.
+-- name: GetOrderPayment :one
+SELECT p.* FROM payments p
+JOIN orders o ON p.checkout_id = o.checkout_id OR p.order_id = o.id
+WHERE o.id = $1 AND p.status = 'succeeded' AND NOT p.duplicate
+ORDER BY p.paid_at DESC
+LIMIT 1;
+
+-- Only orders still waiting on a payment take its outcome; a late result
+-- must not undo a refund. Orders called off before the payment settled are
+-- left for refundCancelledOrders.
+-- name: SetCheckoutPaymentStatus :exec
+UPDATE orders SET payment_status = $2, updated_at = now()
+WHERE checkout_id = $1 AND payment_status IN ('unpaid', 'failed')
+  AND status NOT IN ('cancelled', 'rejected');
+
+-- Like SetCheckoutPaymentStatus, for an order paid for on its own.
+-- name: SetOrderPaymentStatus :exec
+UPDATE orders SET payment_status = $2, updated_at = now()
+WHERE id = $1 AND payment_status IN ('unpaid', 'failed');
@@ -0,0 +1,21 @@
+-- name: CreateProductOption :one
+INSERT INTO product_options (
+    id,
+    product_id,
+    group_name,
+    name,
+    price_delta
+) VALUES (
+    $1, $2, $3, $4, $5) RETURNING *;
+
+-- name: ListProductOptions :many
+SELECT * FROM product_options WHERE product_id = $1 ORDER BY group_name, created_at;
+
+-- name: ListProductOptionsByIDs :many
+SELECT * FROM product_options WHERE id = ANY(sqlc.arg('ids')::varchar[]);
+
+-- name: UpdateProductOptionAvailability :one
+UPDATE product_options SET is_available = $3 WHERE id = $1 AND product_id = $2 RETURNING *;
+
+-- name: DeleteProductOption :exec
+DELETE FROM product_options WHERE id = $1 AND product_id = $2;
@@ -0,0 +1,49 @@
+-- name: CreateProduct :one
+INSERT INTO products (
+    id,
+    shop_id,
+    name,
+    description,
+    category,
+    price,
+    image_urls,
+    dietary_tags,
+    spice_level,
+    allergens
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING *;
+
+-- name: GetProduct :one
+SELECT * FROM products WHERE id = $1;
+
+-- name: ListProducts :many
+SELECT * FROM products
+WHERE (sqlc.narg('shop_id')::varchar IS NULL OR shop_id = sqlc.narg('shop_id')::varchar)
+  AND (sqlc.narg('category')::varchar IS NULL OR category = sqlc.narg('category')::varchar)
+  AND (sqlc.narg('search')::text IS NULL OR name ILIKE '%' || sqlc.narg('search')::text || '%' OR description ILIKE '%' || sqlc.narg('search')::text || '%')
+  AND dietary_tags @> sqlc.arg('dietary_tags')::text[]
+  AND NOT (allergens && sqlc.arg('exclude_allergens')::text[])
+  AND spice_level <= sqlc.arg('max_spice_level')::smallint
+  AND (NOT sqlc.arg('only_available')::boolean OR is_available)
+ORDER BY created_at DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: UpdateProduct :one
+UPDATE products SET
+    name = $2,
+    description = $3,
+    category = $4,
+    price = $5,
+    image_urls = $6,
+    is_available = $7,
+    dietary_tags = $8,
+    spice_level = $9,
+    allergens = $10,
+    updated_at = now()
+WHERE id = $1 RETURNING *;
+
+-- name: DeleteProduct :exec
+DELETE FROM products WHERE id = $1;
+
+-- name: ListProductsByIDs :many
+SELECT * FROM products WHERE id = ANY(sqlc.arg('ids')::varchar[]);
@@ -0,0 +1,100 @@
+-- name: CreateRefund :one
+INSERT INTO refunds (
+    id,
+    order_id,
+    payment_id,
+    source,
+    requested_by,
+    reason,
+    amount,
+    tip_amount,
+    refund_to,
+    status
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING *;
+
+-- name: CreateRefundItem :one
+INSERT INTO refund_items (
+    refund_id,
+    order_item_id,
+    quantity,
+    amount
+) VALUES (
+    $1, $2, $3, $4) RETURNING *;
+
+-- Taken before working out what is left to refund, so two refunds for the
+-- same order cannot both claim the same money.
+-- name: LockOrder :one
+SELECT * FROM orders WHERE id = $1 FOR UPDATE;
+
+-- name: GetRefund :one
+SELECT * FROM refunds WHERE id = $1 LIMIT 1;
+
+-- name: ListOrderRefunds :many
+SELECT * FROM refunds WHERE order_id = $1 ORDER BY created_at, id;
+
+-- name: ListRefundItems :many
+SELECT * FROM refund_items WHERE refund_id = $1 ORDER BY order_item_id;
+
+-- name: ListRefunds :many
+SELECT * FROM refunds
+WHERE sqlc.arg('status')::varchar = '' OR status = sqlc.arg('status')::varchar
+ORDER BY created_at DESC, id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- Refunds that were turned down or failed never gave anything back, so they
+-- do not count against what is left to refund. Neither do refunds of
+-- duplicate payments, which give back money the order never needed.
+-- name: GetOrderRefundTotals :one
+SELECT
+    COALESCE(sum(amount) FILTER (WHERE status NOT IN ('rejected', 'failed')), 0)::bigint AS committed,
+    COALESCE(sum(amount) FILTER (WHERE status = 'refunded'), 0)::bigint AS refunded
+FROM refunds
+WHERE order_id = $1 AND source <> 'duplicate';
+
+-- name: ListRefundedQuantities :many
+SELECT ri.order_item_id, sum(ri.quantity)::bigint AS quantity
+FROM refund_items ri
+JOIN refunds r ON r.id = ri.refund_id
+WHERE r.order_id = $1 AND r.status NOT IN ('rejected', 'failed')
+GROUP BY ri.order_item_id;
+
+-- name: ReviewRefund :one
+UPDATE refunds SET status = sqlc.arg('status'), reviewed_by = sqlc.arg('reviewed_by'), updated_at = now()
+WHERE id = sqlc.arg('id') AND status = 'awaiting_approval'
+RETURNING *;
+
+-- name: SetRefundStatus :one
+UPDATE refunds SET
+    status = sqlc.arg('to_status'),
+    provider_reference = CASE WHEN sqlc.arg('provider_reference')::varchar = '' THEN provider_reference ELSE sqlc.arg('provider_reference')::varchar END,
+    failure_reason = sqlc.arg('failure_reason'),
+    updated_at = now()
+WHERE id = sqlc.arg('id') AND status = sqlc.arg('from_status')
+RETURNING *;
+
+-- Takes a processing refund for sending again. Whoever read it after the
+-- claim finds updated_at moved on and leaves it alone.
+-- name: ClaimRefund :one
+UPDATE refunds SET updated_at = now()
+WHERE id = $1 AND status = 'processing' AND updated_at = $2
+RETURNING *;
+
+-- name: ListRefundsByStatus :many
+SELECT * FROM refunds
+WHERE status = $1
+ORDER BY updated_at
+LIMIT $2;
+
+-- name: AdjustPaymentRefunded :one
+UPDATE payments SET refunded_amount = refunded_amount + sqlc.arg('amount'), updated_at = now()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: SetOrderRefundStatus :exec
+UPDATE orders SET payment_status = $2, updated_at = now()
+WHERE id = $1 AND payment_status IN ('paid', 'partially_refunded', 'refunded');
+
+-- name: RefundOrderTips :exec
+UPDATE order_tips SET status = 'refunded'
+WHERE order_id = $1 AND stage = 'checkout' AND status = 'captured';
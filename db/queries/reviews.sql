@@ -0,0 +1,85 @@
+-- name: CreateReview :one
+INSERT INTO reviews (
+    id,
+    user_id,
+    shop_id,
+    order_id,
+    shop_rating,
+    delivery_rating,
+    comment
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7) RETURNING *;
+
+-- name: CreateReviewItem :one
+INSERT INTO review_items (
+    id,
+    review_id,
+    product_id,
+    rating,
+    comment
+) VALUES (
+    $1, $2, $3, $4, $5) RETURNING *;
+
+-- name: GetReview :one
+SELECT * FROM reviews WHERE id = $1;
+
+-- name: ListShopReviews :many
+SELECT * FROM reviews WHERE shop_id = $1 AND status = 'published' ORDER BY created_at DESC LIMIT $2 OFFSET $3;
+
+-- name: ListReviewItems :many
+SELECT * FROM review_items WHERE review_id = $1;
+
+-- name: ListProductReviews :many
+SELECT ri.id, ri.review_id, ri.rating, ri.comment, r.user_id, r.vendor_reply, r.created_at
+FROM review_items ri
+JOIN reviews r ON r.id = ri.review_id
+WHERE ri.product_id = $1 AND r.status = 'published'
+ORDER BY r.created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: ReplyToReview :one
+UPDATE reviews SET vendor_reply = $2, replied_at = now(), updated_at = now() WHERE id = $1 RETURNING *;
+
+-- name: SetReviewStatus :one
+UPDATE reviews SET status = $2, updated_at = now() WHERE id = $1 RETURNING *;
+
+-- name: CreateReviewReport :one
+INSERT INTO review_reports (
+    id,
+    review_id,
+    reporter_id,
+    reason
+) VALUES (
+    $1, $2, $3, $4) RETURNING *;
+
+-- name: ListReportedReviews :many
+SELECT r.id, r.user_id, r.shop_id, r.shop_rating, r.comment, r.status, r.created_at,
+    count(rr.id)::int AS report_count,
+    max(rr.created_at)::timestamptz AS last_reported_at
+FROM reviews r
+JOIN review_reports rr ON rr.review_id = r.id AND NOT rr.resolved
+GROUP BY r.id
+ORDER BY report_count DESC, last_reported_at
+LIMIT $1 OFFSET $2;
+
+-- name: ListReviewReports :many
+SELECT * FROM review_reports WHERE review_id = $1 ORDER BY created_at;
+
+-- name: ResolveReviewReports :exec
+UPDATE review_reports SET resolved = true WHERE review_id = $1 AND NOT resolved;
+
+-- name: RefreshShopRating :exec
+UPDATE shops SET
+    rating_count = (SELECT count(*) FROM reviews WHERE reviews.shop_id = shops.id AND reviews.status = 'published'),
+    rating_avg = (SELECT COALESCE(round(avg(shop_rating), 2), 0) FROM reviews WHERE reviews.shop_id = shops.id AND reviews.status = 'published')
+WHERE id = $1;
+
+-- name: RefreshReviewProductRatings :exec
+UPDATE products SET
+    rating_count = (
+        SELECT count(*) FROM review_items ri JOIN reviews r ON r.id = ri.review_id
+        WHERE ri.product_id = products.id AND r.status = 'published'),
+    rating_avg = (
+        SELECT COALESCE(round(avg(ri.rating), 2), 0) FROM review_items ri JOIN reviews r ON r.id = ri.review_id
+        WHERE ri.product_id = products.id AND r.status = 'published')
+WHERE id IN (SELECT product_id FROM review_items WHERE review_items.review_id = $1);
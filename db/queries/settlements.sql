@@ -0,0 +1,157 @@
+-- name: CreateVendorEarning :one
+INSERT INTO vendor_earnings (
+    order_id,
+    shop_id,
+    gross,
+    commission,
+    processing_fee,
+    net,
+    commission_percent,
+    processing_fee_basis_points
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8) RETURNING *;
+
+-- name: GetVendorEarning :one
+SELECT * FROM vendor_earnings WHERE order_id = $1 LIMIT 1;
+
+-- Shops with earnings from before the given time that are not in a
+-- settlement yet, with the oldest of them.
+-- name: ListUnsettledShops :many
+SELECT shop_id, min(earned_at)::timestamptz AS first_earned_at
+FROM vendor_earnings
+WHERE settlement_id IS NULL AND earned_at < sqlc.arg('before')
+GROUP BY shop_id
+ORDER BY first_earned_at
+LIMIT sqlc.arg('limit');
+
+-- name: CreateSettlement :one
+INSERT INTO settlements (
+    id,
+    shop_id,
+    period_start,
+    period_end
+) VALUES (
+    $1, $2, $3, $4) RETURNING *;
+
+-- name: GetShopSettlementForPeriod :one
+SELECT * FROM settlements WHERE shop_id = $1 AND period_start = $2 LIMIT 1;
+
+-- name: AttachSettlementEarnings :many
+UPDATE vendor_earnings SET settlement_id = sqlc.arg('settlement_id')
+WHERE shop_id = sqlc.arg('shop_id')
+  AND settlement_id IS NULL
+  AND earned_at >= sqlc.arg('period_start')
+  AND earned_at < sqlc.arg('period_end')
+RETURNING *;
+
+-- Adds earnings to a settlement's totals: all of them when it is closed,
+-- and any that arrive for its week afterwards.
+-- name: AddSettlementTotals :one
+UPDATE settlements SET
+    order_count = order_count + sqlc.arg('order_count'),
+    gross = gross + sqlc.arg('gross'),
+    commission = commission + sqlc.arg('commission'),
+    processing_fee = processing_fee + sqlc.arg('processing_fee'),
+    net = net + sqlc.arg('net')
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: GetSettlement :one
+SELECT * FROM settlements WHERE id = $1 LIMIT 1;
+
+-- name: ListShopSettlements :many
+SELECT * FROM settlements WHERE shop_id = $1 ORDER BY period_start DESC LIMIT $2 OFFSET $3;
+
+-- name: ListSettlementEarnings :many
+SELECT * FROM vendor_earnings WHERE settlement_id = $1 ORDER BY earned_at, order_id;
+
+-- name: ListSettlements :many
+SELECT * FROM settlements
+WHERE (sqlc.arg('shop_id')::varchar = '' OR shop_id = sqlc.arg('shop_id')::varchar)
+  AND period_start >= sqlc.arg('from_time')
+  AND period_start < sqlc.arg('to_time')
+ORDER BY period_start DESC, shop_id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- Totals for every settlement whose period starts in the range.
+-- name: GetSettlementReport :one
+SELECT
+    count(*)::bigint AS settlements,
+    COALESCE(sum(order_count), 0)::bigint AS orders,
+    COALESCE(sum(gross), 0)::bigint AS gross,
+    COALESCE(sum(commission), 0)::bigint AS commission,
+    COALESCE(sum(processing_fee), 0)::bigint AS processing_fee,
+    COALESCE(sum(net), 0)::bigint AS net
+FROM settlements
+WHERE (sqlc.arg('shop_id')::varchar = '' OR shop_id = sqlc.arg('shop_id')::varchar)
+  AND period_start >= sqlc.arg('from_time')
+  AND period_start < sqlc.arg('to_time');
+
+-- What a shop has earned that is still waiting for its settlement.
+-- name: GetUnsettledEarnings :one
+SELECT count(*)::bigint AS orders, COALESCE(sum(net), 0)::bigint AS net
+FROM vendor_earnings
+WHERE shop_id = $1 AND settlement_id IS NULL;
+
+-- name: UpsertShopBankAccount :one
+INSERT INTO shop_bank_accounts (
+    shop_id,
+    bank_code,
+    account_number,
+    account_name,
+    provider,
+    recipient_code
+) VALUES (
+    $1, $2, $3, $4, $5, $6)
+ON CONFLICT (shop_id) DO UPDATE SET
+    bank_code = EXCLUDED.bank_code,
+    account_number = EXCLUDED.account_number,
+    account_name = EXCLUDED.account_name,
+    provider = EXCLUDED.provider,
+    recipient_code = EXCLUDED.recipient_code,
+    verified_at = now(),
+    updated_at = now()
+RETURNING *;
+
+-- name: GetShopBankAccount :one
+SELECT * FROM shop_bank_accounts WHERE shop_id = $1 LIMIT 1;
+
+-- name: CreatePayout :one
+INSERT INTO payouts (
+    id,
+    shop_id,
+    requested_by,
+    amount,
+    bank_code,
+    account_number,
+    account_name,
+    provider
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8) RETURNING *;
+
+-- A payout only moves forward from pending or processing, so a failure is
+-- reversed once.
+-- name: UpdatePayoutStatus :one
+UPDATE payouts SET
+    status = sqlc.arg('status'),
+    provider_reference = CASE WHEN sqlc.arg('provider_reference')::varchar = '' THEN provider_reference ELSE sqlc.arg('provider_reference')::varchar END,
+    failure_reason = sqlc.arg('failure_reason'),
+    updated_at = now()
+WHERE id = sqlc.arg('id') AND status IN ('pending', 'processing')
+RETURNING *;
+
+-- name: GetPayout :one
+SELECT * FROM payouts WHERE id = $1 LIMIT 1;
+
+-- name: ListShopPayouts :many
+SELECT * FROM payouts WHERE shop_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3;
+
+-- name: ListProcessingPayouts :many
+SELECT * FROM payouts WHERE status = 'processing' ORDER BY updated_at LIMIT $1;
+
+-- name: GetPaidOutTotal :one
+SELECT COALESCE(sum(amount), 0)::bigint FROM payouts
+WHERE (sqlc.arg('shop_id')::varchar = '' OR shop_id = sqlc.arg('shop_id')::varchar)
+  AND status = 'paid'
+  AND created_at >= sqlc.arg('from_time')
+  AND created_at < sqlc.arg('to_time');
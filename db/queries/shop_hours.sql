@@ -0,0 +1,14 @@
+-- name: CreateShopHours :one
+INSERT INTO shop_hours (
+    shop_id,
+    weekday,
+    opens_minute,
+    closes_minute
+) VALUES (
+    $1, $2, $3, $4) RETURNING *;
+
+-- name: ListShopHours :many
+SELECT * FROM shop_hours WHERE shop_id = $1 ORDER BY weekday, opens_minute;
+
+-- name: DeleteShopHours :exec
+DELETE FROM shop_hours WHERE shop_id = $1;
@@ -0,0 +1,35 @@
+-- name: CreateShop :one
+INSERT INTO shops (
+    id,
+    owner_id,
+    name,
+    description,
+    address,
+    phone,
+    image_url
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7) RETURNING *;
+
+-- name: GetShop :one
+SELECT * FROM shops WHERE id = $1;
+
+-- name: ListShops :many
+SELECT * FROM shops
+WHERE (sqlc.narg('search')::text IS NULL OR name ILIKE '%' || sqlc.narg('search')::text || '%')
+ORDER BY name
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListShopsByOwner :many
+SELECT * FROM shops WHERE owner_id = $1 ORDER BY created_at;
+
+-- name: UpdateShop :one
+UPDATE shops SET name = $2, description = $3, address = $4, phone = $5, image_url = $6, is_open = $7, updated_at = now() WHERE id = $1 RETURNING *;
+
+-- name: DeleteShop :exec
+DELETE FROM shops WHERE id = $1;
+
+-- name: UpdateShopLeadTime :one
+UPDATE shops SET lead_time_minutes = $2, updated_at = now() WHERE id = $1 RETURNING *;
+
+-- name: UpdateShopServiceCharge :one
+UPDATE shops SET service_charge_basis_points = $2, updated_at = now() WHERE id = $1 RETURNING *;
@@ -0,0 +1,50 @@
+-- name: CreateOrderTip :one
+INSERT INTO order_tips (
+    id,
+    order_id,
+    user_id,
+    amount,
+    stage
+) VALUES (
+    $1, $2, $3, $4, $5) RETURNING *;
+
+-- name: ListOrderTips :many
+SELECT * FROM order_tips WHERE order_id = $1 ORDER BY created_at, id;
+
+-- name: GetOrderDeliveredAt :one
+SELECT created_at FROM order_status_history
+WHERE order_id = $1 AND to_status = 'delivered'
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- Orders count towards the period they were delivered in. Only captured
+-- tips have been paid; pending ones may never be.
+-- name: GetRiderEarnings :one
+SELECT
+    count(*)::bigint AS deliveries,
+    COALESCE(sum(o.delivery_fee), 0)::bigint AS delivery_fees,
+    COALESCE(sum((
+        SELECT sum(t.amount) FROM order_tips t
+        WHERE t.order_id = o.id AND t.status = 'captured'
+    )), 0)::bigint AS tips
+FROM orders o
+JOIN order_status_history h ON h.order_id = o.id AND h.to_status = 'delivered'
+WHERE o.rider_id = sqlc.arg('rider_id')::varchar
+  AND o.status = 'delivered'
+  AND h.created_at >= sqlc.arg('from_time')
+  AND h.created_at < sqlc.arg('to_time');
+
+-- name: GetOrderTip :one
+SELECT * FROM order_tips WHERE id = $1 LIMIT 1;
+
+-- Taken before paying a tip from a wallet, like LockCheckout.
+-- name: LockOrderTip :one
+SELECT * FROM order_tips WHERE id = $1 FOR UPDATE;
+
+-- name: CaptureCheckoutTips :exec
+UPDATE order_tips SET status = 'captured'
+WHERE stage = 'checkout' AND status = 'pending'
+  AND order_id IN (SELECT id FROM orders WHERE checkout_id = sqlc.arg('checkout_id')::varchar);
+
+-- name: SetTipStatus :exec
+UPDATE order_tips SET status = $2 WHERE id = $1 AND status = 'pending';
@@ -29,4 +29,7 @@ UPDATE users SET address = $4, phone = $3, email = $2, updated_at = $5 WHERE id
 DELETE FROM users WHERE id = $1;
 
 -- name: DeleteAllUsers :exec
-DELETE FROM users;
\ No newline at end of file
+DELETE FROM users;
+
+-- name: UpdateUserRole :one
+UPDATE users SET role = $2, updated_at = now() WHERE id = $1 RETURNING *;
@@ -5,9 +5,423 @@
 package db
 
 import (
+	"database/sql"
 	"time"
+
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
 )
 
+type AllergenProfile struct {
+	UserID     string    `json:"user_id"`
+	Allergens  []string  `json:"allergens"`
+	HideUnsafe bool      `json:"hide_unsafe"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type Checkout struct {
+	ID              string         `json:"id"`
+	UserID          string         `json:"user_id"`
+	Subtotal        utils.Money    `json:"subtotal"`
+	DeliveryFee     utils.Money    `json:"delivery_fee"`
+	Total           utils.Money    `json:"total"`
+	DeliveryAddress string         `json:"delivery_address"`
+	Note            string         `json:"note"`
+	CreatedAt       time.Time      `json:"created_at"`
+	ServiceCharge   utils.Money    `json:"service_charge"`
+	Vat             utils.Money    `json:"vat"`
+	Tip             utils.Money    `json:"tip"`
+	Discount        utils.Money    `json:"discount"`
+	CouponID        sql.NullString `json:"coupon_id"`
+}
+
+type Coupon struct {
+	ID              string         `json:"id"`
+	Code            string         `json:"code"`
+	Description     string         `json:"description"`
+	Kind            string         `json:"kind"`
+	PercentOff      int32          `json:"percent_off"`
+	AmountOff       utils.Money    `json:"amount_off"`
+	MaxDiscount     utils.Money    `json:"max_discount"`
+	MinOrder        utils.Money    `json:"min_order"`
+	StartsAt        sql.NullTime   `json:"starts_at"`
+	EndsAt          sql.NullTime   `json:"ends_at"`
+	MaxRedemptions  sql.NullInt32  `json:"max_redemptions"`
+	MaxPerUser      sql.NullInt32  `json:"max_per_user"`
+	FirstOrderOnly  bool           `json:"first_order_only"`
+	ShopID          sql.NullString `json:"shop_id"`
+	Categories      []string       `json:"categories"`
+	IsActive        bool           `json:"is_active"`
+	RedemptionCount int32          `json:"redemption_count"`
+	CreatedBy       sql.NullString `json:"created_by"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+type CouponRedemption struct {
+	ID         string       `json:"id"`
+	CouponID   string       `json:"coupon_id"`
+	UserID     string       `json:"user_id"`
+	CheckoutID string       `json:"checkout_id"`
+	Discount   utils.Money  `json:"discount"`
+	ReleasedAt sql.NullTime `json:"released_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type FavouriteProduct struct {
+	UserID    string    `json:"user_id"`
+	ProductID string    `json:"product_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FavouriteShop struct {
+	UserID    string    `json:"user_id"`
+	ShopID    string    `json:"shop_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GroupOrder struct {
+	ID              string         `json:"id"`
+	HostID          string         `json:"host_id"`
+	ShopID          string         `json:"shop_id"`
+	InviteCode      string         `json:"invite_code"`
+	Status          string         `json:"status"`
+	PaymentMode     string         `json:"payment_mode"`
+	Deadline        time.Time      `json:"deadline"`
+	DeliveryAddress string         `json:"delivery_address"`
+	Note            string         `json:"note"`
+	OrderID         sql.NullString `json:"order_id"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+type GroupOrderMember struct {
+	GroupOrderID string    `json:"group_order_id"`
+	UserID       string    `json:"user_id"`
+	JoinedAt     time.Time `json:"joined_at"`
+}
+
+type LedgerAccount struct {
+	ID        string         `json:"id"`
+	UserID    sql.NullString `json:"user_id"`
+	Kind      string         `json:"kind"`
+	Currency  string         `json:"currency"`
+	Balance   utils.Money    `json:"balance"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	ShopID    sql.NullString `json:"shop_id"`
+}
+
+type LedgerEntry struct {
+	ID            string      `json:"id"`
+	TransactionID string      `json:"transaction_id"`
+	AccountID     string      `json:"account_id"`
+	Amount        utils.Money `json:"amount"`
+	BalanceAfter  utils.Money `json:"balance_after"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+type LedgerTransaction struct {
+	ID          string         `json:"id"`
+	Kind        string         `json:"kind"`
+	Description string         `json:"description"`
+	PaymentID   sql.NullString `json:"payment_id"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+type Order struct {
+	ID              string         `json:"id"`
+	UserID          string         `json:"user_id"`
+	ShopID          string         `json:"shop_id"`
+	Status          string         `json:"status"`
+	Subtotal        utils.Money    `json:"subtotal"`
+	Total           utils.Money    `json:"total"`
+	DeliveryAddress string         `json:"delivery_address"`
+	Note            string         `json:"note"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	RiderID         sql.NullString `json:"rider_id"`
+	ScheduledFor    sql.NullTime   `json:"scheduled_for"`
+	ReleaseAt       sql.NullTime   `json:"release_at"`
+	GroupOrderID    sql.NullString `json:"group_order_id"`
+	CheckoutID      sql.NullString `json:"checkout_id"`
+	DeliveryFee     utils.Money    `json:"delivery_fee"`
+	ServiceCharge   utils.Money    `json:"service_charge"`
+	Vat             utils.Money    `json:"vat"`
+	Tip             utils.Money    `json:"tip"`
+	PaymentStatus   string         `json:"payment_status"`
+	Discount        utils.Money    `json:"discount"`
+	CouponID        sql.NullString `json:"coupon_id"`
+}
+
+type OrderCancellation struct {
+	OrderID      string         `json:"order_id"`
+	CancelledBy  sql.NullString `json:"cancelled_by"`
+	ActorRole    string         `json:"actor_role"`
+	ReasonCode   string         `json:"reason_code"`
+	Note         string         `json:"note"`
+	Fee          utils.Money    `json:"fee"`
+	RefundAmount utils.Money    `json:"refund_amount"`
+	RefundTo     string         `json:"refund_to"`
+	RefundStatus string         `json:"refund_status"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+type OrderDelivery struct {
+	OrderID              string          `json:"order_id"`
+	Latitude             sql.NullFloat64 `json:"latitude"`
+	Longitude            sql.NullFloat64 `json:"longitude"`
+	DistanceMeters       sql.NullInt32   `json:"distance_meters"`
+	DistanceFee          utils.Money     `json:"distance_fee"`
+	SmallOrderFee        utils.Money     `json:"small_order_fee"`
+	SurgePercent         int32           `json:"surge_percent"`
+	SurgeFee             utils.Money     `json:"surge_fee"`
+	FreeDeliveryDiscount utils.Money     `json:"free_delivery_discount"`
+	Fee                  utils.Money     `json:"fee"`
+	CreatedAt            time.Time       `json:"created_at"`
+}
+
+type OrderInvoice struct {
+	OrderID                  string      `json:"order_id"`
+	ShopID                   string      `json:"shop_id"`
+	Number                   int64       `json:"number"`
+	VatBasisPoints           int32       `json:"vat_basis_points"`
+	PricesIncludeVat         bool        `json:"prices_include_vat"`
+	ServiceChargeBasisPoints int32       `json:"service_charge_basis_points"`
+	TaxableAmount            utils.Money `json:"taxable_amount"`
+	Vat                      utils.Money `json:"vat"`
+	ServiceCharge            utils.Money `json:"service_charge"`
+	IssuedAt                 time.Time   `json:"issued_at"`
+}
+
+type OrderItem struct {
+	ID          string         `json:"id"`
+	OrderID     string         `json:"order_id"`
+	ProductID   sql.NullString `json:"product_id"`
+	ProductName string         `json:"product_name"`
+	UnitPrice   utils.Money    `json:"unit_price"`
+	Quantity    int32          `json:"quantity"`
+	LineTotal   utils.Money    `json:"line_total"`
+	Note        string         `json:"note"`
+	AddedBy     sql.NullString `json:"added_by"`
+	Vat         utils.Money    `json:"vat"`
+	VatExempt   bool           `json:"vat_exempt"`
+}
+
+type OrderItemOption struct {
+	ID          string         `json:"id"`
+	OrderItemID string         `json:"order_item_id"`
+	OptionID    sql.NullString `json:"option_id"`
+	GroupName   string         `json:"group_name"`
+	Name        string         `json:"name"`
+	PriceDelta  utils.Money    `json:"price_delta"`
+}
+
+type OrderStatusHistory struct {
+	ID         string         `json:"id"`
+	OrderID    string         `json:"order_id"`
+	FromStatus sql.NullString `json:"from_status"`
+	ToStatus   string         `json:"to_status"`
+	ActorID    sql.NullString `json:"actor_id"`
+	ActorRole  string         `json:"actor_role"`
+	Note       string         `json:"note"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+type OrderTip struct {
+	ID        string      `json:"id"`
+	OrderID   string      `json:"order_id"`
+	UserID    string      `json:"user_id"`
+	Amount    utils.Money `json:"amount"`
+	Stage     string      `json:"stage"`
+	Status    string      `json:"status"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type Payment struct {
+	ID                string         `json:"id"`
+	Reference         string         `json:"reference"`
+	UserID            string         `json:"user_id"`
+	CheckoutID        sql.NullString `json:"checkout_id"`
+	OrderID           sql.NullString `json:"order_id"`
+	TipID             sql.NullString `json:"tip_id"`
+	Provider          string         `json:"provider"`
+	ProviderReference string         `json:"provider_reference"`
+	Amount            utils.Money    `json:"amount"`
+	Currency          string         `json:"currency"`
+	Status            string         `json:"status"`
+	Channel           string         `json:"channel"`
+	AuthorizationUrl  string         `json:"authorization_url"`
+	FailureReason     string         `json:"failure_reason"`
+	PaidAt            sql.NullTime   `json:"paid_at"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	WalletAccountID   sql.NullString `json:"wallet_account_id"`
+	RefundedAmount    utils.Money    `json:"refunded_amount"`
+	Duplicate         bool           `json:"duplicate"`
+}
+
+type Payout struct {
+	ID                string         `json:"id"`
+	ShopID            string         `json:"shop_id"`
+	RequestedBy       sql.NullString `json:"requested_by"`
+	Amount            utils.Money    `json:"amount"`
+	BankCode          string         `json:"bank_code"`
+	AccountNumber     string         `json:"account_number"`
+	AccountName       string         `json:"account_name"`
+	Provider          string         `json:"provider"`
+	ProviderReference string         `json:"provider_reference"`
+	Status            string         `json:"status"`
+	FailureReason     string         `json:"failure_reason"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+}
+
+type Product struct {
+	ID          string      `json:"id"`
+	ShopID      string      `json:"shop_id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Category    string      `json:"category"`
+	Price       utils.Money `json:"price"`
+	ImageUrls   []string    `json:"image_urls"`
+	IsAvailable bool        `json:"is_available"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	DietaryTags []string    `json:"dietary_tags"`
+	SpiceLevel  int16       `json:"spice_level"`
+	Allergens   []string    `json:"allergens"`
+	RatingAvg   string      `json:"rating_avg"`
+	RatingCount int32       `json:"rating_count"`
+}
+
+type ProductOption struct {
+	ID          string      `json:"id"`
+	ProductID   string      `json:"product_id"`
+	GroupName   string      `json:"group_name"`
+	Name        string      `json:"name"`
+	PriceDelta  utils.Money `json:"price_delta"`
+	IsAvailable bool        `json:"is_available"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+type Refund struct {
+	ID                string         `json:"id"`
+	OrderID           string         `json:"order_id"`
+	PaymentID         string         `json:"payment_id"`
+	Source            string         `json:"source"`
+	RequestedBy       sql.NullString `json:"requested_by"`
+	ReviewedBy        sql.NullString `json:"reviewed_by"`
+	Reason            string         `json:"reason"`
+	Amount            utils.Money    `json:"amount"`
+	TipAmount         utils.Money    `json:"tip_amount"`
+	RefundTo          string         `json:"refund_to"`
+	Status            string         `json:"status"`
+	ProviderReference string         `json:"provider_reference"`
+	FailureReason     string         `json:"failure_reason"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+}
+
+type RefundItem struct {
+	RefundID    string      `json:"refund_id"`
+	OrderItemID string      `json:"order_item_id"`
+	Quantity    int32       `json:"quantity"`
+	Amount      utils.Money `json:"amount"`
+}
+
+type Review struct {
+	ID             string         `json:"id"`
+	UserID         string         `json:"user_id"`
+	ShopID         string         `json:"shop_id"`
+	OrderID        sql.NullString `json:"order_id"`
+	ShopRating     int16          `json:"shop_rating"`
+	DeliveryRating sql.NullInt16  `json:"delivery_rating"`
+	Comment        string         `json:"comment"`
+	VendorReply    sql.NullString `json:"vendor_reply"`
+	RepliedAt      sql.NullTime   `json:"replied_at"`
+	Status         string         `json:"status"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+type ReviewItem struct {
+	ID        string `json:"id"`
+	ReviewID  string `json:"review_id"`
+	ProductID string `json:"product_id"`
+	Rating    int16  `json:"rating"`
+	Comment   string `json:"comment"`
+}
+
+type ReviewReport struct {
+	ID         string    `json:"id"`
+	ReviewID   string    `json:"review_id"`
+	ReporterID string    `json:"reporter_id"`
+	Reason     string    `json:"reason"`
+	Resolved   bool      `json:"resolved"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type Settlement struct {
+	ID            string      `json:"id"`
+	ShopID        string      `json:"shop_id"`
+	PeriodStart   time.Time   `json:"period_start"`
+	PeriodEnd     time.Time   `json:"period_end"`
+	OrderCount    int32       `json:"order_count"`
+	Gross         utils.Money `json:"gross"`
+	Commission    utils.Money `json:"commission"`
+	ProcessingFee utils.Money `json:"processing_fee"`
+	Net           utils.Money `json:"net"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+type Shop struct {
+	ID                       string    `json:"id"`
+	OwnerID                  string    `json:"owner_id"`
+	Name                     string    `json:"name"`
+	Description              string    `json:"description"`
+	Address                  string    `json:"address"`
+	Phone                    string    `json:"phone"`
+	ImageUrl                 string    `json:"image_url"`
+	IsOpen                   bool      `json:"is_open"`
+	CreatedAt                time.Time `json:"created_at"`
+	UpdatedAt                time.Time `json:"updated_at"`
+	RatingAvg                string    `json:"rating_avg"`
+	RatingCount              int32     `json:"rating_count"`
+	LeadTimeMinutes          int32     `json:"lead_time_minutes"`
+	ServiceChargeBasisPoints int32     `json:"service_charge_basis_points"`
+}
+
+type ShopBankAccount struct {
+	ShopID        string    `json:"shop_id"`
+	BankCode      string    `json:"bank_code"`
+	AccountNumber string    `json:"account_number"`
+	AccountName   string    `json:"account_name"`
+	Provider      string    `json:"provider"`
+	RecipientCode string    `json:"recipient_code"`
+	VerifiedAt    time.Time `json:"verified_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type ShopHour struct {
+	ShopID       string `json:"shop_id"`
+	Weekday      int16  `json:"weekday"`
+	OpensMinute  int32  `json:"opens_minute"`
+	ClosesMinute int32  `json:"closes_minute"`
+}
+
+type ShopInvoiceCounter struct {
+	ShopID     string `json:"shop_id"`
+	LastNumber int64  `json:"last_number"`
+}
+
+type ShopLocation struct {
+	ShopID    string    `json:"shop_id"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 type User struct {
 	ID             string    `json:"id"`
 	Lastname       string    `json:"lastname"`
@@ -18,4 +432,18 @@ type User struct {
 	Email          string    `json:"email"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
+	Role           string    `json:"role"`
+}
+
+type VendorEarning struct {
+	OrderID                  string         `json:"order_id"`
+	ShopID                   string         `json:"shop_id"`
+	Gross                    utils.Money    `json:"gross"`
+	Commission               utils.Money    `json:"commission"`
+	ProcessingFee            utils.Money    `json:"processing_fee"`
+	Net                      utils.Money    `json:"net"`
+	CommissionPercent        int32          `json:"commission_percent"`
+	ProcessingFeeBasisPoints int32          `json:"processing_fee_basis_points"`
+	SettlementID             sql.NullString `json:"settlement_id"`
+	EarnedAt                 time.Time      `json:"earned_at"`
 }
@@ -20,7 +20,7 @@ INSERT INTO users (
     address,
     hashed_password
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7) RETURNING id, lastname, firstname, hashed_password, phone, address, email, created_at, updated_at
+    $1, $2, $3, $4, $5, $6, $7) RETURNING id, lastname, firstname, hashed_password, phone, address, email, created_at, updated_at, role
 `
 
 type CreateUserParams struct {
@@ -54,6 +54,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
 	)
 	return i, err
 }
@@ -77,7 +78,7 @@ func (q *Queries) DeleteUser(ctx context.Context, id string) error {
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, lastname, firstname, hashed_password, phone, address, email, created_at, updated_at FROM users WHERE email = $1
+SELECT id, lastname, firstname, hashed_password, phone, address, email, created_at, updated_at, role FROM users WHERE email = $1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -93,12 +94,13 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getUserById = `-- name: GetUserById :one
-SELECT id, lastname, firstname, hashed_password, phone, address, email, created_at, updated_at FROM users WHERE id = $1
+SELECT id, lastname, firstname, hashed_password, phone, address, email, created_at, updated_at, role FROM users WHERE id = $1
 `
 
 func (q *Queries) GetUserById(ctx context.Context, id string) (User, error) {
@@ -114,12 +116,13 @@ func (q *Queries) GetUserById(ctx context.Context, id string) (User, error) {
 		&i.Email,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
 	)
 	return i, err
 }
 
 const listAllUsers = `-- name: ListAllUsers :many
-SELECT id, lastname, firstname, hashed_password, phone, address, email, created_at, updated_at FROM users ORDER BY id LIMIT $1 OFFSET $2
+SELECT id, lastname, firstname, hashed_password, phone, address, email, created_at, updated_at, role FROM users ORDER BY id LIMIT $1 OFFSET $2
 `
 
 type ListAllUsersParams struct {
@@ -146,6 +149,7 @@ func (q *Queries) ListAllUsers(ctx context.Context, arg ListAllUsersParams) ([]U
 			&i.Email,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Role,
 		); err != nil {
 			return nil, err
 		}
@@ -161,7 +165,7 @@ func (q *Queries) ListAllUsers(ctx context.Context, arg ListAllUsersParams) ([]U
 }
 
 const updateUser = `-- name: UpdateUser :one
-UPDATE users SET address = $4, phone = $3, email = $2, updated_at = $5 WHERE id = $1 RETURNING id, lastname, firstname, hashed_password, phone, address, email, created_at, updated_at
+UPDATE users SET address = $4, phone = $3, email = $2, updated_at = $5 WHERE id = $1 RETURNING id, lastname, firstname, hashed_password, phone, address, email, created_at, updated_at, role
 `
 
 type UpdateUserParams struct {
@@ -191,12 +195,13 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
 	)
 	return i, err
 }
 
 const updateUserPassword = `-- name: UpdateUserPassword :one
-UPDATE users SET hashed_password = $2, updated_at = $3 WHERE id = $1 RETURNING id, lastname, firstname, hashed_password, phone, address, email, created_at, updated_at
+UPDATE users SET hashed_password = $2, updated_at = $3 WHERE id = $1 RETURNING id, lastname, firstname, hashed_password, phone, address, email, created_at, updated_at, role
 `
 
 type UpdateUserPasswordParams struct {
@@ -218,6 +223,34 @@ func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPassword
 		&i.Email,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users SET role = $2, updated_at = now() WHERE id = $1 RETURNING id, lastname, firstname, hashed_password, phone, address, email, created_at, updated_at, role
+`
+
+type UpdateUserRoleParams struct {
+	ID   string `json:"id"`
+	Role string `json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserRole, arg.ID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Lastname,
+		&i.Firstname,
+		&i.HashedPassword,
+		&i.Phone,
+		&i.Address,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
 	)
 	return i, err
 }
@@ -0,0 +1,219 @@
+package all_test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRandomCoupon(t *testing.T, maxRedemptions int32) db.Coupon {
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+
+	arg := db.CreateCouponParams{
+		ID:             id,
+		Code:           strings.ToUpper(utils.RandomString(10)),
+		Kind:           utils.CouponPercentage,
+		PercentOff:     10,
+		MaxRedemptions: sql.NullInt32{Int32: maxRedemptions, Valid: maxRedemptions > 0},
+		Categories:     []string{},
+	}
+
+	coupon, err := testQueries.CreateCoupon(context.Background(), arg)
+	assert.NoError(t, err)
+	assert.Equal(t, arg.Code, coupon.Code)
+	assert.True(t, coupon.IsActive)
+	assert.Equal(t, int32(0), coupon.RedemptionCount)
+
+	return coupon
+}
+
+func TestCouponDiscount(t *testing.T) {
+	lines := []utils.CouponLine{
+		{ShopID: "a", Category: "rice", Amount: utils.Naira(3000)},
+		{ShopID: "a", Category: "drinks", Amount: utils.Naira(1000)},
+		{ShopID: "b", Category: "rice", Amount: utils.Naira(2000)},
+	}
+
+	// Off everything, shared between the shops by what each put in.
+	rules := utils.CouponRules{Kind: utils.CouponPercentage, PercentOff: 10}
+	discount, err := rules.Discount(lines, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.Naira(600), discount.Total)
+	assert.Equal(t, utils.Naira(400), discount.Shops["a"])
+	assert.Equal(t, utils.Naira(200), discount.Shops["b"])
+
+	// The cap holds however large the cart.
+	rules.MaxDiscount = utils.Naira(500)
+	discount, err = rules.Discount(lines, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.Naira(500), discount.Total)
+	assert.Equal(t, discount.Total, discount.Shops["a"].Add(discount.Shops["b"]))
+
+	// A fixed amount never takes off more than the items it applies to.
+	rules = utils.CouponRules{Kind: utils.CouponFixed, AmountOff: utils.Naira(5000), Categories: []string{"drinks"}}
+	discount, err = rules.Discount(lines, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.Naira(1000), discount.Total)
+	assert.Equal(t, utils.Naira(1000), discount.Shops["a"])
+	_, ok := discount.Shops["b"]
+	assert.False(t, ok)
+
+	// Free delivery only covers shops the coupon applies to.
+	rules = utils.CouponRules{Kind: utils.CouponFreeDelivery, ShopID: "b"}
+	discount, err = rules.Discount(lines, map[string]utils.Money{"a": utils.Naira(800), "b": utils.Naira(500)})
+	assert.NoError(t, err)
+	assert.Equal(t, utils.Naira(500), discount.Total)
+	assert.Equal(t, utils.Naira(500), discount.Shops["b"])
+
+	discount, err = rules.Discount(lines, nil)
+	assert.NoError(t, err)
+	assert.True(t, discount.Total.IsZero())
+}
+
+func TestCouponRules(t *testing.T) {
+	lines := []utils.CouponLine{{ShopID: "a", Category: "rice", Amount: utils.Naira(3000)}}
+
+	rules := utils.CouponRules{Kind: utils.CouponPercentage, PercentOff: 10, MinOrder: utils.Naira(5000)}
+	_, err := rules.Discount(lines, nil)
+	assert.ErrorIs(t, err, utils.ErrCouponMinOrder)
+
+	rules = utils.CouponRules{Kind: utils.CouponPercentage, PercentOff: 10, Categories: []string{"drinks"}}
+	_, err = rules.Discount(lines, nil)
+	assert.ErrorIs(t, err, utils.ErrCouponNotApplicable)
+
+	now := time.Now()
+	rules = utils.CouponRules{Kind: utils.CouponFixed, AmountOff: utils.Naira(500), StartsAt: now.Add(time.Hour)}
+	assert.ErrorIs(t, rules.Check(now), utils.ErrCouponNotStarted)
+	rules = utils.CouponRules{Kind: utils.CouponFixed, AmountOff: utils.Naira(500), EndsAt: now}
+	assert.ErrorIs(t, rules.Check(now), utils.ErrCouponExpired)
+	assert.NoError(t, rules.Check(now.Add(-time.Minute)))
+
+	assert.Error(t, utils.CouponRules{Kind: utils.CouponPercentage}.Validate())
+	assert.Error(t, utils.CouponRules{Kind: utils.CouponFixed}.Validate())
+	assert.NoError(t, utils.CouponRules{Kind: utils.CouponFreeDelivery}.Validate())
+}
+
+func TestRedeemCouponConcurrently(t *testing.T) {
+	coupon := createRandomCoupon(t, 3)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	redeemed := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := testQueries.RedeemCoupon(context.Background(), coupon.ID)
+			if err == nil {
+				mu.Lock()
+				redeemed++
+				mu.Unlock()
+			} else {
+				assert.ErrorIs(t, err, sql.ErrNoRows)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Never more uses than the coupon allows, however many race for them.
+	assert.Equal(t, 3, redeemed)
+	got, err := testQueries.GetCoupon(context.Background(), coupon.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), got.RedemptionCount)
+}
+
+func TestReleaseCouponRedemption(t *testing.T) {
+	user := createRandomUser(t)
+	shop := createRandomShop(t)
+	coupon := createRandomCoupon(t, 0)
+
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+	checkout, err := testQueries.CreateCheckout(context.Background(), db.CreateCheckoutParams{
+		ID:              id,
+		UserID:          user.ID,
+		Subtotal:        utils.Naira(3000),
+		DeliveryFee:     utils.Naira(0),
+		Total:           utils.Naira(2700),
+		DeliveryAddress: utils.RandomAddress(),
+		Discount:        utils.Naira(300),
+		CouponID:        sql.NullString{String: coupon.ID, Valid: true},
+	})
+	assert.NoError(t, err)
+
+	orderId, err := utils.NewID()
+	assert.NoError(t, err)
+	order, err := testQueries.CreateOrder(context.Background(), db.CreateOrderParams{
+		ID:              orderId,
+		UserID:          user.ID,
+		ShopID:          shop.ID,
+		Subtotal:        utils.Naira(3000),
+		Total:           utils.Naira(2700),
+		DeliveryAddress: checkout.DeliveryAddress,
+		Status:          utils.OrderPending,
+		CheckoutID:      sql.NullString{String: checkout.ID, Valid: true},
+		DeliveryFee:     utils.Naira(0),
+		Discount:        utils.Naira(300),
+		CouponID:        sql.NullString{String: coupon.ID, Valid: true},
+	})
+	assert.NoError(t, err)
+
+	_, err = testQueries.RedeemCoupon(context.Background(), coupon.ID)
+	assert.NoError(t, err)
+	redemptionId, err := utils.NewID()
+	assert.NoError(t, err)
+	_, err = testQueries.CreateCouponRedemption(context.Background(), db.CreateCouponRedemptionParams{
+		ID:         redemptionId,
+		CouponID:   coupon.ID,
+		UserID:     user.ID,
+		CheckoutID: checkout.ID,
+		Discount:   utils.Naira(300),
+	})
+	assert.NoError(t, err)
+
+	used, err := testQueries.CountUserRedemptions(context.Background(), db.CountUserRedemptionsParams{
+		CouponID: coupon.ID,
+		UserID:   user.ID,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), used)
+
+	// Nothing is given back while the order is still going ahead.
+	_, err = testQueries.ReleaseCheckoutRedemption(context.Background(), checkout.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = testQueries.UpdateOrderStatus(context.Background(), db.UpdateOrderStatusParams{
+		ToStatus:   utils.OrderCancelled,
+		ID:         order.ID,
+		FromStatus: utils.OrderPending,
+	})
+	assert.NoError(t, err)
+
+	redemption, err := testQueries.ReleaseCheckoutRedemption(context.Background(), checkout.ID)
+	assert.NoError(t, err)
+	assert.True(t, redemption.ReleasedAt.Valid)
+	assert.NoError(t, testQueries.UnredeemCoupon(context.Background(), coupon.ID))
+
+	used, err = testQueries.CountUserRedemptions(context.Background(), db.CountUserRedemptionsParams{
+		CouponID: coupon.ID,
+		UserID:   user.ID,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), used)
+
+	got, err := testQueries.GetCoupon(context.Background(), coupon.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), got.RedemptionCount)
+
+	// A use is only released once.
+	_, err = testQueries.ReleaseCheckoutRedemption(context.Background(), checkout.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,74 @@
+package all_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeliveryQuote(t *testing.T) {
+	pricing := utils.DefaultDeliveryPricing()
+	morning := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	lunch := time.Date(2024, 5, 6, 12, 30, 0, 0, time.UTC)
+
+	quote, err := pricing.Quote(2500, true, utils.Naira(3000), morning)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.Naira(500), quote.Fee)
+	assert.Equal(t, int64(100), quote.SurgePercent)
+
+	quote, err = pricing.Quote(2500, true, utils.Naira(3000), lunch)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.Naira(100), quote.SurgeFee)
+	assert.Equal(t, utils.Naira(600), quote.Fee)
+
+	quote, err = pricing.Quote(0, false, utils.Naira(2000), morning)
+	assert.NoError(t, err)
+	assert.Nil(t, quote.DistanceMeters)
+	assert.Equal(t, pricing.FlatFee.Add(pricing.SmallOrderFee), quote.Fee)
+
+	quote, err = pricing.Quote(8000, true, utils.Naira(25000), lunch)
+	assert.NoError(t, err)
+	assert.True(t, quote.Fee.IsZero())
+	assert.Equal(t, quote.DistanceFee.Add(quote.SurgeFee), quote.FreeDeliveryDiscount)
+
+	_, err = pricing.Quote(2500, true, utils.Naira(500), morning)
+	assert.ErrorIs(t, err, utils.ErrBelowMinimumOrder)
+
+	_, err = pricing.Quote(40000, true, utils.Naira(3000), morning)
+	assert.ErrorIs(t, err, utils.ErrOutOfDeliveryArea)
+}
+
+func TestDistanceMeters(t *testing.T) {
+	// Ikeja to Victoria Island is about 21km in a straight line.
+	distance := utils.DistanceMeters(6.6018, 3.3515, 6.4281, 3.4219)
+	assert.InDelta(t, 20700, distance, 1000)
+	assert.Zero(t, utils.DistanceMeters(6.5, 3.4, 6.5, 3.4))
+}
+
+func TestUpsertShopLocation(t *testing.T) {
+	shop := createRandomShop(t)
+
+	location, err := testQueries.UpsertShopLocation(context.Background(), db.UpsertShopLocationParams{
+		ShopID:    shop.ID,
+		Latitude:  6.5244,
+		Longitude: 3.3792,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 6.5244, location.Latitude)
+
+	location, err = testQueries.UpsertShopLocation(context.Background(), db.UpsertShopLocationParams{
+		ShopID:    shop.ID,
+		Latitude:  6.45,
+		Longitude: 3.39,
+	})
+	assert.NoError(t, err)
+
+	got, err := testQueries.GetShopLocation(context.Background(), shop.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, location.Latitude, got.Latitude)
+	assert.Equal(t, 6.45, got.Latitude)
+}
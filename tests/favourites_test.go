@@ -0,0 +1,56 @@
+package all_test
+
+import (
+	"context"
+	"testing"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFavouriteShops(t *testing.T) {
+	user := createRandomUser(t)
+	shop := createRandomShop(t)
+	other := createRandomShop(t)
+
+	arg := db.AddFavouriteShopParams{UserID: user.ID, ShopID: shop.ID}
+	assert.NoError(t, testQueries.AddFavouriteShop(context.Background(), arg))
+	// favouriting twice is a no-op
+	assert.NoError(t, testQueries.AddFavouriteShop(context.Background(), arg))
+
+	shops, err := testQueries.ListFavouriteShops(context.Background(), user.ID)
+	assert.NoError(t, err)
+	assert.Len(t, shops, 1)
+	assert.Equal(t, shops[0].ID, shop.ID)
+
+	ids, err := testQueries.FilterFavouriteShops(context.Background(), db.FilterFavouriteShopsParams{
+		UserID:  user.ID,
+		ShopIds: []string{shop.ID, other.ID},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, ids, []string{shop.ID})
+
+	assert.NoError(t, testQueries.RemoveFavouriteShop(context.Background(), db.RemoveFavouriteShopParams{UserID: user.ID, ShopID: shop.ID}))
+
+	shops, err = testQueries.ListFavouriteShops(context.Background(), user.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, shops)
+}
+
+func TestFavouriteProducts(t *testing.T) {
+	user := createRandomUser(t)
+	shop := createRandomShop(t)
+	product := createRandomProduct(t, shop, []string{}, []string{}, 0)
+
+	err := testQueries.AddFavouriteProduct(context.Background(), db.AddFavouriteProductParams{UserID: user.ID, ProductID: product.ID})
+	assert.NoError(t, err)
+
+	products, err := testQueries.ListFavouriteProducts(context.Background(), user.ID)
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, products[0].ID, product.ID)
+	assert.Equal(t, products[0].Price, product.Price)
+	assert.Equal(t, products[0].ShopName, shop.Name)
+	assert.True(t, products[0].IsAvailable)
+	assert.True(t, products[0].ShopIsOpen)
+}
@@ -0,0 +1,104 @@
+package all_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRandomGroupOrder(t *testing.T, host db.User, shop db.Shop, deadline time.Time) db.GroupOrder {
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+
+	code, err := utils.NewID()
+	assert.NoError(t, err)
+
+	group, err := testQueries.CreateGroupOrder(context.Background(), db.CreateGroupOrderParams{
+		ID:              id,
+		HostID:          host.ID,
+		ShopID:          shop.ID,
+		InviteCode:      code,
+		PaymentMode:     "host",
+		Deadline:        deadline,
+		DeliveryAddress: utils.RandomAddress(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, group.Status, "open")
+
+	err = testQueries.AddGroupOrderMember(context.Background(), db.AddGroupOrderMemberParams{
+		GroupOrderID: group.ID,
+		UserID:       host.ID,
+	})
+	assert.NoError(t, err)
+
+	return group
+}
+
+func TestGroupOrderMembers(t *testing.T) {
+	host := createRandomUser(t)
+	guest := createRandomUser(t)
+	shop := createRandomShop(t)
+
+	group := createRandomGroupOrder(t, host, shop, time.Now().Add(time.Hour))
+
+	found, err := testQueries.GetGroupOrderByInviteCode(context.Background(), group.InviteCode)
+	assert.NoError(t, err)
+	assert.Equal(t, found.ID, group.ID)
+
+	// Joining twice is harmless.
+	for i := 0; i < 2; i++ {
+		err = testQueries.AddGroupOrderMember(context.Background(), db.AddGroupOrderMemberParams{
+			GroupOrderID: group.ID,
+			UserID:       guest.ID,
+		})
+		assert.NoError(t, err)
+	}
+
+	members, err := testQueries.ListGroupOrderMembers(context.Background(), group.ID)
+	assert.NoError(t, err)
+	assert.Len(t, members, 2)
+
+	groups, err := testQueries.ListUserGroupOrders(context.Background(), db.ListUserGroupOrdersParams{
+		UserID: guest.ID,
+		Limit:  10,
+		Offset: 0,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, groups, 1)
+}
+
+func TestSubmitGroupOrder(t *testing.T) {
+	host := createRandomUser(t)
+	shop := createRandomShop(t)
+
+	group := createRandomGroupOrder(t, host, shop, time.Now().Add(-time.Minute))
+
+	locked, err := testQueries.LockExpiredGroupOrders(context.Background(), time.Now())
+	assert.NoError(t, err)
+
+	ids := []string{}
+	for _, expired := range locked {
+		ids = append(ids, expired.ID)
+	}
+	assert.Contains(t, ids, group.ID)
+
+	order := createRandomOrder(t, host, shop)
+
+	submitted, err := testQueries.SubmitGroupOrder(context.Background(), db.SubmitGroupOrderParams{
+		ID:      group.ID,
+		OrderID: sql.NullString{String: order.ID, Valid: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, submitted.Status, "submitted")
+
+	_, err = testQueries.SubmitGroupOrder(context.Background(), db.SubmitGroupOrderParams{
+		ID:      group.ID,
+		OrderID: sql.NullString{String: order.ID, Valid: true},
+	})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
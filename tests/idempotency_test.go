@@ -0,0 +1,129 @@
+package all_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/api"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// idempotentRouter serves one route behind IdempotencyMiddleware that counts
+// how often it really runs. Its handler waits on release, when given, so a
+// request can be held in flight.
+func idempotentRouter(t *testing.T, calls *int32, started chan<- struct{}, release <-chan struct{}) *gin.Engine {
+	config, err := utils.LoadDBConfig("..")
+	assert.NoError(t, err)
+	api.Rdb = redis.NewClient(&redis.Options{
+		Addr:     config.RedisAddress,
+		Password: config.RedisPassword,
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/things", api.IdempotencyMiddleware(), func(ctx *gin.Context) {
+		n := atomic.AddInt32(calls, 1)
+		if started != nil {
+			started <- struct{}{}
+		}
+		if release != nil {
+			<-release
+		}
+		if ctx.GetHeader("X-Fail") != "" {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"Error": "failed"})
+			return
+		}
+		ctx.JSON(http.StatusCreated, gin.H{"call": n})
+	})
+	return router
+}
+
+func idempotentRequest(router *gin.Engine, key, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/things", strings.NewReader(body))
+	req.Header.Set("Idempotency-Key", key)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	var calls int32
+	router := idempotentRouter(t, &calls, nil, nil)
+	key := utils.RandomString(20)
+
+	first := idempotentRequest(router, key, `{"amount":100}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+
+	again := idempotentRequest(router, key, `{"amount":100}`)
+	assert.Equal(t, http.StatusCreated, again.Code)
+	assert.Equal(t, "true", again.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Body.String(), again.Body.String())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// The same key with another body is a client mistake, not a retry.
+	other := idempotentRequest(router, key, `{"amount":200}`)
+	assert.Equal(t, http.StatusConflict, other.Code)
+	assert.Contains(t, other.Body.String(), "different request body")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// Requests without a key are never deduplicated.
+	idempotentRequest(router, "", `{"amount":100}`)
+	idempotentRequest(router, "", `{"amount":100}`)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotencyInFlight(t *testing.T) {
+	var calls int32
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	router := idempotentRouter(t, &calls, started, release)
+	key := utils.RandomString(20)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- idempotentRequest(router, key, `{"amount":100}`)
+	}()
+	<-started
+
+	duplicate := idempotentRequest(router, key, `{"amount":100}`)
+	assert.Equal(t, http.StatusConflict, duplicate.Code)
+	assert.Contains(t, duplicate.Body.String(), "still being processed")
+
+	close(release)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotencyServerError(t *testing.T) {
+	var calls int32
+	router := idempotentRouter(t, &calls, nil, nil)
+	key := utils.RandomString(20)
+
+	// A server error releases the key so the retry runs again.
+	failed := idempotentRequest(router, key, `{"amount":100}`, "X-Fail", "1")
+	assert.Equal(t, http.StatusInternalServerError, failed.Code)
+
+	retry := idempotentRequest(router, key, `{"amount":100}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Empty(t, retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotencyBodyLimit(t *testing.T) {
+	var calls int32
+	router := idempotentRouter(t, &calls, nil, nil)
+
+	large := idempotentRequest(router, utils.RandomString(20), strings.Repeat("a", 2<<20))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, large.Code)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+}
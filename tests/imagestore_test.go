@@ -0,0 +1,29 @@
+package all_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewImageStore(t *testing.T) {
+	dir := t.TempDir()
+
+	// Nothing configured is a development setup, which stores on disk.
+	store, err := utils.NewImageStore(&utils.Config{LocalUploadDir: dir})
+	assert.NoError(t, err)
+	assert.IsType(t, &utils.LocalStore{}, store)
+
+	store, err = utils.NewImageStore(&utils.Config{CloudName: "demo", CloudApiKey: "key", CloudApiSecret: "secret"})
+	assert.NoError(t, err)
+	assert.IsType(t, &utils.CloudinaryStore{}, store)
+
+	// Half configured Cloudinary is a mistake, not a reason to fall back.
+	_, err = utils.NewImageStore(&utils.Config{CloudName: "demo", LocalUploadDir: dir})
+	assert.Error(t, err)
+	_, err = utils.NewImageStore(&utils.Config{ImageStore: "cloudinary", LocalUploadDir: dir})
+	assert.Error(t, err)
+	_, err = utils.NewImageStore(&utils.Config{ImageStore: "s3"})
+	assert.Error(t, err)
+}
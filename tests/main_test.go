@@ -1,13 +1,21 @@
 package all_test
 
 import (
+	"bytes"
 	"database/sql"
+	"encoding/json"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/api"
 	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
 	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/stretchr/testify/assert"
 )
 
 var testQueries *db.Queries
@@ -27,3 +35,62 @@ func TestMain(m *testing.M) {
 	testQueries = db.New(conn)
 	os.Exit(m.Run())
 }
+
+var (
+	testServerOnce sync.Once
+	testServer     *api.Server
+	testTokens     *utils.JWTToken
+)
+
+// newTestServer returns a server with every route, on the same database and
+// redis as testQueries. It is only built once, as building it swaps out the
+// api package's redis client and token keys.
+func newTestServer(t *testing.T) *api.Server {
+	testServerOnce.Do(func() {
+		config, err := utils.LoadOtherConfig("..")
+		if err != nil {
+			log.Fatal("Could not load env config", err)
+		}
+		testServer = api.NewServer("..")
+		testTokens = utils.NewJWTToken(config)
+	})
+	return testServer
+}
+
+// newAuthRequest builds a request signed in as user, with body sent as JSON
+// when it is not nil.
+func newAuthRequest(t *testing.T, user db.User, method, path string, body interface{}) *http.Request {
+	newTestServer(t)
+
+	raw := []byte{}
+	if body != nil {
+		var err error
+		raw, err = json.Marshal(body)
+		assert.NoError(t, err)
+	}
+
+	token, err := testTokens.CreateToken(user.ID, user.Role, time.Minute)
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(raw))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+// serveAs sends a request to the test server as user and decodes the data
+// of a successful response into data.
+func serveAs(t *testing.T, user db.User, method, path string, body, data interface{}) *httptest.ResponseRecorder {
+	server := newTestServer(t)
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, newAuthRequest(t, user, method, path, body))
+
+	if data != nil && recorder.Code < http.StatusBadRequest {
+		envelope := struct {
+			Data interface{} `json:"data"`
+		}{Data: data}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &envelope))
+	}
+	return recorder
+}
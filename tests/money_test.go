@@ -0,0 +1,51 @@
+package all_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	money, err := utils.ParseMoney("1500.5")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(150050), money.Kobo())
+	assert.Equal(t, "1500.50", money.String())
+
+	money, err = utils.ParseMoney("-0.05")
+	assert.NoError(t, err)
+	assert.True(t, money.IsNegative())
+	assert.Equal(t, "-0.05", money.String())
+
+	for _, bad := range []string{"", "1.005", "abc", "1,000", "1e3"} {
+		_, err = utils.ParseMoney(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var body struct {
+		Price utils.Money `json:"price"`
+	}
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"price": 1500.5}`), &body))
+	assert.Equal(t, utils.Kobo(150050), body.Price)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"price": "20"}`), &body))
+	assert.Equal(t, utils.Naira(20), body.Price)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"price": "12.345"}`), &body))
+
+	raw, err := json.Marshal(body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"price": "20.00"}`, string(raw))
+}
+
+func TestMoneyPercent(t *testing.T) {
+	assert.Equal(t, int64(37513), utils.Kobo(150050).Percent(25).Kobo())
+	assert.Equal(t, int64(-37513), utils.Kobo(-150050).Percent(25).Kobo())
+	assert.Equal(t, utils.Naira(3), utils.Naira(2).Percent(150))
+	assert.Equal(t, utils.Naira(2), utils.Naira(2).Min(utils.Naira(5)))
+}
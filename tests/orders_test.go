@@ -0,0 +1,510 @@
+package all_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/api"
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRandomOrder(t *testing.T, user db.User, shop db.Shop) db.Order {
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+
+	arg := db.CreateOrderParams{
+		ID:              id,
+		UserID:          user.ID,
+		ShopID:          shop.ID,
+		Subtotal:        utils.Naira(3000),
+		Total:           utils.Naira(3000),
+		DeliveryAddress: utils.RandomAddress(),
+		Status:          utils.OrderPending,
+		DeliveryFee:     utils.Naira(0),
+	}
+
+	order, err := testQueries.CreateOrder(context.Background(), arg)
+	assert.NoError(t, err)
+	assert.Equal(t, order.UserID, arg.UserID)
+	assert.Equal(t, order.ShopID, arg.ShopID)
+	assert.Equal(t, order.Total, arg.Total)
+	assert.Equal(t, order.Status, "pending")
+
+	return order
+}
+
+func TestCreateOrderWithItems(t *testing.T) {
+	user := createRandomUser(t)
+	shop := createRandomShop(t)
+	product := createRandomProduct(t, shop, []string{}, []string{}, 0)
+	option := createRandomProductOption(t, product, utils.Naira(500))
+
+	order := createRandomOrder(t, user, shop)
+
+	itemId, err := utils.NewID()
+	assert.NoError(t, err)
+
+	item, err := testQueries.CreateOrderItem(context.Background(), db.CreateOrderItemParams{
+		ID:          itemId,
+		OrderID:     order.ID,
+		ProductID:   sql.NullString{String: product.ID, Valid: true},
+		ProductName: product.Name,
+		UnitPrice:   utils.Naira(2000),
+		Quantity:    2,
+		LineTotal:   utils.Naira(4000),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, item.OrderID, order.ID)
+
+	optionId, err := utils.NewID()
+	assert.NoError(t, err)
+
+	_, err = testQueries.CreateOrderItemOption(context.Background(), db.CreateOrderItemOptionParams{
+		ID:          optionId,
+		OrderItemID: item.ID,
+		OptionID:    sql.NullString{String: option.ID, Valid: true},
+		GroupName:   option.GroupName,
+		Name:        option.Name,
+		PriceDelta:  option.PriceDelta,
+	})
+	assert.NoError(t, err)
+
+	items, err := testQueries.ListOrderItems(context.Background(), order.ID)
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+
+	options, err := testQueries.ListOrderItemOptions(context.Background(), []string{item.ID})
+	assert.NoError(t, err)
+	assert.Len(t, options, 1)
+	assert.Equal(t, options[0].Name, option.Name)
+
+	orders, err := testQueries.ListUserOrders(context.Background(), db.ListUserOrdersParams{
+		UserID: user.ID,
+		Limit:  10,
+		Offset: 0,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, orders, 1)
+	assert.Equal(t, orders[0].ID, order.ID)
+}
+
+func TestUpdateOrderStatusHistory(t *testing.T) {
+	user := createRandomUser(t)
+	shop := createRandomShop(t)
+	order := createRandomOrder(t, user, shop)
+
+	updated, err := testQueries.UpdateOrderStatus(context.Background(), db.UpdateOrderStatusParams{
+		ToStatus:   utils.OrderAccepted,
+		ID:         order.ID,
+		FromStatus: utils.OrderPending,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, updated.Status, utils.OrderAccepted)
+
+	// A second update from the stale status must not match.
+	_, err = testQueries.UpdateOrderStatus(context.Background(), db.UpdateOrderStatusParams{
+		ToStatus:   utils.OrderRejected,
+		ID:         order.ID,
+		FromStatus: utils.OrderPending,
+	})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+
+	_, err = testQueries.CreateOrderStatusHistory(context.Background(), db.CreateOrderStatusHistoryParams{
+		ID:         id,
+		OrderID:    order.ID,
+		FromStatus: sql.NullString{String: utils.OrderPending, Valid: true},
+		ToStatus:   utils.OrderAccepted,
+		ActorID:    sql.NullString{String: shop.OwnerID, Valid: true},
+		ActorRole:  utils.ActorVendor,
+	})
+	assert.NoError(t, err)
+
+	history, err := testQueries.ListOrderStatusHistory(context.Background(), order.ID)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.Equal(t, history[0].ToStatus, utils.OrderAccepted)
+}
+
+func TestOrderTransitions(t *testing.T) {
+	assert.True(t, utils.CanTransitionOrder(utils.OrderPending, utils.OrderAccepted, utils.ActorVendor))
+	assert.False(t, utils.CanTransitionOrder(utils.OrderPending, utils.OrderAccepted, utils.ActorCustomer))
+	assert.True(t, utils.CanTransitionOrder(utils.OrderPending, utils.OrderCancelled, utils.ActorCustomer))
+	assert.True(t, utils.CanTransitionOrder(utils.OrderPreparing, utils.OrderCancelled, utils.ActorCustomer))
+	assert.False(t, utils.CanTransitionOrder(utils.OrderPickedUp, utils.OrderCancelled, utils.ActorCustomer))
+	assert.True(t, utils.CanTransitionOrder(utils.OrderReady, utils.OrderPickedUp, utils.ActorRider))
+	assert.False(t, utils.CanTransitionOrder(utils.OrderReady, utils.OrderPickedUp, utils.ActorVendor))
+	assert.False(t, utils.CanTransitionOrder(utils.OrderDelivered, utils.OrderCancelled, utils.ActorAdmin))
+	assert.True(t, utils.CanTransitionOrder(utils.OrderPickedUp, utils.OrderDelivered, utils.ActorAdmin))
+
+	assert.Equal(t, utils.NextOrderStatuses(utils.OrderPending, utils.ActorVendor), []string{utils.OrderAccepted, utils.OrderCancelled, utils.OrderRejected})
+	assert.Empty(t, utils.NextOrderStatuses(utils.OrderDelivered, utils.ActorRider))
+}
+
+func TestCheckoutWithSubOrders(t *testing.T) {
+	user := createRandomUser(t)
+
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+
+	checkout, err := testQueries.CreateCheckout(context.Background(), db.CreateCheckoutParams{
+		ID:              id,
+		UserID:          user.ID,
+		Subtotal:        utils.Naira(4500),
+		DeliveryFee:     utils.Naira(0),
+		Total:           utils.Naira(4500),
+		DeliveryAddress: utils.RandomAddress(),
+	})
+	assert.NoError(t, err)
+
+	for _, subtotal := range []utils.Money{utils.Naira(1500), utils.Naira(3000)} {
+		orderId, err := utils.NewID()
+		assert.NoError(t, err)
+
+		_, err = testQueries.CreateOrder(context.Background(), db.CreateOrderParams{
+			ID:              orderId,
+			UserID:          user.ID,
+			ShopID:          createRandomShop(t).ID,
+			Subtotal:        subtotal,
+			Total:           subtotal,
+			DeliveryAddress: checkout.DeliveryAddress,
+			Status:          utils.OrderPending,
+			CheckoutID:      sql.NullString{String: checkout.ID, Valid: true},
+			DeliveryFee:     utils.Naira(0),
+		})
+		assert.NoError(t, err)
+	}
+
+	orders, err := testQueries.ListCheckoutOrders(context.Background(), sql.NullString{String: checkout.ID, Valid: true})
+	assert.NoError(t, err)
+	assert.Len(t, orders, 2)
+	assert.NotEqual(t, orders[0].ShopID, orders[1].ShopID)
+
+	_, err = testQueries.UpdateOrderStatus(context.Background(), db.UpdateOrderStatusParams{
+		ToStatus:   utils.OrderAccepted,
+		ID:         orders[0].ID,
+		FromStatus: utils.OrderPending,
+	})
+	assert.NoError(t, err)
+
+	other, err := testQueries.GetOrder(context.Background(), orders[1].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.OrderPending, other.Status)
+}
+
+func TestCancellationPolicy(t *testing.T) {
+	policy := utils.NewCancellationPolicy(25)
+
+	fee, ok := policy.Fee(utils.OrderAccepted, utils.Naira(1500))
+	assert.True(t, ok)
+	assert.True(t, fee.IsZero())
+
+	fee, ok = policy.Fee(utils.OrderPreparing, utils.Kobo(150050))
+	assert.True(t, ok)
+	assert.Equal(t, int64(37513), fee.Kobo())
+
+	_, ok = policy.Fee(utils.OrderPickedUp, utils.Naira(1500))
+	assert.False(t, ok)
+
+	fee, _ = utils.NewCancellationPolicy(150).Fee(utils.OrderReady, utils.Kobo(1000))
+	assert.Equal(t, int64(1000), fee.Kobo())
+}
+
+func TestLoadCancellationPolicy(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "cancellation.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{
+		"pending": {"allowed": true},
+		"accepted": {"allowed": true, "fee_percent": 10},
+		"preparing": {"allowed": false}
+	}`), 0o644))
+
+	policy, err := utils.LoadCancellationPolicy(path)
+	assert.NoError(t, err)
+
+	fee, ok := policy.Fee(utils.OrderPending, utils.Naira(1500))
+	assert.True(t, ok)
+	assert.True(t, fee.IsZero())
+
+	fee, ok = policy.Fee(utils.OrderAccepted, utils.Naira(1500))
+	assert.True(t, ok)
+	assert.Equal(t, utils.Naira(150), fee)
+
+	_, ok = policy.Fee(utils.OrderPreparing, utils.Naira(1500))
+	assert.False(t, ok)
+	_, ok = policy.Fee(utils.OrderReady, utils.Naira(1500))
+	assert.False(t, ok)
+
+	for _, bad := range []string{
+		`{"picked_up": {"allowed": true}}`,
+		`{"pending": {"allowed": true, "fee_percent": 120}}`,
+		`{"pending": true}`,
+	} {
+		assert.NoError(t, os.WriteFile(path, []byte(bad), 0o644))
+		_, err := utils.LoadCancellationPolicy(path)
+		assert.Error(t, err, bad)
+	}
+
+	_, err = utils.LoadCancellationPolicy(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestCreateOrderCancellation(t *testing.T) {
+	user := createRandomUser(t)
+	order := createRandomOrder(t, user, createRandomShop(t))
+
+	cancellation, err := testQueries.CreateOrderCancellation(context.Background(), db.CreateOrderCancellationParams{
+		OrderID:      order.ID,
+		CancelledBy:  sql.NullString{String: user.ID, Valid: true},
+		ActorRole:    utils.ActorCustomer,
+		ReasonCode:   utils.CancelChangedMind,
+		Fee:          utils.Naira(0),
+		RefundAmount: order.Total,
+		RefundTo:     utils.RefundToWallet,
+		RefundStatus: utils.RefundPending,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, utils.RefundPending, cancellation.RefundStatus)
+
+	updated, err := testQueries.UpdateRefundStatus(context.Background(), db.UpdateRefundStatusParams{
+		ToStatus:   utils.RefundProcessing,
+		OrderID:    order.ID,
+		FromStatus: utils.RefundPending,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, utils.RefundProcessing, updated.RefundStatus)
+
+	_, err = testQueries.UpdateRefundStatus(context.Background(), db.UpdateRefundStatusParams{
+		ToStatus:   utils.RefundProcessing,
+		OrderID:    order.ID,
+		FromStatus: utils.RefundPending,
+	})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestListDueScheduledOrders(t *testing.T) {
+	user := createRandomUser(t)
+	shop := createRandomShop(t)
+
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+
+	due := time.Now().Add(-time.Minute)
+	order, err := testQueries.CreateOrder(context.Background(), db.CreateOrderParams{
+		ID:              id,
+		UserID:          user.ID,
+		ShopID:          shop.ID,
+		Subtotal:        utils.Naira(1500),
+		Total:           utils.Naira(1500),
+		DeliveryAddress: utils.RandomAddress(),
+		Status:          utils.OrderScheduled,
+		ScheduledFor:    sql.NullTime{Time: due.Add(time.Hour), Valid: true},
+		ReleaseAt:       sql.NullTime{Time: due, Valid: true},
+		DeliveryFee:     utils.Naira(0),
+	})
+	assert.NoError(t, err)
+
+	dueIds := func() []string {
+		orders, err := testQueries.ListDueScheduledOrders(context.Background(), db.ListDueScheduledOrdersParams{
+			ReleaseAt: sql.NullTime{Time: time.Now(), Valid: true},
+			Limit:     1000,
+		})
+		assert.NoError(t, err)
+
+		ids := []string{}
+		for _, due := range orders {
+			ids = append(ids, due.ID)
+		}
+		return ids
+	}
+
+	// An unpaid order waits until it is paid for.
+	assert.NotContains(t, dueIds(), order.ID)
+
+	assert.NoError(t, testQueries.SetOrderPaymentStatus(context.Background(), db.SetOrderPaymentStatusParams{
+		ID:            order.ID,
+		PaymentStatus: utils.OrderPaid,
+	}))
+	assert.Contains(t, dueIds(), order.ID)
+}
+
+func TestShopOrdersArePaid(t *testing.T) {
+	user := createRandomUser(t)
+	shop := createRandomShop(t)
+	order := createRandomOrder(t, user, shop)
+
+	listed := func() int {
+		orders, err := testQueries.ListShopOrders(context.Background(), db.ListShopOrdersParams{
+			ShopID: shop.ID,
+			Limit:  10,
+		})
+		assert.NoError(t, err)
+		return len(orders)
+	}
+
+	assert.Equal(t, 0, listed())
+
+	assert.NoError(t, testQueries.SetOrderPaymentStatus(context.Background(), db.SetOrderPaymentStatusParams{
+		ID:            order.ID,
+		PaymentStatus: utils.OrderPaid,
+	}))
+	assert.Equal(t, 1, listed())
+}
+
+func TestIsOpenAt(t *testing.T) {
+	// Monday 09:00-17:00 and Friday 18:00-02:00.
+	hours := []utils.OpeningHours{
+		{Weekday: time.Monday, OpensMinute: 9 * 60, ClosesMinute: 17 * 60},
+		{Weekday: time.Friday, OpensMinute: 18 * 60, ClosesMinute: 2 * 60},
+	}
+
+	monday := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	assert.True(t, utils.IsOpenAt(monday, hours))
+	assert.False(t, utils.IsOpenAt(monday.Add(6*time.Hour), hours))
+
+	friday := time.Date(2024, 1, 5, 23, 0, 0, 0, time.UTC)
+	assert.True(t, utils.IsOpenAt(friday, hours))
+	assert.True(t, utils.IsOpenAt(friday.Add(2*time.Hour), hours))
+	assert.False(t, utils.IsOpenAt(friday.Add(4*time.Hour), hours))
+
+	assert.True(t, utils.IsOpenAt(monday, nil))
+
+	minutes, err := utils.ParseClock("13:30")
+	assert.NoError(t, err)
+	assert.Equal(t, 13*60+30, minutes)
+	_, err = utils.ParseClock("25:00")
+	assert.Error(t, err)
+}
+
+func createRandomOrderItem(t *testing.T, order db.Order, product db.Product, unitPrice utils.Money, quantity int32, options ...db.ProductOption) db.OrderItem {
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+
+	item, err := testQueries.CreateOrderItem(context.Background(), db.CreateOrderItemParams{
+		ID:          id,
+		OrderID:     order.ID,
+		ProductID:   sql.NullString{String: product.ID, Valid: true},
+		ProductName: product.Name,
+		UnitPrice:   unitPrice,
+		Quantity:    quantity,
+		LineTotal:   unitPrice.Mul(int64(quantity)),
+	})
+	assert.NoError(t, err)
+
+	for _, option := range options {
+		optionId, err := utils.NewID()
+		assert.NoError(t, err)
+
+		_, err = testQueries.CreateOrderItemOption(context.Background(), db.CreateOrderItemOptionParams{
+			ID:          optionId,
+			OrderItemID: item.ID,
+			OptionID:    sql.NullString{String: option.ID, Valid: true},
+			GroupName:   option.GroupName,
+			Name:        option.Name,
+			PriceDelta:  option.PriceDelta,
+		})
+		assert.NoError(t, err)
+	}
+
+	return item
+}
+
+func TestReorder(t *testing.T) {
+	user := createRandomUser(t)
+	shop := createRandomShop(t)
+	order := createRandomOrder(t, user, shop)
+
+	// Cheaper last time.
+	repriced := createRandomProduct(t, shop, []string{}, []string{}, 0)
+	createRandomOrderItem(t, order, repriced, utils.Naira(1200), 1)
+
+	deleted := createRandomProduct(t, shop, []string{}, []string{}, 0)
+	createRandomOrderItem(t, order, deleted, deleted.Price, 1)
+	assert.NoError(t, testQueries.DeleteProduct(context.Background(), deleted.ID))
+
+	unavailable := createRandomProduct(t, shop, []string{}, []string{}, 0)
+	createRandomOrderItem(t, order, unavailable, unavailable.Price, 1)
+	_, err := testQueries.UpdateProduct(context.Background(), db.UpdateProductParams{
+		ID:          unavailable.ID,
+		Name:        unavailable.Name,
+		Description: unavailable.Description,
+		Category:    unavailable.Category,
+		Price:       unavailable.Price,
+		ImageUrls:   unavailable.ImageUrls,
+		IsAvailable: false,
+		DietaryTags: unavailable.DietaryTags,
+		SpiceLevel:  unavailable.SpiceLevel,
+		Allergens:   unavailable.Allergens,
+	})
+	assert.NoError(t, err)
+
+	// What the order item points at is now another shop's product.
+	moved := createRandomProduct(t, createRandomShop(t), []string{}, []string{}, 0)
+	createRandomOrderItem(t, order, moved, moved.Price, 1)
+
+	// Once the large option is gone the first line is the same as the
+	// second, and they become one line of three.
+	rice := createRandomProduct(t, shop, []string{}, []string{}, 0)
+	large := createRandomProductOption(t, rice, utils.Naira(500))
+	createRandomOrderItem(t, order, rice, rice.Price.Add(large.PriceDelta), 1, large)
+	createRandomOrderItem(t, order, rice, rice.Price, 2)
+	_, err = testQueries.UpdateProductOptionAvailability(context.Background(), db.UpdateProductOptionAvailabilityParams{
+		ID:          large.ID,
+		ProductID:   rice.ID,
+		IsAvailable: false,
+	})
+	assert.NoError(t, err)
+
+	response := api.ReorderResponse{}
+	recorder := serveAs(t, user, http.MethodPost, "/orders/"+order.ID+"/reorder", nil, &response)
+	assert.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+
+	changes := map[string][]api.ReorderChange{}
+	for _, change := range response.Changes {
+		changes[change.Name] = append(changes[change.Name], change)
+	}
+
+	assert.Len(t, changes[repriced.Name], 1)
+	assert.Equal(t, "price_changed", changes[repriced.Name][0].Status)
+	assert.Equal(t, utils.Naira(1200), changes[repriced.Name][0].OldUnitPrice)
+	if assert.NotNil(t, changes[repriced.Name][0].NewUnitPrice) {
+		assert.Equal(t, repriced.Price, *changes[repriced.Name][0].NewUnitPrice)
+	}
+
+	for _, product := range []db.Product{deleted, unavailable, moved} {
+		if assert.Len(t, changes[product.Name], 1, product.Name) {
+			assert.Equal(t, "dropped", changes[product.Name][0].Status)
+			assert.Nil(t, changes[product.Name][0].NewUnitPrice)
+		}
+	}
+	assert.Nil(t, changes[deleted.Name][0].ProductID)
+	assert.Equal(t, "This product is no longer sold by this shop.", changes[moved.Name][0].Reason)
+
+	statuses := map[string]api.ReorderChange{}
+	for _, change := range changes[rice.Name] {
+		statuses[change.Status] = change
+	}
+	assert.Len(t, statuses, 2)
+	assert.Equal(t, []string{large.Name}, statuses["options_changed"].DroppedOptions)
+	assert.Empty(t, statuses["unchanged"].DroppedOptions)
+
+	lines := map[string]api.CartLine{}
+	for _, line := range response.Cart.Lines {
+		lines[line.ProductID] = line
+	}
+	assert.Len(t, lines, 2)
+	assert.Equal(t, int32(1), lines[repriced.ID].Quantity)
+	assert.Equal(t, int32(3), lines[rice.ID].Quantity)
+	assert.Empty(t, lines[rice.ID].Options)
+	assert.Equal(t, order.Subtotal, response.OldSubtotal)
+}
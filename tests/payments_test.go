@@ -0,0 +1,232 @@
+package all_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"database/sql"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRandomPayment(t *testing.T, user db.User, order db.Order) db.Payment {
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+
+	payment, err := testQueries.CreatePayment(context.Background(), db.CreatePaymentParams{
+		ID:        id,
+		Reference: "RNK-" + id,
+		UserID:    user.ID,
+		OrderID:   sql.NullString{String: order.ID, Valid: true},
+		Provider:  "fake",
+		Amount:    order.Total,
+		Currency:  order.Total.Currency(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PaymentPending, payment.Status)
+
+	return payment
+}
+
+func TestFakePaymentProvider(t *testing.T) {
+	fake := utils.NewFakePaymentProvider()
+	ctx := context.Background()
+
+	session, err := fake.Initialize(ctx, utils.PaymentRequest{Reference: "ref-1", Amount: utils.Naira(2500)})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, session.AuthorizationURL)
+
+	result, err := fake.Verify(ctx, "ref-1")
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PaymentPending, result.Status)
+
+	_, err = fake.Refund(ctx, utils.RefundRequest{Reference: "ref-1", Amount: utils.Naira(100)})
+	assert.Error(t, err)
+
+	assert.NoError(t, fake.Complete("ref-1", true))
+	result, err = fake.Verify(ctx, "ref-1")
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PaymentSucceeded, result.Status)
+	assert.Equal(t, utils.Naira(2500), result.Amount)
+
+	// Settled payments stay settled.
+	assert.NoError(t, fake.Complete("ref-1", false))
+	result, _ = fake.Verify(ctx, "ref-1")
+	assert.Equal(t, utils.PaymentSucceeded, result.Status)
+
+	_, err = fake.Refund(ctx, utils.RefundRequest{Reference: "ref-1", Amount: utils.Naira(3000)})
+	assert.Error(t, err)
+	refund, err := fake.Refund(ctx, utils.RefundRequest{Reference: "ref-1", Amount: utils.Naira(1000)})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, refund.ProviderReference)
+
+	// A refund sent again under its ID is not paid twice.
+	first, err := fake.Refund(ctx, utils.RefundRequest{ID: "refund-1", Reference: "ref-1", Amount: utils.Naira(500)})
+	assert.NoError(t, err)
+	again, err := fake.Refund(ctx, utils.RefundRequest{ID: "refund-1", Reference: "ref-1", Amount: utils.Naira(500)})
+	assert.NoError(t, err)
+	assert.Equal(t, first.ProviderReference, again.ProviderReference)
+	assert.NotEqual(t, refund.ProviderReference, first.ProviderReference)
+
+	_, err = fake.Verify(ctx, "missing")
+	assert.ErrorIs(t, err, utils.ErrPaymentNotFound)
+}
+
+func TestNewPaymentProvider(t *testing.T) {
+	// The fake provider settles payments for anyone, so it is never the
+	// default.
+	_, err := utils.NewPaymentProvider(&utils.Config{})
+	assert.Error(t, err)
+
+	provider, err := utils.NewPaymentProvider(&utils.Config{PaymentProvider: "fake"})
+	assert.NoError(t, err)
+	assert.Equal(t, "fake", provider.Name())
+}
+
+func TestPaymentWebhookSignatures(t *testing.T) {
+	body := []byte(`{"event":"charge.success","data":{"reference":"ref-1","tx_ref":"ref-1"}}`)
+
+	paystack, err := utils.NewPaystackProvider("sk_test_secret")
+	assert.NoError(t, err)
+
+	mac := hmac.New(sha512.New, []byte("sk_test_secret"))
+	mac.Write(body)
+	header := http.Header{}
+	header.Set("x-paystack-signature", hex.EncodeToString(mac.Sum(nil)))
+
+	reference, err := paystack.Webhook(header, body)
+	assert.NoError(t, err)
+	assert.Equal(t, "ref-1", reference)
+
+	_, err = paystack.Webhook(header, append(body, ' '))
+	assert.ErrorIs(t, err, utils.ErrInvalidSignature)
+	_, err = paystack.Webhook(http.Header{}, body)
+	assert.ErrorIs(t, err, utils.ErrInvalidSignature)
+
+	flutterwave, err := utils.NewFlutterwaveProvider("FLWSECK_TEST", "hash")
+	assert.NoError(t, err)
+
+	header = http.Header{}
+	header.Set("verif-hash", "hash")
+	reference, err = flutterwave.Webhook(header, body)
+	assert.NoError(t, err)
+	assert.Equal(t, "ref-1", reference)
+
+	header.Set("verif-hash", "wrong")
+	_, err = flutterwave.Webhook(header, body)
+	assert.ErrorIs(t, err, utils.ErrInvalidSignature)
+
+	fake := utils.NewFakePaymentProvider()
+	body = []byte(`{"reference":"ref-2"}`)
+	header = http.Header{}
+	header.Set(utils.FakePaymentHeader, fake.Sign(body))
+	reference, err = fake.Webhook(header, body)
+	assert.NoError(t, err)
+	assert.Equal(t, "ref-2", reference)
+
+	_, err = utils.NewPaystackProvider("")
+	assert.Error(t, err)
+}
+
+func TestPaymentChannel(t *testing.T) {
+	assert.Equal(t, "Card via Paystack", utils.PaymentChannel("card", "paystack"))
+	assert.Equal(t, "Bank transfer via Flutterwave", utils.PaymentChannel("banktransfer", "flutterwave"))
+	assert.Equal(t, "Online payment", utils.PaymentChannel("", ""))
+}
+
+func TestSettlePayment(t *testing.T) {
+	user := createRandomUser(t)
+	shop := createRandomShop(t)
+	order := createRandomOrder(t, user, shop)
+	payment := createRandomPayment(t, user, order)
+
+	settled, err := testQueries.SettlePayment(context.Background(), db.SettlePaymentParams{
+		ID:                payment.ID,
+		Status:            utils.PaymentSucceeded,
+		ProviderReference: "fake_" + payment.Reference,
+		Channel:           "card",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PaymentSucceeded, settled.Status)
+
+	// A repeated webhook finds nothing left to settle.
+	_, err = testQueries.SettlePayment(context.Background(), db.SettlePaymentParams{
+		ID:     payment.ID,
+		Status: utils.PaymentFailed,
+	})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	assert.NoError(t, testQueries.SetOrderPaymentStatus(context.Background(), db.SetOrderPaymentStatusParams{
+		ID:            order.ID,
+		PaymentStatus: utils.OrderPaid,
+	}))
+
+	got, err := testQueries.GetOrderPayment(context.Background(), order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, payment.ID, got.ID)
+
+	paid, err := testQueries.CountSucceededPayments(context.Background(), db.CountSucceededPaymentsParams{
+		OrderID: sql.NullString{String: order.ID, Valid: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), paid)
+
+	// Once refunded, a late payment result leaves the order alone.
+	assert.NoError(t, testQueries.SetOrderRefundStatus(context.Background(), db.SetOrderRefundStatusParams{
+		ID:            order.ID,
+		PaymentStatus: utils.OrderRefunded,
+	}))
+	assert.NoError(t, testQueries.SetOrderPaymentStatus(context.Background(), db.SetOrderPaymentStatusParams{
+		ID:            order.ID,
+		PaymentStatus: utils.OrderPaid,
+	}))
+
+	refunded, err := testQueries.GetOrder(context.Background(), order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.OrderRefunded, refunded.PaymentStatus)
+}
+
+func TestDuplicatePayment(t *testing.T) {
+	user := createRandomUser(t)
+	shop := createRandomShop(t)
+	order := createRandomOrder(t, user, shop)
+	first := createRandomPayment(t, user, order)
+	second := createRandomPayment(t, user, order)
+	target := db.GetPendingPaymentParams{OrderID: sql.NullString{String: order.ID, Valid: true}}
+
+	pending, err := testQueries.GetPendingPayment(context.Background(), target)
+	assert.NoError(t, err)
+	assert.Equal(t, second.ID, pending.ID)
+
+	for _, payment := range []db.Payment{first, second} {
+		_, err := testQueries.SettlePayment(context.Background(), db.SettlePaymentParams{
+			ID:                payment.ID,
+			Status:            utils.PaymentSucceeded,
+			ProviderReference: "fake_" + payment.Reference,
+			Channel:           "card",
+		})
+		assert.NoError(t, err)
+	}
+
+	_, err = testQueries.GetPendingPayment(context.Background(), target)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	flagged, err := testQueries.FlagDuplicatePayment(context.Background(), second.ID)
+	assert.NoError(t, err)
+	assert.True(t, flagged.Duplicate)
+
+	assert.NoError(t, testQueries.SetOrderPaymentStatus(context.Background(), db.SetOrderPaymentStatusParams{
+		ID:            order.ID,
+		PaymentStatus: utils.OrderPaid,
+	}))
+
+	// Refunds for the order come out of the payment that counted.
+	got, err := testQueries.GetOrderPayment(context.Background(), order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, first.ID, got.ID)
+}
@@ -0,0 +1,62 @@
+package all_test
+
+import (
+	"context"
+	"testing"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRandomProductOption(t *testing.T, product db.Product, priceDelta utils.Money) db.ProductOption {
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+
+	arg := db.CreateProductOptionParams{
+		ID:         id,
+		ProductID:  product.ID,
+		GroupName:  "size",
+		Name:       utils.RandomName(),
+		PriceDelta: priceDelta,
+	}
+
+	option, err := testQueries.CreateProductOption(context.Background(), arg)
+	assert.NoError(t, err)
+	assert.Equal(t, option.ProductID, arg.ProductID)
+	assert.Equal(t, option.PriceDelta, arg.PriceDelta)
+	assert.True(t, option.IsAvailable)
+
+	return option
+}
+
+func TestProductOptions(t *testing.T) {
+	shop := createRandomShop(t)
+	product := createRandomProduct(t, shop, []string{}, []string{}, 0)
+
+	large := createRandomProductOption(t, product, utils.Naira(500))
+	small := createRandomProductOption(t, product, utils.Naira(0))
+
+	options, err := testQueries.ListProductOptionsByIDs(context.Background(), []string{large.ID, small.ID})
+	assert.NoError(t, err)
+	assert.Len(t, options, 2)
+
+	updated, err := testQueries.UpdateProductOptionAvailability(context.Background(), db.UpdateProductOptionAvailabilityParams{
+		ID:          large.ID,
+		ProductID:   product.ID,
+		IsAvailable: false,
+	})
+	assert.NoError(t, err)
+	assert.False(t, updated.IsAvailable)
+
+	err = testQueries.DeleteProductOption(context.Background(), db.DeleteProductOptionParams{
+		ID:        small.ID,
+		ProductID: product.ID,
+	})
+	assert.NoError(t, err)
+
+	options, err = testQueries.ListProductOptions(context.Background(), product.ID)
+	assert.NoError(t, err)
+	assert.Len(t, options, 1)
+	assert.Equal(t, options[0].ID, large.ID)
+}
@@ -0,0 +1,149 @@
+package all_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/api"
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRandomShop(t *testing.T) db.Shop {
+	owner := createRandomUser(t)
+
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+
+	arg := db.CreateShopParams{
+		ID:      id,
+		OwnerID: owner.ID,
+		Name:    utils.RandomName(),
+		Address: utils.RandomAddress(),
+		Phone:   utils.RandomPhone(),
+	}
+
+	shop, err := testQueries.CreateShop(context.Background(), arg)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, shop)
+	assert.Equal(t, shop.OwnerID, arg.OwnerID)
+	assert.Equal(t, shop.Name, arg.Name)
+	assert.True(t, shop.IsOpen)
+	assert.WithinDuration(t, shop.CreatedAt, time.Now(), 2*time.Second)
+
+	return shop
+}
+
+func createRandomProduct(t *testing.T, shop db.Shop, tags, allergens []string, spice int16) db.Product {
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+
+	arg := db.CreateProductParams{
+		ID:          id,
+		ShopID:      shop.ID,
+		Name:        utils.RandomName(),
+		Description: utils.RandomText(),
+		Category:    "mains",
+		Price:       utils.Naira(1500),
+		ImageUrls:   []string{},
+		DietaryTags: tags,
+		SpiceLevel:  spice,
+		Allergens:   allergens,
+	}
+
+	product, err := testQueries.CreateProduct(context.Background(), arg)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, product)
+	assert.Equal(t, product.ShopID, arg.ShopID)
+	assert.Equal(t, product.Price, arg.Price)
+	assert.ElementsMatch(t, product.DietaryTags, arg.DietaryTags)
+	assert.ElementsMatch(t, product.Allergens, arg.Allergens)
+	assert.Equal(t, product.SpiceLevel, arg.SpiceLevel)
+	assert.True(t, product.IsAvailable)
+
+	return product
+}
+
+func TestCreateProduct(t *testing.T) {
+	shop := createRandomShop(t)
+	createRandomProduct(t, shop, []string{utils.TagHalal}, []string{utils.AllergenNuts}, 1)
+}
+
+func TestListProductsDietaryFilters(t *testing.T) {
+	shop := createRandomShop(t)
+
+	veggie := createRandomProduct(t, shop, []string{utils.TagVegetarian, utils.TagHalal}, []string{utils.AllergenDairy}, 0)
+	spicy := createRandomProduct(t, shop, []string{utils.TagHalal}, []string{utils.AllergenShellfish}, 3)
+	nutty := createRandomProduct(t, shop, []string{utils.TagVegetarian}, []string{utils.AllergenNuts, utils.AllergenGluten}, 1)
+
+	list := func(tags, exclude []string, maxSpice int16) []string {
+		products, err := testQueries.ListProducts(context.Background(), db.ListProductsParams{
+			ShopID:           sql.NullString{String: shop.ID, Valid: true},
+			DietaryTags:      tags,
+			ExcludeAllergens: exclude,
+			MaxSpiceLevel:    maxSpice,
+			Limit:            10,
+			Offset:           0,
+		})
+		assert.NoError(t, err)
+
+		ids := []string{}
+		for _, product := range products {
+			ids = append(ids, product.ID)
+		}
+		return ids
+	}
+
+	assert.ElementsMatch(t, list([]string{}, []string{}, 3), []string{veggie.ID, spicy.ID, nutty.ID})
+	assert.ElementsMatch(t, list([]string{utils.TagVegetarian}, []string{}, 3), []string{veggie.ID, nutty.ID})
+	assert.ElementsMatch(t, list([]string{utils.TagVegetarian, utils.TagHalal}, []string{}, 3), []string{veggie.ID})
+	assert.ElementsMatch(t, list([]string{}, []string{utils.AllergenNuts, utils.AllergenShellfish}, 3), []string{veggie.ID})
+	assert.ElementsMatch(t, list([]string{}, []string{}, 1), []string{veggie.ID, nutty.ID})
+}
+
+func TestUpsertAllergenProfile(t *testing.T) {
+	user := createRandomUser(t)
+
+	_, err := testQueries.GetAllergenProfile(context.Background(), user.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	profile, err := testQueries.UpsertAllergenProfile(context.Background(), db.UpsertAllergenProfileParams{
+		UserID:     user.ID,
+		Allergens:  []string{utils.AllergenNuts},
+		HideUnsafe: false,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, profile.Allergens, []string{utils.AllergenNuts})
+
+	profile, err = testQueries.UpsertAllergenProfile(context.Background(), db.UpsertAllergenProfileParams{
+		UserID:     user.ID,
+		Allergens:  []string{utils.AllergenDairy, utils.AllergenGluten},
+		HideUnsafe: true,
+	})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, profile.Allergens, []string{utils.AllergenDairy, utils.AllergenGluten})
+	assert.True(t, profile.HideUnsafe)
+
+	assert.Equal(t, utils.UnsafeAllergens([]string{utils.AllergenGluten, utils.AllergenSoy}, profile.Allergens), []string{utils.AllergenGluten})
+}
+
+func TestDietaryValidation(t *testing.T) {
+	validate := validator.New()
+	assert.NoError(t, validate.RegisterValidation("dietaryTag", api.DietaryTagValidation))
+	assert.NoError(t, validate.RegisterValidation("allergen", api.AllergenValidation))
+
+	type labels struct {
+		DietaryTags []string `validate:"omitempty,dive,dietaryTag"`
+		Allergens   []string `validate:"omitempty,dive,allergen"`
+	}
+
+	assert.NoError(t, validate.Struct(labels{}))
+	assert.NoError(t, validate.Struct(labels{DietaryTags: utils.DietaryTags, Allergens: utils.Allergens}))
+	assert.Error(t, validate.Struct(labels{DietaryTags: []string{utils.TagVegan, "keto"}}))
+	assert.Error(t, validate.Struct(labels{Allergens: []string{utils.AllergenSoy, "Soy"}}))
+	assert.Error(t, validate.Struct(labels{Allergens: []string{utils.TagHalal}}))
+}
@@ -0,0 +1,45 @@
+package all_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderReceipt(t *testing.T) {
+	receipt := utils.Receipt{
+		ShopName:      "Ìyá Ọlọ́jà Kitchen",
+		CustomerName:  "Adéṣọlá Ńkẹ́",
+		InvoiceNumber: utils.InvoiceNumber(42),
+		OrderID:       "order-1",
+		PlacedAt:      time.Now(),
+		DeliveredAt:   time.Now(),
+		Subtotal:      utils.Naira(2000),
+		VAT:           utils.Naira(150),
+		VATRate:       utils.FormatBasisPoints(750),
+		Total:         utils.Naira(2150),
+		Lines: []utils.ReceiptLine{
+			{Name: "Jollof rice", Quantity: 2, UnitPrice: utils.Naira(1000), LineTotal: utils.Naira(2000), Options: []string{"Size: Large (₦500)"}},
+		},
+	}
+
+	pdf := utils.RenderReceipt(receipt)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4")))
+	assert.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	assert.Contains(t, string(pdf), "/Encoding /Identity-H")
+	assert.Contains(t, string(pdf), "/FontFile2")
+	assert.Equal(t, 1, bytes.Count(pdf, []byte("/Type /Page ")))
+
+	// Yoruba letters and the naira sign are kept, not replaced with "?".
+	for _, char := range []string{"<1ECD>", "<1EB9>", "<1E63>", "<0143>", "<20A6>"} {
+		assert.Contains(t, string(pdf), char)
+	}
+
+	for i := 0; i < 60; i++ {
+		receipt.Lines = append(receipt.Lines, receipt.Lines[0])
+	}
+	assert.Greater(t, bytes.Count(utils.RenderReceipt(receipt), []byte("/Type /Page ")), 1)
+}
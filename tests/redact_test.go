@@ -0,0 +1,27 @@
+package all_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactQuery(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/orders/1/track", "/orders/1/track"},
+		{"/orders/1/track?access_token=eyJhbGciOi.x.y", "/orders/1/track?access_token=REDACTED"},
+		{"/orders/1/track?a=1&access_token=secret", "/orders/1/track?a=1&access_token=REDACTED"},
+		{"/products?page=2", "/products?page=2"},
+		{"/orders/1/track?access_token=%zz", "/orders/1/track?REDACTED"},
+	}
+
+	for _, test := range tests {
+		got := utils.RedactQuery(test.path, utils.SecretQueryParams...)
+		assert.Equal(t, test.want, got, test.path)
+		assert.NotContains(t, got, "secret")
+	}
+}
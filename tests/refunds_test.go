@@ -0,0 +1,223 @@
+package all_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRandomRefund(t *testing.T, order db.Order, payment db.Payment, amount utils.Money, status string) db.Refund {
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+
+	refund, err := testQueries.CreateRefund(context.Background(), db.CreateRefundParams{
+		ID:        id,
+		OrderID:   order.ID,
+		PaymentID: payment.ID,
+		Source:    utils.RefundBySupport,
+		Reason:    "Missing item",
+		Amount:    amount,
+		TipAmount: utils.Kobo(0),
+		RefundTo:  utils.RefundToOriginal,
+		Status:    status,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, status, refund.Status)
+
+	return refund
+}
+
+func TestItemRefund(t *testing.T) {
+	// Two of three at ₦1,000 each, on an order whose ₦3,000 of food came to
+	// ₦3,450 with service charge and VAT.
+	amount := utils.ItemRefund(utils.Naira(3000), 2, 3, utils.Naira(3450), utils.Naira(3000))
+	assert.Equal(t, utils.Naira(2300), amount)
+
+	// Without a subtotal there is nothing to share out.
+	amount = utils.ItemRefund(utils.Naira(3000), 1, 3, utils.Naira(0), utils.Naira(0))
+	assert.Equal(t, utils.Naira(1000), amount)
+}
+
+func TestSplitRefund(t *testing.T) {
+	total, tip := utils.Naira(5000), utils.Naira(500)
+
+	sales, tips := utils.SplitRefund(utils.Naira(2000), utils.Naira(0), total, tip)
+	assert.Equal(t, utils.Naira(2000), sales)
+	assert.True(t, tips.IsZero())
+
+	// The tip only goes once everything else has been given back.
+	sales, tips = utils.SplitRefund(utils.Naira(3000), utils.Naira(2000), total, tip)
+	assert.Equal(t, utils.Naira(2500), sales)
+	assert.Equal(t, utils.Naira(500), tips)
+
+	sales, tips = utils.SplitRefund(utils.Naira(300), utils.Naira(4700), total, tip)
+	assert.True(t, sales.IsZero())
+	assert.Equal(t, utils.Naira(300), tips)
+}
+
+func TestNeedsApproval(t *testing.T) {
+	assert.False(t, utils.NeedsApproval(utils.Naira(20000), utils.DefaultRefundApproval))
+	assert.True(t, utils.NeedsApproval(utils.MustParseMoney("20000.01"), utils.DefaultRefundApproval))
+}
+
+func TestFakeRefundStatus(t *testing.T) {
+	fake := utils.NewFakePaymentProvider()
+	ctx := context.Background()
+
+	_, err := fake.Initialize(ctx, utils.PaymentRequest{Reference: "ref-1", Amount: utils.Naira(2500)})
+	assert.NoError(t, err)
+	assert.NoError(t, fake.Complete("ref-1", true))
+
+	refund, err := fake.Refund(ctx, utils.RefundRequest{Reference: "ref-1", Amount: utils.Naira(1000)})
+	assert.NoError(t, err)
+	assert.Equal(t, utils.RefundCompleted, refund.Status)
+
+	status, err := fake.RefundStatus(ctx, refund.ProviderReference)
+	assert.NoError(t, err)
+	assert.Equal(t, refund, status)
+
+	_, err = fake.RefundStatus(ctx, "missing")
+	assert.ErrorIs(t, err, utils.ErrPaymentNotFound)
+}
+
+func TestOrderRefunds(t *testing.T) {
+	user := createRandomUser(t)
+	shop := createRandomShop(t)
+	order := createRandomOrder(t, user, shop)
+	payment := createRandomPayment(t, user, order)
+
+	itemId, err := utils.NewID()
+	assert.NoError(t, err)
+	item, err := testQueries.CreateOrderItem(context.Background(), db.CreateOrderItemParams{
+		ID:          itemId,
+		OrderID:     order.ID,
+		ProductName: "Jollof rice",
+		UnitPrice:   utils.Naira(1000),
+		Quantity:    3,
+		LineTotal:   utils.Naira(3000),
+	})
+	assert.NoError(t, err)
+
+	partial := createRandomRefund(t, order, payment, utils.Naira(1000), utils.RefundPending)
+	_, err = testQueries.CreateRefundItem(context.Background(), db.CreateRefundItemParams{
+		RefundID:    partial.ID,
+		OrderItemID: item.ID,
+		Quantity:    1,
+		Amount:      utils.Naira(1000),
+	})
+	assert.NoError(t, err)
+
+	large := createRandomRefund(t, order, payment, utils.Naira(2000), utils.RefundAwaitingApproval)
+
+	// Refunds waiting for approval count against what is left; rejected
+	// ones stop counting.
+	totals, err := testQueries.GetOrderRefundTotals(context.Background(), order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.Naira(3000), totals.Committed)
+	assert.True(t, totals.Refunded.IsZero())
+
+	large, err = testQueries.ReviewRefund(context.Background(), db.ReviewRefundParams{
+		ID:         large.ID,
+		Status:     utils.RefundRejected,
+		ReviewedBy: sql.NullString{String: user.ID, Valid: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, utils.RefundRejected, large.Status)
+
+	_, err = testQueries.ReviewRefund(context.Background(), db.ReviewRefundParams{
+		ID:     large.ID,
+		Status: utils.RefundPending,
+	})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	quantities, err := testQueries.ListRefundedQuantities(context.Background(), order.ID)
+	assert.NoError(t, err)
+	assert.Len(t, quantities, 1)
+	assert.Equal(t, int64(1), quantities[0].Quantity)
+
+	// A payment never gives back more than it took.
+	_, err = testQueries.AdjustPaymentRefunded(context.Background(), db.AdjustPaymentRefundedParams{
+		ID:     payment.ID,
+		Amount: payment.Amount.Add(utils.Kobo(1)),
+	})
+	assert.Error(t, err)
+
+	got, err := testQueries.AdjustPaymentRefunded(context.Background(), db.AdjustPaymentRefundedParams{
+		ID:     payment.ID,
+		Amount: partial.Amount,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, utils.Naira(1000), got.RefundedAmount)
+
+	partial, err = testQueries.SetRefundStatus(context.Background(), db.SetRefundStatusParams{
+		ID:                partial.ID,
+		FromStatus:        utils.RefundPending,
+		ToStatus:          utils.RefundCompleted,
+		ProviderReference: "fake_refund_1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "fake_refund_1", partial.ProviderReference)
+
+	_, err = testQueries.SetRefundStatus(context.Background(), db.SetRefundStatusParams{
+		ID:         partial.ID,
+		FromStatus: utils.RefundPending,
+		ToStatus:   utils.RefundFailed,
+	})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	totals, err = testQueries.GetOrderRefundTotals(context.Background(), order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.Naira(1000), totals.Committed)
+	assert.Equal(t, utils.Naira(1000), totals.Refunded)
+
+	// Only paid orders move to refunded.
+	assert.NoError(t, testQueries.SetOrderRefundStatus(context.Background(), db.SetOrderRefundStatusParams{
+		ID:            order.ID,
+		PaymentStatus: utils.OrderPartiallyRefunded,
+	}))
+	unpaid, err := testQueries.GetOrder(context.Background(), order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.OrderUnpaid, unpaid.PaymentStatus)
+
+	assert.NoError(t, testQueries.SetOrderPaymentStatus(context.Background(), db.SetOrderPaymentStatusParams{
+		ID:            order.ID,
+		PaymentStatus: utils.OrderPaid,
+	}))
+	assert.NoError(t, testQueries.SetOrderRefundStatus(context.Background(), db.SetOrderRefundStatusParams{
+		ID:            order.ID,
+		PaymentStatus: utils.OrderPartiallyRefunded,
+	}))
+	refunded, err := testQueries.GetOrder(context.Background(), order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.OrderPartiallyRefunded, refunded.PaymentStatus)
+
+	refunds, err := testQueries.ListOrderRefunds(context.Background(), order.ID)
+	assert.NoError(t, err)
+	assert.Len(t, refunds, 2)
+}
+
+func TestClaimRefund(t *testing.T) {
+	user := createRandomUser(t)
+	shop := createRandomShop(t)
+	order := createRandomOrder(t, user, shop)
+	payment := createRandomPayment(t, user, order)
+	refund := createRandomRefund(t, order, payment, utils.Naira(500), utils.RefundProcessing)
+
+	claimed, err := testQueries.ClaimRefund(context.Background(), db.ClaimRefundParams{
+		ID:        refund.ID,
+		UpdatedAt: refund.UpdatedAt,
+	})
+	assert.NoError(t, err)
+	assert.True(t, claimed.UpdatedAt.After(refund.UpdatedAt))
+
+	// A second instance that listed the refund before the claim loses.
+	_, err = testQueries.ClaimRefund(context.Background(), db.ClaimRefundParams{
+		ID:        refund.ID,
+		UpdatedAt: refund.UpdatedAt,
+	})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,144 @@
+package all_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRandomReview(t *testing.T, shop db.Shop, product db.Product, shopRating, itemRating int16) db.Review {
+	user := createRandomUser(t)
+	order := createRandomOrder(t, user, shop)
+
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+
+	review, err := testQueries.CreateReview(context.Background(), db.CreateReviewParams{
+		ID:         id,
+		UserID:     user.ID,
+		ShopID:     shop.ID,
+		OrderID:    sql.NullString{String: order.ID, Valid: true},
+		ShopRating: shopRating,
+		Comment:    utils.RandomText(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, review.Status, "published")
+
+	itemId, err := utils.NewID()
+	assert.NoError(t, err)
+
+	item, err := testQueries.CreateReviewItem(context.Background(), db.CreateReviewItemParams{
+		ID:        itemId,
+		ReviewID:  review.ID,
+		ProductID: product.ID,
+		Rating:    itemRating,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, item.Rating, itemRating)
+
+	return review
+}
+
+func TestRefreshRatings(t *testing.T) {
+	shop := createRandomShop(t)
+	product := createRandomProduct(t, shop, []string{}, []string{}, 0)
+
+	first := createRandomReview(t, shop, product, 5, 4)
+	second := createRandomReview(t, shop, product, 2, 1)
+
+	for _, review := range []db.Review{first, second} {
+		assert.NoError(t, testQueries.RefreshShopRating(context.Background(), review.ShopID))
+		assert.NoError(t, testQueries.RefreshReviewProductRatings(context.Background(), review.ID))
+	}
+
+	shop, err := testQueries.GetShop(context.Background(), shop.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, shop.RatingCount, int32(2))
+	assert.Equal(t, shop.RatingAvg, "3.50")
+
+	product, err = testQueries.GetProduct(context.Background(), product.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, product.RatingCount, int32(2))
+	assert.Equal(t, product.RatingAvg, "2.50")
+
+	// hidden reviews drop out of the aggregate
+	_, err = testQueries.SetReviewStatus(context.Background(), db.SetReviewStatusParams{
+		ID:     second.ID,
+		Status: "hidden",
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, testQueries.RefreshShopRating(context.Background(), shop.ID))
+
+	shop, err = testQueries.GetShop(context.Background(), shop.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, shop.RatingCount, int32(1))
+	assert.Equal(t, shop.RatingAvg, "5.00")
+}
+
+func TestReportedReviewsQueue(t *testing.T) {
+	shop := createRandomShop(t)
+	product := createRandomProduct(t, shop, []string{}, []string{}, 0)
+	review := createRandomReview(t, shop, product, 1, 1)
+
+	for i := 0; i < 2; i++ {
+		reporter := createRandomUser(t)
+		id, err := utils.NewID()
+		assert.NoError(t, err)
+
+		_, err = testQueries.CreateReviewReport(context.Background(), db.CreateReviewReportParams{
+			ID:         id,
+			ReviewID:   review.ID,
+			ReporterID: reporter.ID,
+			Reason:     "spam",
+		})
+		assert.NoError(t, err)
+	}
+
+	reported, err := testQueries.ListReportedReviews(context.Background(), db.ListReportedReviewsParams{
+		Limit:  100,
+		Offset: 0,
+	})
+	assert.NoError(t, err)
+
+	found := false
+	for _, r := range reported {
+		if r.ID == review.ID {
+			found = true
+			assert.Equal(t, r.ReportCount, int32(2))
+		}
+	}
+	assert.True(t, found)
+
+	assert.NoError(t, testQueries.ResolveReviewReports(context.Background(), review.ID))
+
+	reports, err := testQueries.ListReviewReports(context.Background(), review.ID)
+	assert.NoError(t, err)
+	for _, report := range reports {
+		assert.True(t, report.Resolved)
+	}
+
+	_, err = testQueries.GetReview(context.Background(), "does-not-exist")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestOneReviewPerOrder(t *testing.T) {
+	shop := createRandomShop(t)
+	product := createRandomProduct(t, shop, []string{}, []string{}, 0)
+	review := createRandomReview(t, shop, product, 4, 4)
+
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+
+	_, err = testQueries.CreateReview(context.Background(), db.CreateReviewParams{
+		ID:         id,
+		UserID:     review.UserID,
+		ShopID:     shop.ID,
+		OrderID:    review.OrderID,
+		ShopRating: 1,
+	})
+	assert.Error(t, err)
+}
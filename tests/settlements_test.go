@@ -0,0 +1,276 @@
+package all_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRandomVendorEarning(t *testing.T, order db.Order, fees utils.VendorFees) db.VendorEarning {
+	earning := fees.Earning(order.Total.Sub(order.DeliveryFee), order.Subtotal)
+
+	got, err := testQueries.CreateVendorEarning(context.Background(), db.CreateVendorEarningParams{
+		OrderID:                  order.ID,
+		ShopID:                   order.ShopID,
+		Gross:                    earning.Gross,
+		Commission:               earning.Commission,
+		ProcessingFee:            earning.ProcessingFee,
+		Net:                      earning.Net,
+		CommissionPercent:        int32(fees.CommissionPercent),
+		ProcessingFeeBasisPoints: int32(fees.ProcessingFeeBasisPoints),
+	})
+	assert.NoError(t, err)
+	assert.False(t, got.SettlementID.Valid)
+
+	return got
+}
+
+func TestVendorEarning(t *testing.T) {
+	fees := utils.VendorFees{CommissionPercent: 15, ProcessingFeeBasisPoints: 150}
+
+	// Commission is on the food only; the processing fee on everything the
+	// customer paid the shop.
+	earning := fees.Earning(utils.Naira(4300), utils.Naira(4000))
+	assert.Equal(t, utils.Naira(600), earning.Commission)
+	assert.Equal(t, utils.Kobo(6450), earning.ProcessingFee)
+	assert.Equal(t, utils.Kobo(430000-60000-6450), earning.Net)
+	assert.Equal(t, earning.Gross, earning.Net.Add(earning.Commission).Add(earning.ProcessingFee))
+}
+
+func TestSettlementPeriod(t *testing.T) {
+	lagos, err := time.LoadLocation("Africa/Lagos")
+	assert.NoError(t, err)
+
+	// Wednesday evening falls in the week from the Monday before.
+	start, end := utils.SettlementPeriod(time.Date(2024, 5, 15, 21, 30, 0, 0, lagos))
+	assert.Equal(t, time.Date(2024, 5, 13, 0, 0, 0, 0, lagos), start)
+	assert.Equal(t, time.Date(2024, 5, 20, 0, 0, 0, 0, lagos), end)
+
+	// Sunday is the last day of its week, Monday midnight the first.
+	start, _ = utils.SettlementPeriod(time.Date(2024, 5, 19, 23, 59, 0, 0, lagos))
+	assert.Equal(t, time.Date(2024, 5, 13, 0, 0, 0, 0, lagos), start)
+	start, _ = utils.SettlementPeriod(time.Date(2024, 5, 20, 0, 0, 0, 0, lagos))
+	assert.Equal(t, time.Date(2024, 5, 20, 0, 0, 0, 0, lagos), start)
+}
+
+func TestFakePayoutProvider(t *testing.T) {
+	fake := utils.NewFakePayoutProvider()
+	ctx := context.Background()
+
+	_, err := fake.VerifyAccount(ctx, utils.BankAccount{BankCode: "058", AccountNumber: "12345"})
+	assert.ErrorIs(t, err, utils.ErrAccountNotFound)
+
+	account, err := fake.VerifyAccount(ctx, utils.BankAccount{BankCode: "058", AccountNumber: "0123456789"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, account.AccountName)
+	assert.NotEmpty(t, account.RecipientCode)
+
+	result, err := fake.Transfer(ctx, utils.TransferRequest{Reference: "payout-1", Amount: utils.Naira(5000), RecipientCode: account.RecipientCode})
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PayoutPaid, result.Status)
+
+	status, err := fake.TransferStatus(ctx, "payout-1")
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PayoutPaid, status.Status)
+
+	failing, err := fake.VerifyAccount(ctx, utils.BankAccount{BankCode: "058", AccountNumber: "0123456780"})
+	assert.NoError(t, err)
+	result, err = fake.Transfer(ctx, utils.TransferRequest{Reference: "payout-2", Amount: utils.Naira(5000), RecipientCode: failing.RecipientCode})
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PayoutFailed, result.Status)
+
+	// A transfer the provider refused outright is safe to give back; one it
+	// never heard of is not known yet.
+	_, err = fake.Transfer(ctx, utils.TransferRequest{Reference: "payout-3", Amount: utils.Naira(5000), RecipientCode: "unknown"})
+	assert.True(t, utils.Declined(err))
+	_, err = fake.TransferStatus(ctx, "payout-4")
+	assert.ErrorIs(t, err, utils.ErrPaymentNotFound)
+	assert.False(t, utils.Declined(err))
+}
+
+func TestCloseSettlement(t *testing.T) {
+	user := createRandomUser(t)
+	shop := createRandomShop(t)
+	fees := utils.VendorFees{CommissionPercent: utils.DefaultVendorCommission, ProcessingFeeBasisPoints: utils.DefaultProcessingFeeBasisPoints}
+
+	first := createRandomVendorEarning(t, createRandomOrder(t, user, shop), fees)
+	second := createRandomVendorEarning(t, createRandomOrder(t, user, shop), fees)
+
+	// An order only ever earns once.
+	_, err := testQueries.CreateVendorEarning(context.Background(), db.CreateVendorEarningParams{
+		OrderID: first.OrderID,
+		ShopID:  shop.ID,
+	})
+	assert.Error(t, err)
+
+	pending, err := testQueries.GetUnsettledEarnings(context.Background(), shop.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), pending.Orders)
+	assert.Equal(t, first.Net.Add(second.Net), pending.Net)
+
+	start, end := utils.SettlementPeriod(time.Now())
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+	settlement, err := testQueries.CreateSettlement(context.Background(), db.CreateSettlementParams{
+		ID:          id,
+		ShopID:      shop.ID,
+		PeriodStart: start,
+		PeriodEnd:   end,
+	})
+	assert.NoError(t, err)
+
+	// A shop's week is only ever settled once.
+	again, err := utils.NewID()
+	assert.NoError(t, err)
+	_, err = testQueries.CreateSettlement(context.Background(), db.CreateSettlementParams{
+		ID:          again,
+		ShopID:      shop.ID,
+		PeriodStart: start,
+		PeriodEnd:   end,
+	})
+	assert.Error(t, err)
+
+	earnings, err := testQueries.AttachSettlementEarnings(context.Background(), db.AttachSettlementEarningsParams{
+		SettlementID: settlement.ID,
+		ShopID:       shop.ID,
+		PeriodStart:  start,
+		PeriodEnd:    end,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, earnings, 2)
+
+	settlement, err = testQueries.AddSettlementTotals(context.Background(), db.AddSettlementTotalsParams{
+		ID:            settlement.ID,
+		OrderCount:    2,
+		Gross:         first.Gross.Add(second.Gross),
+		Commission:    first.Commission.Add(second.Commission),
+		ProcessingFee: first.ProcessingFee.Add(second.ProcessingFee),
+		Net:           first.Net.Add(second.Net),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), settlement.OrderCount)
+
+	pending, err = testQueries.GetUnsettledEarnings(context.Background(), shop.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), pending.Orders)
+
+	// An earning that turns up after its week was closed joins the same
+	// settlement.
+	late := createRandomVendorEarning(t, createRandomOrder(t, user, shop), fees)
+	existing, err := testQueries.GetShopSettlementForPeriod(context.Background(), db.GetShopSettlementForPeriodParams{
+		ShopID:      shop.ID,
+		PeriodStart: start,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, settlement.ID, existing.ID)
+
+	earnings, err = testQueries.AttachSettlementEarnings(context.Background(), db.AttachSettlementEarningsParams{
+		SettlementID: existing.ID,
+		ShopID:       shop.ID,
+		PeriodStart:  start,
+		PeriodEnd:    end,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, earnings, 1)
+
+	settlement, err = testQueries.AddSettlementTotals(context.Background(), db.AddSettlementTotalsParams{
+		ID:            existing.ID,
+		OrderCount:    1,
+		Gross:         late.Gross,
+		Commission:    late.Commission,
+		ProcessingFee: late.ProcessingFee,
+		Net:           late.Net,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), settlement.OrderCount)
+	assert.Equal(t, first.Net.Add(second.Net).Add(late.Net), settlement.Net)
+
+	attached, err := testQueries.ListSettlementEarnings(context.Background(), sql.NullString{String: settlement.ID, Valid: true})
+	assert.NoError(t, err)
+	assert.Len(t, attached, 3)
+
+	report, err := testQueries.GetSettlementReport(context.Background(), db.GetSettlementReportParams{
+		ShopID:   shop.ID,
+		FromTime: start,
+		ToTime:   end,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), report.Settlements)
+	assert.Equal(t, int64(3), report.Orders)
+	assert.Equal(t, settlement.Net, report.Net)
+}
+
+func TestShopPayout(t *testing.T) {
+	user := createRandomUser(t)
+	shop := createRandomShop(t)
+
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+	account, err := testQueries.GetOrCreateShopAccount(context.Background(), db.GetOrCreateShopAccountParams{
+		ID:     id,
+		ShopID: sql.NullString{String: shop.ID, Valid: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "shop", account.Kind)
+
+	id, err = utils.NewID()
+	assert.NoError(t, err)
+	settled, err := testQueries.CreateLedgerTransaction(context.Background(), db.CreateLedgerTransactionParams{
+		ID:   id,
+		Kind: utils.LedgerSettlement,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, postLedgerLine(t, settled, utils.AccountSales, utils.Naira(-10000)))
+	assert.NoError(t, postLedgerLine(t, settled, account.ID, utils.Naira(8500)))
+	assert.NoError(t, postLedgerLine(t, settled, utils.AccountCommission, utils.Naira(1500)))
+
+	// A shop cannot be paid more than it has.
+	_, err = testQueries.AdjustLedgerBalance(context.Background(), db.AdjustLedgerBalanceParams{
+		ID:     account.ID,
+		Amount: utils.Naira(-9000),
+	})
+	assert.Error(t, err)
+
+	id, err = utils.NewID()
+	assert.NoError(t, err)
+	payout, err := testQueries.CreatePayout(context.Background(), db.CreatePayoutParams{
+		ID:            id,
+		ShopID:        shop.ID,
+		RequestedBy:   sql.NullString{String: user.ID, Valid: true},
+		Amount:        utils.Naira(8500),
+		BankCode:      "058",
+		AccountNumber: "0123456789",
+		AccountName:   "Test Account",
+		Provider:      "fake",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PayoutPending, payout.Status)
+
+	payout, err = testQueries.UpdatePayoutStatus(context.Background(), db.UpdatePayoutStatusParams{
+		ID:                payout.ID,
+		Status:            utils.PayoutPaid,
+		ProviderReference: "TRF-1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PayoutPaid, payout.Status)
+
+	// A finished payout does not change again.
+	_, err = testQueries.UpdatePayoutStatus(context.Background(), db.UpdatePayoutStatusParams{
+		ID:     payout.ID,
+		Status: utils.PayoutFailed,
+	})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	paidOut, err := testQueries.GetPaidOutTotal(context.Background(), db.GetPaidOutTotalParams{
+		ShopID:   shop.ID,
+		FromTime: time.Now().Add(-time.Hour),
+		ToTime:   time.Now().Add(time.Hour),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, utils.Naira(8500), paidOut)
+}
@@ -0,0 +1,88 @@
+package all_test
+
+import (
+	"context"
+	"testing"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaxRules(t *testing.T) {
+	rules := utils.TaxRules{VATBasisPoints: 750, ExemptCategories: []string{"Groceries"}}
+	lines := []utils.TaxLine{
+		{Amount: utils.Naira(2000), Category: "Mains"},
+		{Amount: utils.Naira(1000), Category: " groceries "},
+	}
+
+	tax := rules.Compute(lines, 1000)
+	assert.Equal(t, utils.Naira(150), tax.Lines[0].VAT)
+	assert.True(t, tax.Lines[1].Exempt)
+	assert.True(t, tax.Lines[1].VAT.IsZero())
+	assert.Equal(t, utils.Naira(300), tax.ServiceCharge)
+	assert.Equal(t, utils.Naira(2300), tax.Taxable)
+	assert.Equal(t, utils.MustParseMoney("172.50"), tax.VAT)
+	assert.Equal(t, utils.MustParseMoney("3472.50"), tax.Total)
+
+	rules.PricesIncludeVAT = true
+	tax = rules.Compute(lines[:1], 0)
+	assert.Equal(t, utils.MustParseMoney("139.53"), tax.VAT)
+	assert.Equal(t, utils.Naira(2000), tax.Total)
+
+	both := tax.Add(rules.Compute(lines[:1], 0))
+	assert.Equal(t, utils.Naira(4000), both.Total)
+	assert.Len(t, both.Lines, 2)
+}
+
+func TestNextInvoiceNumber(t *testing.T) {
+	shop := createRandomShop(t)
+	other := createRandomShop(t)
+
+	for want := int64(1); want <= 3; want++ {
+		number, err := testQueries.NextInvoiceNumber(context.Background(), shop.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, want, number)
+	}
+
+	number, err := testQueries.NextInvoiceNumber(context.Background(), other.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), number)
+	assert.Equal(t, "INV-000001", utils.InvoiceNumber(number))
+}
+
+func TestCreateOrderInvoice(t *testing.T) {
+	user := createRandomUser(t)
+	shop := createRandomShop(t)
+	order := createRandomOrder(t, user, shop)
+
+	number, err := testQueries.NextInvoiceNumber(context.Background(), shop.ID)
+	assert.NoError(t, err)
+
+	invoice, err := testQueries.CreateOrderInvoice(context.Background(), db.CreateOrderInvoiceParams{
+		OrderID:          order.ID,
+		ShopID:           shop.ID,
+		Number:           number,
+		VatBasisPoints:   750,
+		TaxableAmount:    order.Subtotal,
+		Vat:              order.Subtotal.MulRatio(750, 10000),
+		ServiceCharge:    utils.Kobo(0),
+		PricesIncludeVat: false,
+	})
+	assert.NoError(t, err)
+
+	got, err := testQueries.GetOrderInvoice(context.Background(), order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, invoice.Number, got.Number)
+	assert.Equal(t, invoice.Vat, got.Vat)
+
+	_, err = testQueries.CreateOrderInvoice(context.Background(), db.CreateOrderInvoiceParams{
+		OrderID:       createRandomOrder(t, user, shop).ID,
+		ShopID:        shop.ID,
+		Number:        number,
+		TaxableAmount: utils.Kobo(0),
+		Vat:           utils.Kobo(0),
+		ServiceCharge: utils.Kobo(0),
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,129 @@
+package all_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRandomTip(t *testing.T, order db.Order, amount utils.Money, stage string) db.OrderTip {
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+
+	tip, err := testQueries.CreateOrderTip(context.Background(), db.CreateOrderTipParams{
+		ID:      id,
+		OrderID: order.ID,
+		UserID:  order.UserID,
+		Amount:  amount,
+		Stage:   stage,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, utils.TipPending, tip.Status)
+
+	return tip
+}
+
+func TestMoneySplit(t *testing.T) {
+	parts := utils.Kobo(1000).Split(3)
+	assert.Equal(t, []int64{334, 333, 333}, []int64{parts[0].Kobo(), parts[1].Kobo(), parts[2].Kobo()})
+
+	assert.Empty(t, utils.Naira(10).Split(0))
+	assert.True(t, utils.Kobo(0).Split(2)[1].IsZero())
+}
+
+func TestRiderEarnings(t *testing.T) {
+	earnings := utils.NewRiderEarnings(3, utils.Naira(1500), utils.Naira(700), 20)
+	assert.Equal(t, utils.Naira(300), earnings.Commission)
+	assert.Equal(t, utils.Naira(1200), earnings.DeliveryEarnings)
+	assert.Equal(t, utils.Naira(700), earnings.Tips)
+	assert.Equal(t, utils.Naira(1900), earnings.Total)
+}
+
+func TestCreateOrderTip(t *testing.T) {
+	user := createRandomUser(t)
+	shop := createRandomShop(t)
+	order := createRandomOrder(t, user, shop)
+
+	createRandomTip(t, order, utils.Naira(200), utils.TipAtCheckout)
+	createRandomTip(t, order, utils.Naira(500), utils.TipAfterDelivery)
+
+	// One tip per stage.
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+	_, err = testQueries.CreateOrderTip(context.Background(), db.CreateOrderTipParams{
+		ID:      id,
+		OrderID: order.ID,
+		UserID:  user.ID,
+		Amount:  utils.Naira(100),
+		Stage:   utils.TipAfterDelivery,
+	})
+	assert.Error(t, err)
+
+	tips, err := testQueries.ListOrderTips(context.Background(), order.ID)
+	assert.NoError(t, err)
+	assert.Len(t, tips, 2)
+}
+
+func TestGetRiderEarnings(t *testing.T) {
+	user := createRandomUser(t)
+	rider := createRandomUser(t)
+	shop := createRandomShop(t)
+	order := createRandomOrder(t, user, shop)
+
+	_, err := testQueries.AssignOrderRider(context.Background(), db.AssignOrderRiderParams{
+		ID:      order.ID,
+		RiderID: sql.NullString{String: rider.ID, Valid: true},
+	})
+	assert.NoError(t, err)
+
+	_, err = testQueries.UpdateOrderStatus(context.Background(), db.UpdateOrderStatusParams{
+		ToStatus:   utils.OrderDelivered,
+		ID:         order.ID,
+		FromStatus: utils.OrderPending,
+	})
+	assert.NoError(t, err)
+
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+	_, err = testQueries.CreateOrderStatusHistory(context.Background(), db.CreateOrderStatusHistoryParams{
+		ID:        id,
+		OrderID:   order.ID,
+		ToStatus:  utils.OrderDelivered,
+		ActorID:   sql.NullString{String: rider.ID, Valid: true},
+		ActorRole: utils.ActorRider,
+	})
+	assert.NoError(t, err)
+
+	tip := createRandomTip(t, order, utils.Naira(300), utils.TipAfterDelivery)
+
+	deliveredAt, err := testQueries.GetOrderDeliveredAt(context.Background(), order.ID)
+	assert.NoError(t, err)
+
+	arg := db.GetRiderEarningsParams{
+		RiderID:  rider.ID,
+		FromTime: deliveredAt.Add(-time.Minute),
+		ToTime:   deliveredAt.Add(time.Minute),
+	}
+	earnings, err := testQueries.GetRiderEarnings(context.Background(), arg)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), earnings.Deliveries)
+	assert.Equal(t, order.DeliveryFee.Kobo(), earnings.DeliveryFees.Kobo())
+
+	// A tip that has not been paid yet is not earned.
+	assert.True(t, earnings.Tips.IsZero())
+
+	err = testQueries.SetTipStatus(context.Background(), db.SetTipStatusParams{
+		ID:     tip.ID,
+		Status: utils.TipCaptured,
+	})
+	assert.NoError(t, err)
+
+	earnings, err = testQueries.GetRiderEarnings(context.Background(), arg)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.Naira(300), earnings.Tips)
+}
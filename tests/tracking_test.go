@@ -0,0 +1,117 @@
+package all_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/api"
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// openTracking starts following order as user. The stream is closed when
+// the test ends.
+func openTracking(t *testing.T, user db.User, order db.Order) *bufio.Reader {
+	server := httptest.NewServer(newTestServer(t))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	req := newAuthRequest(t, user, http.MethodGet, server.URL+"/orders/"+order.ID+"/track", nil)
+	req.RequestURI = ""
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	assert.NoError(t, err)
+	t.Cleanup(func() { res.Body.Close() })
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	return bufio.NewReader(res.Body)
+}
+
+// readOrderEvent reads the next server-sent event, skipping heartbeats.
+func readOrderEvent(stream *bufio.Reader) (api.OrderEvent, error) {
+	event := api.OrderEvent{}
+	data := ""
+	for {
+		line, err := stream.ReadString('\n')
+		if err != nil {
+			return event, err
+		}
+
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimPrefix(line, "data:")
+		case line == "" && data != "":
+			return event, json.Unmarshal([]byte(data), &event)
+		}
+	}
+}
+
+func TestTrackOrder(t *testing.T) {
+	user := createRandomUser(t)
+	shop := createRandomShop(t)
+	order := createRandomOrder(t, user, shop)
+
+	vendor, err := testQueries.GetUserById(context.Background(), shop.OwnerID)
+	assert.NoError(t, err)
+
+	stream := openTracking(t, user, order)
+
+	// The current status comes first.
+	event, err := readOrderEvent(stream)
+	assert.NoError(t, err)
+	assert.Equal(t, "status", event.Type)
+	assert.Equal(t, order.ID, event.OrderID)
+	assert.Equal(t, utils.OrderPending, event.Status)
+
+	recorder := serveAs(t, vendor, http.MethodPut, "/vendor/orders/"+order.ID+"/status", gin.H{"status": utils.OrderRejected}, nil)
+	assert.Equal(t, http.StatusAccepted, recorder.Code, recorder.Body.String())
+
+	event, err = readOrderEvent(stream)
+	assert.NoError(t, err)
+	assert.Equal(t, "status", event.Type)
+	assert.Equal(t, utils.OrderRejected, event.Status)
+
+	// Nothing more can happen to a rejected order, so the stream ends.
+	_, err = readOrderEvent(stream)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestTrackFinishedOrder(t *testing.T) {
+	user := createRandomUser(t)
+	order := createRandomOrder(t, user, createRandomShop(t))
+
+	_, err := testQueries.UpdateOrderStatus(context.Background(), db.UpdateOrderStatusParams{
+		ToStatus:   utils.OrderRejected,
+		ID:         order.ID,
+		FromStatus: utils.OrderPending,
+	})
+	assert.NoError(t, err)
+
+	stream := openTracking(t, user, order)
+
+	event, err := readOrderEvent(stream)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.OrderRejected, event.Status)
+
+	_, err = readOrderEvent(stream)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestTrackOtherUsersOrder(t *testing.T) {
+	order := createRandomOrder(t, createRandomUser(t), createRandomShop(t))
+
+	recorder := serveAs(t, createRandomUser(t), http.MethodGet, "/orders/"+order.ID+"/track", nil, nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
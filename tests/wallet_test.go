@@ -0,0 +1,137 @@
+package all_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	db "github.com/GoogleCloudPlatform/golang-samples/run/helloworld/db/sqlc"
+	"github.com/GoogleCloudPlatform/golang-samples/run/helloworld/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRandomWallet(t *testing.T, user db.User) db.LedgerAccount {
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+
+	wallet, err := testQueries.GetOrCreateWallet(context.Background(), db.GetOrCreateWalletParams{
+		ID:     id,
+		UserID: sql.NullString{String: user.ID, Valid: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "wallet", wallet.Kind)
+
+	return wallet
+}
+
+// postLedgerLine writes one entry the way the api does, moving the cached
+// balance with it.
+func postLedgerLine(t *testing.T, transaction db.LedgerTransaction, account string, amount utils.Money) error {
+	balance, err := testQueries.AdjustLedgerBalance(context.Background(), db.AdjustLedgerBalanceParams{
+		ID:     account,
+		Amount: amount,
+	})
+	if err != nil {
+		return err
+	}
+
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+
+	_, err = testQueries.CreateLedgerEntry(context.Background(), db.CreateLedgerEntryParams{
+		ID:            id,
+		TransactionID: transaction.ID,
+		AccountID:     account,
+		Amount:        amount,
+		BalanceAfter:  balance,
+	})
+	return err
+}
+
+func TestBalanceLedger(t *testing.T) {
+	lines, err := utils.BalanceLedger([]utils.LedgerLine{
+		{Account: utils.AccountSales, Amount: utils.Naira(900)},
+		{Account: utils.AccountProvider, Amount: utils.Naira(-1000)},
+		{Account: utils.AccountTips, Amount: utils.Naira(100)},
+		{Account: utils.AccountCashback, Amount: utils.Kobo(0)},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, lines, 3)
+	assert.Equal(t, utils.AccountProvider, lines[0].Account)
+
+	_, err = utils.BalanceLedger([]utils.LedgerLine{
+		{Account: utils.AccountSales, Amount: utils.Naira(900)},
+		{Account: utils.AccountProvider, Amount: utils.Naira(-1000)},
+	})
+	assert.ErrorIs(t, err, utils.ErrUnbalancedLedger)
+
+	_, err = utils.BalanceLedger([]utils.LedgerLine{
+		{Account: utils.AccountSales, Amount: utils.Naira(5)},
+		{Account: utils.AccountSales, Amount: utils.Naira(-5)},
+	})
+	assert.ErrorIs(t, err, utils.ErrUnbalancedLedger)
+}
+
+func TestWalletLedger(t *testing.T) {
+	user := createRandomUser(t)
+	wallet := createRandomWallet(t, user)
+
+	// Asking again returns the same wallet.
+	again := createRandomWallet(t, user)
+	assert.Equal(t, wallet.ID, again.ID)
+	assert.True(t, again.Balance.IsZero())
+
+	id, err := utils.NewID()
+	assert.NoError(t, err)
+	topUp, err := testQueries.CreateLedgerTransaction(context.Background(), db.CreateLedgerTransactionParams{
+		ID:          id,
+		Kind:        utils.LedgerTopUp,
+		Description: "Wallet top-up",
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, postLedgerLine(t, topUp, utils.AccountProvider, utils.Naira(-5000)))
+	assert.NoError(t, postLedgerLine(t, topUp, wallet.ID, utils.Naira(5000)))
+
+	// A wallet can never go below zero.
+	_, err = testQueries.AdjustLedgerBalance(context.Background(), db.AdjustLedgerBalanceParams{
+		ID:     wallet.ID,
+		Amount: utils.Naira(-6000),
+	})
+	assert.Error(t, err)
+
+	id, err = utils.NewID()
+	assert.NoError(t, err)
+	payment, err := testQueries.CreateLedgerTransaction(context.Background(), db.CreateLedgerTransactionParams{
+		ID:   id,
+		Kind: utils.LedgerPayment,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, postLedgerLine(t, payment, wallet.ID, utils.Naira(-1500)))
+	assert.NoError(t, postLedgerLine(t, payment, utils.AccountSales, utils.Naira(1500)))
+
+	got, err := testQueries.GetLedgerAccount(context.Background(), wallet.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.Naira(3500), got.Balance)
+
+	total, err := testQueries.SumLedgerEntries(context.Background(), wallet.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, got.Balance.Kobo(), total.Kobo())
+
+	statement, err := testQueries.ListAccountStatement(context.Background(), db.ListAccountStatementParams{
+		AccountID: wallet.ID,
+		Limit:     1,
+		Offset:    0,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, statement, 1)
+	assert.Equal(t, utils.LedgerPayment, statement[0].Kind)
+	assert.Equal(t, utils.Naira(3500), statement[0].BalanceAfter)
+
+	entries, err := testQueries.ListLedgerTransactionEntries(context.Background(), payment.ID)
+	assert.NoError(t, err)
+	sum := utils.Kobo(0)
+	for _, entry := range entries {
+		sum = sum.Add(entry.Amount)
+	}
+	assert.True(t, sum.IsZero())
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// DefaultCancellationFee is the share of the subtotal, in percent, kept when
+// a customer cancels after the shop has started preparing the order.
+const DefaultCancellationFee = 50
+
+// Why an order was cancelled. Customers pick one of the first group; the rest
+// are filled in when someone else cancels.
+const (
+	CancelChangedMind  = "changed_mind"
+	CancelMistake      = "ordered_by_mistake"
+	CancelTooSlow      = "taking_too_long"
+	CancelWrongAddress = "wrong_address"
+	CancelDuplicate    = "duplicate_order"
+	CancelOther        = "other"
+	CancelByVendor     = "vendor_cancelled"
+	CancelByAdmin      = "admin_cancelled"
+	CancelBySystem     = "system_cancelled"
+)
+
+// Where the refund for a cancelled order goes.
+const (
+	RefundToOriginal = "original"
+	RefundToWallet   = "wallet"
+)
+
+// Refund states. A refund starts pending and is moved along by whoever
+// talks to the payment provider.
+const (
+	RefundNotRequired = "not_required"
+	RefundPending     = "pending"
+	RefundProcessing  = "processing"
+	RefundCompleted   = "refunded"
+	RefundFailed      = "failed"
+)
+
+// CancellationRule is what happens when a customer cancels an order in one
+// status. FeePercent is charged on the subtotal.
+type CancellationRule struct {
+	Allowed    bool  `json:"allowed"`
+	FeePercent int64 `json:"fee_percent"`
+}
+
+// CancellationPolicy maps order statuses to the rule customers get in that
+// status. Statuses that are not listed cannot be cancelled by customers.
+// In CANCELLATION_POLICY_FILE it is a JSON object keyed by status.
+type CancellationPolicy map[string]CancellationRule
+
+// NewCancellationPolicy is free cancellation until the shop starts cooking,
+// feePercent of the subtotal while the food is being prepared or waiting for
+// a rider, and no cancellation once a rider has it.
+func NewCancellationPolicy(feePercent int64) CancellationPolicy {
+	if feePercent < 0 {
+		feePercent = 0
+	}
+	if feePercent > 100 {
+		feePercent = 100
+	}
+
+	return CancellationPolicy{
+		OrderScheduled: {Allowed: true},
+		OrderPending:   {Allowed: true},
+		OrderAccepted:  {Allowed: true},
+		OrderPreparing: {Allowed: true, FeePercent: feePercent},
+		OrderReady:     {Allowed: true, FeePercent: feePercent},
+	}
+}
+
+// LoadCancellationPolicy reads a policy from a JSON file laid out like
+// CancellationPolicy.
+func LoadCancellationPolicy(path string) (CancellationPolicy, error) {
+	policy := CancellationPolicy{}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return policy, err
+	}
+	if err := json.Unmarshal(raw, &policy); err != nil {
+		return policy, err
+	}
+	return policy, policy.validate()
+}
+
+func (p CancellationPolicy) validate() error {
+	for status, rule := range p {
+		if rule.Allowed && !CanTransitionOrder(status, OrderCancelled, ActorCustomer) {
+			return fmt.Errorf("%s: customers cannot cancel orders in this status", status)
+		}
+		if rule.FeePercent < 0 || rule.FeePercent > 100 {
+			return fmt.Errorf("%s: fee percent must be between 0 and 100", status)
+		}
+	}
+	return nil
+}
+
+// Fee returns the cancellation fee for an order in status, rounded to the
+// nearest kobo, and whether the customer may cancel at all.
+func (p CancellationPolicy) Fee(status string, subtotal Money) (Money, bool) {
+	rule, ok := p[status]
+	if !ok || !rule.Allowed {
+		return Money{}, false
+	}
+	return subtotal.Percent(rule.FeePercent), true
+}
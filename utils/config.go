@@ -18,6 +18,29 @@ type Config struct {
 	GooglePassword    string `mapstructure:"GOOGLE_PASSWORD"`
 	RedisPassword     string `mapstructure:"REDIS_PASSWORD"`
 	RedisAddress      string `mapstructure:"REDIS_ADDRESS"`
+	ImageStore        string `mapstructure:"IMAGE_STORE"`
+	LocalUploadDir    string `mapstructure:"LOCAL_UPLOAD_DIR"`
+	LocalUploadURL    string `mapstructure:"LOCAL_UPLOAD_URL"`
+	Timezone          string `mapstructure:"TIMEZONE"`
+	ScheduleRelease   int    `mapstructure:"SCHEDULE_RELEASE_MINUTES"`
+	CancellationFee   int    `mapstructure:"CANCELLATION_FEE_PERCENT"`
+	CancellationRules string `mapstructure:"CANCELLATION_POLICY_FILE"`
+	DeliveryPricing   string `mapstructure:"DELIVERY_PRICING_FILE"`
+	TaxRules          string `mapstructure:"TAX_RULES_FILE"`
+	RiderCommission   int    `mapstructure:"RIDER_COMMISSION_PERCENT"`
+
+	PaymentProvider        string `mapstructure:"PAYMENT_PROVIDER"`
+	PaymentCallbackURL     string `mapstructure:"PAYMENT_CALLBACK_URL"`
+	PaystackSecretKey      string `mapstructure:"PAYSTACK_SECRET_KEY"`
+	FlutterwaveSecretKey   string `mapstructure:"FLUTTERWAVE_SECRET_KEY"`
+	FlutterwaveWebhookHash string `mapstructure:"FLUTTERWAVE_WEBHOOK_HASH"`
+
+	PayoutProvider   string `mapstructure:"PAYOUT_PROVIDER"`
+	VendorCommission int    `mapstructure:"VENDOR_COMMISSION_PERCENT"`
+	ProcessingFee    int    `mapstructure:"PAYMENT_FEE_BASIS_POINTS"`
+
+	// RefundApproval is in naira.
+	RefundApproval int `mapstructure:"REFUND_APPROVAL_THRESHOLD"`
 }
 
 func LoadDBConfig(path string) (config *Config, err error) {
@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"errors"
+	"time"
+)
+
+// What a coupon takes off.
+const (
+	CouponPercentage   = "percentage"
+	CouponFixed        = "fixed"
+	CouponFreeDelivery = "free_delivery"
+)
+
+var (
+	ErrCouponNotFound      = errors.New("coupon does not exist")
+	ErrCouponInactive      = errors.New("coupon is not active")
+	ErrCouponNotStarted    = errors.New("coupon cannot be used yet")
+	ErrCouponExpired       = errors.New("coupon has expired")
+	ErrCouponUsedUp        = errors.New("coupon has been used up")
+	ErrCouponUserLimit     = errors.New("coupon has already been used the most times allowed")
+	ErrCouponFirstOrder    = errors.New("coupon is only for a first order")
+	ErrCouponMinOrder      = errors.New("order is below the coupon's minimum")
+	ErrCouponNotApplicable = errors.New("coupon does not apply to anything in the cart")
+)
+
+// CouponRules is what a coupon takes off and what it applies to. A zero
+// StartsAt or EndsAt leaves that end of the window open, a zero MaxDiscount
+// leaves the discount uncapped, an empty ShopID applies to every shop and
+// empty Categories to every category.
+type CouponRules struct {
+	Kind        string
+	PercentOff  int64
+	AmountOff   Money
+	MaxDiscount Money
+	MinOrder    Money
+	StartsAt    time.Time
+	EndsAt      time.Time
+	ShopID      string
+	Categories  []string
+}
+
+// CouponLine is one line of a cart as far as a coupon is concerned.
+type CouponLine struct {
+	ShopID   string
+	Category string
+	Amount   Money
+}
+
+// CouponDiscount is what a coupon takes off a cart, in total and from each
+// shop's order. Eligible is what the lines it applies to come to.
+type CouponDiscount struct {
+	Eligible Money
+	Total    Money
+	Shops    map[string]Money
+}
+
+// Validate checks that the rules describe a coupon that can be used.
+func (r CouponRules) Validate() error {
+	switch r.Kind {
+	case CouponPercentage:
+		if r.PercentOff < 1 || r.PercentOff > 100 {
+			return errors.New("a percentage coupon needs percent_off between 1 and 100")
+		}
+	case CouponFixed:
+		if r.AmountOff.Cmp(Kobo(0)) <= 0 {
+			return errors.New("a fixed coupon needs an amount_off")
+		}
+	case CouponFreeDelivery:
+	default:
+		return errors.New("unknown coupon kind")
+	}
+
+	if !r.StartsAt.IsZero() && !r.EndsAt.IsZero() && !r.StartsAt.Before(r.EndsAt) {
+		return errors.New("a coupon must start before it ends")
+	}
+	return nil
+}
+
+// Check reports whether the coupon can be used at now.
+func (r CouponRules) Check(now time.Time) error {
+	if !r.StartsAt.IsZero() && now.Before(r.StartsAt) {
+		return ErrCouponNotStarted
+	}
+	if !r.EndsAt.IsZero() && !now.Before(r.EndsAt) {
+		return ErrCouponExpired
+	}
+	return nil
+}
+
+// Applies reports whether the coupon covers a line from shopId in category.
+func (r CouponRules) Applies(shopId, category string) bool {
+	if r.ShopID != "" && r.ShopID != shopId {
+		return false
+	}
+	if len(r.Categories) == 0 {
+		return true
+	}
+	for _, c := range r.Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Discount works out what the coupon takes off lines. The minimum order is
+// checked against the lines it applies to only. A free delivery coupon
+// takes off the delivery fee of every shop with such a line; fees may be
+// nil when delivery has not been priced yet, and the discount is then zero.
+// The discount is shared between shops in proportion to what each put in,
+// with any odd kobo going to the last of them.
+func (r CouponRules) Discount(lines []CouponLine, fees map[string]Money) (CouponDiscount, error) {
+	discount := CouponDiscount{
+		Eligible: Kobo(0),
+		Total:    Kobo(0),
+		Shops:    map[string]Money{},
+	}
+
+	shops := []string{}
+	eligible := map[string]Money{}
+	for _, line := range lines {
+		if !r.Applies(line.ShopID, line.Category) {
+			continue
+		}
+		if _, ok := eligible[line.ShopID]; !ok {
+			shops = append(shops, line.ShopID)
+			eligible[line.ShopID] = Kobo(0)
+		}
+		eligible[line.ShopID] = eligible[line.ShopID].Add(line.Amount)
+		discount.Eligible = discount.Eligible.Add(line.Amount)
+	}
+
+	if discount.Eligible.Cmp(Kobo(0)) <= 0 {
+		return discount, ErrCouponNotApplicable
+	}
+	if discount.Eligible.Cmp(r.MinOrder) < 0 {
+		return discount, ErrCouponMinOrder
+	}
+
+	base := eligible
+	switch r.Kind {
+	case CouponPercentage:
+		discount.Total = discount.Eligible.Percent(r.PercentOff)
+	case CouponFixed:
+		discount.Total = r.AmountOff.Min(discount.Eligible)
+	case CouponFreeDelivery:
+		base = map[string]Money{}
+		for _, shopId := range shops {
+			fee, ok := fees[shopId]
+			if !ok {
+				fee = Kobo(0)
+			}
+			base[shopId] = fee
+			discount.Total = discount.Total.Add(fee)
+		}
+	}
+
+	if r.MaxDiscount.Cmp(Kobo(0)) > 0 {
+		discount.Total = discount.Total.Min(r.MaxDiscount)
+	}
+
+	baseTotal := Kobo(0)
+	for _, shopId := range shops {
+		baseTotal = baseTotal.Add(base[shopId])
+	}
+
+	left := discount.Total
+	for i, shopId := range shops {
+		share := left
+		if i < len(shops)-1 {
+			share = Kobo(0)
+			if baseTotal.Cmp(Kobo(0)) > 0 {
+				share = discount.Total.MulRatio(base[shopId].Kobo(), baseTotal.Kobo()).Min(left)
+			}
+		}
+		discount.Shops[shopId] = share
+		left = left.Sub(share)
+	}
+	return discount, nil
+}
@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+var (
+	ErrBelowMinimumOrder = errors.New("order is below the minimum for delivery")
+	ErrOutOfDeliveryArea = errors.New("address is too far away for delivery")
+)
+
+// DeliveryBand charges Fee for deliveries up to MaxMeters.
+type DeliveryBand struct {
+	MaxMeters int64 `json:"max_meters"`
+	Fee       Money `json:"fee"`
+}
+
+// SurgeWindow raises the distance fee to Percent of itself between From and
+// To ("HH:MM", in the server's timezone), every day. 150 is one and a half
+// times the usual fee.
+type SurgeWindow struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Percent int64  `json:"percent"`
+
+	from, to int
+}
+
+// DeliveryPricing holds every knob of the delivery fee. Amounts are written
+// in naira in the pricing file, and a zero threshold switches that rule off.
+type DeliveryPricing struct {
+	// Bands must be in increasing MaxMeters. Anything beyond the last band
+	// is not delivered to.
+	Bands []DeliveryBand `json:"bands"`
+
+	// FlatFee is charged when either end of the trip has no coordinates.
+	FlatFee Money `json:"flat_fee"`
+
+	MinOrder              Money         `json:"min_order"`
+	SmallOrderThreshold   Money         `json:"small_order_threshold"`
+	SmallOrderFee         Money         `json:"small_order_fee"`
+	FreeDeliveryThreshold Money         `json:"free_delivery_threshold"`
+	Surges                []SurgeWindow `json:"surges"`
+}
+
+// DeliveryQuote is the fee for one shop's order and how it was reached.
+// DistanceMeters is nil when the flat fee was used.
+type DeliveryQuote struct {
+	DistanceMeters       *int64
+	DistanceFee          Money
+	SmallOrderFee        Money
+	SurgePercent         int64
+	SurgeFee             Money
+	FreeDeliveryDiscount Money
+	Fee                  Money
+}
+
+// DefaultDeliveryPricing is used when DELIVERY_PRICING_FILE is not set.
+func DefaultDeliveryPricing() DeliveryPricing {
+	pricing := DeliveryPricing{
+		Bands: []DeliveryBand{
+			{MaxMeters: 3000, Fee: Naira(500)},
+			{MaxMeters: 6000, Fee: Naira(800)},
+			{MaxMeters: 10000, Fee: Naira(1200)},
+			{MaxMeters: 15000, Fee: Naira(1800)},
+		},
+		FlatFee:               Naira(800),
+		MinOrder:              Naira(1000),
+		SmallOrderThreshold:   Naira(2500),
+		SmallOrderFee:         Naira(200),
+		FreeDeliveryThreshold: Naira(20000),
+		Surges: []SurgeWindow{
+			{From: "12:00", To: "14:00", Percent: 120},
+			{From: "18:00", To: "21:00", Percent: 130},
+		},
+	}
+	if err := pricing.validate(); err != nil {
+		panic(err)
+	}
+	return pricing
+}
+
+// LoadDeliveryPricing reads pricing from a JSON file laid out like
+// DeliveryPricing.
+func LoadDeliveryPricing(path string) (DeliveryPricing, error) {
+	pricing := DeliveryPricing{}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return pricing, err
+	}
+	if err := json.Unmarshal(raw, &pricing); err != nil {
+		return pricing, err
+	}
+	return pricing, pricing.validate()
+}
+
+func (p *DeliveryPricing) validate() error {
+	if len(p.Bands) == 0 {
+		return errors.New("delivery pricing needs at least one distance band")
+	}
+	if !sort.SliceIsSorted(p.Bands, func(i, j int) bool { return p.Bands[i].MaxMeters < p.Bands[j].MaxMeters }) {
+		return errors.New("delivery bands must be in increasing distance")
+	}
+
+	for i, surge := range p.Surges {
+		from, err := ParseClock(surge.From)
+		if err != nil {
+			return fmt.Errorf("surge %d: %w", i, err)
+		}
+		to, err := ParseClock(surge.To)
+		if err != nil {
+			return fmt.Errorf("surge %d: %w", i, err)
+		}
+		if surge.Percent < 100 {
+			return fmt.Errorf("surge %d: percent must be at least 100", i)
+		}
+		p.Surges[i].from, p.Surges[i].to = from, to
+	}
+	return nil
+}
+
+// Quote prices delivering an order worth subtotal over distanceMeters, or at
+// the flat fee when the distance is not known, at time at.
+func (p DeliveryPricing) Quote(distanceMeters int64, known bool, subtotal Money, at time.Time) (DeliveryQuote, error) {
+	quote := DeliveryQuote{SurgePercent: 100}
+
+	if !p.MinOrder.IsZero() && subtotal.Cmp(p.MinOrder) < 0 {
+		return quote, ErrBelowMinimumOrder
+	}
+
+	if known {
+		quote.DistanceMeters = &distanceMeters
+		found := false
+		for _, band := range p.Bands {
+			if distanceMeters <= band.MaxMeters {
+				quote.DistanceFee = band.Fee
+				found = true
+				break
+			}
+		}
+		if !found {
+			return quote, ErrOutOfDeliveryArea
+		}
+	} else {
+		quote.DistanceFee = p.FlatFee
+	}
+
+	minute := at.Hour()*60 + at.Minute()
+	for _, surge := range p.Surges {
+		inWindow := minute >= surge.from && minute < surge.to
+		if surge.to <= surge.from {
+			inWindow = minute >= surge.from || minute < surge.to
+		}
+		if inWindow {
+			quote.SurgePercent = surge.Percent
+			quote.SurgeFee = quote.DistanceFee.Percent(surge.Percent - 100)
+			break
+		}
+	}
+
+	if !p.SmallOrderThreshold.IsZero() && subtotal.Cmp(p.SmallOrderThreshold) < 0 {
+		quote.SmallOrderFee = p.SmallOrderFee
+	}
+
+	if !p.FreeDeliveryThreshold.IsZero() && subtotal.Cmp(p.FreeDeliveryThreshold) >= 0 {
+		quote.FreeDeliveryDiscount = quote.DistanceFee.Add(quote.SurgeFee)
+	}
+
+	quote.Fee = quote.DistanceFee.Add(quote.SurgeFee).Add(quote.SmallOrderFee).Sub(quote.FreeDeliveryDiscount)
+	return quote, nil
+}
+
+// DistanceMeters is the straight-line distance between two points on the
+// earth's surface.
+func DistanceMeters(lat1, lng1, lat2, lng2 float64) int64 {
+	const earthRadius = 6371000.0
+
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return int64(math.Round(earthRadius * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))))
+}
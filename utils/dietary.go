@@ -0,0 +1,89 @@
+package utils
+
+// Dietary tags a vendor can put on a product. Spice is tracked separately as
+// a level so it can be filtered with "at most".
+const (
+	TagVegetarian = "vegetarian"
+	TagVegan      = "vegan"
+	TagHalal      = "halal"
+	TagGlutenFree = "gluten_free"
+)
+
+// Allergens follow the common declarable food allergens.
+const (
+	AllergenNuts      = "nuts"
+	AllergenPeanuts   = "peanuts"
+	AllergenGluten    = "gluten"
+	AllergenDairy     = "dairy"
+	AllergenEggs      = "eggs"
+	AllergenShellfish = "shellfish"
+	AllergenFish      = "fish"
+	AllergenSoy       = "soy"
+	AllergenSesame    = "sesame"
+)
+
+// DietaryTags and Allergens are every value a product or allergen profile
+// may use, in the order they are listed to users.
+var (
+	DietaryTags = []string{TagVegetarian, TagVegan, TagHalal, TagGlutenFree}
+	Allergens   = []string{
+		AllergenNuts, AllergenPeanuts, AllergenGluten, AllergenDairy, AllergenEggs,
+		AllergenShellfish, AllergenFish, AllergenSoy, AllergenSesame,
+	}
+)
+
+const MaxSpiceLevel = 3
+
+// IsDietaryTag reports whether tag is one of DietaryTags.
+func IsDietaryTag(tag string) bool {
+	return contains(DietaryTags, tag)
+}
+
+// IsAllergen reports whether allergen is one of Allergens.
+func IsAllergen(allergen string) bool {
+	return contains(Allergens, allergen)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// UnsafeAllergens returns the allergens in a product that the user has said
+// they react to.
+func UnsafeAllergens(productAllergens, profile []string) []string {
+	unsafe := []string{}
+	if len(profile) == 0 {
+		return unsafe
+	}
+
+	avoid := make(map[string]bool, len(profile))
+	for _, allergen := range profile {
+		avoid[allergen] = true
+	}
+
+	for _, allergen := range productAllergens {
+		if avoid[allergen] {
+			unsafe = append(unsafe, allergen)
+		}
+	}
+
+	return unsafe
+}
+
+// Dedupe drops repeated entries while keeping the original order.
+func Dedupe(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := []string{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			out = append(out, value)
+		}
+	}
+	return out
+}
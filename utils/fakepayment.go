@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FakePaymentHeader carries the signature on fake webhooks.
+const FakePaymentHeader = "X-Fake-Signature"
+
+// FakePaymentProvider keeps payments in memory for development and tests,
+// where there are no gateway keys. Nothing is charged: a payment stays
+// pending until Complete is called for it, which is what the customer
+// visiting the authorization URL does in development.
+type FakePaymentProvider struct {
+	secret string
+
+	mu       sync.Mutex
+	payments map[string]*PaymentResult
+	refunds  map[string]RefundResult
+}
+
+func NewFakePaymentProvider() *FakePaymentProvider {
+	secret, err := NewID()
+	if err != nil {
+		secret = fmt.Sprint(time.Now().UnixNano())
+	}
+
+	return &FakePaymentProvider{
+		secret:   secret,
+		payments: map[string]*PaymentResult{},
+		refunds:  map[string]RefundResult{},
+	}
+}
+
+func (f *FakePaymentProvider) Name() string {
+	return "fake"
+}
+
+func (f *FakePaymentProvider) Initialize(ctx context.Context, req PaymentRequest) (PaymentSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.payments[req.Reference] = &PaymentResult{
+		Reference:         req.Reference,
+		ProviderReference: "fake_" + req.Reference,
+		Status:            PaymentPending,
+		Amount:            req.Amount,
+		Channel:           "card",
+	}
+
+	return PaymentSession{
+		AuthorizationURL: "/payments/fake/" + req.Reference,
+		AccessCode:       req.Reference,
+	}, nil
+}
+
+func (f *FakePaymentProvider) Verify(ctx context.Context, reference string) (PaymentResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	payment, ok := f.payments[reference]
+	if !ok {
+		return PaymentResult{Reference: reference}, ErrPaymentNotFound
+	}
+	return *payment, nil
+}
+
+func (f *FakePaymentProvider) Refund(ctx context.Context, req RefundRequest) (RefundResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	payment, ok := f.payments[req.Reference]
+	if !ok || payment.Status != PaymentSucceeded {
+		return RefundResult{}, ErrPaymentNotFound
+	}
+	if req.Amount.Cmp(payment.Amount) > 0 {
+		return RefundResult{}, &DeclinedError{Message: fmt.Sprintf("fake: cannot refund %s of %s", req.Amount, payment.Amount)}
+	}
+
+	// The same refund asked for again gets the first answer, as it would
+	// from a gateway honouring the idempotency key.
+	if req.ID != "" {
+		if result, ok := f.refunds[req.ID]; ok {
+			return result, nil
+		}
+	}
+
+	result := RefundResult{
+		ProviderReference: fmt.Sprintf("fake_refund_%d", len(f.refunds)+1),
+		Status:            RefundCompleted,
+	}
+	f.refunds[result.ProviderReference] = result
+	if req.ID != "" {
+		f.refunds[req.ID] = result
+	}
+	return result, nil
+}
+
+func (f *FakePaymentProvider) RefundStatus(ctx context.Context, providerReference string) (RefundResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result, ok := f.refunds[providerReference]
+	if !ok {
+		return RefundResult{}, ErrPaymentNotFound
+	}
+	return result, nil
+}
+
+func (f *FakePaymentProvider) Webhook(header http.Header, body []byte) (string, error) {
+	if !equalSignature(header.Get(FakePaymentHeader), f.Sign(body)) {
+		return "", ErrInvalidSignature
+	}
+
+	event := struct {
+		Reference string `json:"reference"`
+	}{}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return "", fmt.Errorf("fake: could not decode webhook: %v", err)
+	}
+	return event.Reference, nil
+}
+
+// Complete settles a pending payment as the customer would at a real
+// gateway, succeeding it or failing it.
+func (f *FakePaymentProvider) Complete(reference string, succeed bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	payment, ok := f.payments[reference]
+	if !ok {
+		return ErrPaymentNotFound
+	}
+	if payment.Status != PaymentPending {
+		return nil
+	}
+
+	if succeed {
+		payment.Status = PaymentSucceeded
+		payment.PaidAt = time.Now()
+	} else {
+		payment.Status = PaymentFailed
+		payment.Message = "Declined by the fake provider"
+	}
+	return nil
+}
+
+// Sign returns the signature a webhook body needs to be accepted.
+func (f *FakePaymentProvider) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(f.secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
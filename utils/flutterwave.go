@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const flutterwaveBaseURL = "https://api.flutterwave.com/v3"
+
+// FlutterwaveProvider takes payments through Flutterwave Standard. Unlike
+// Paystack, Flutterwave takes and returns amounts in naira, so they cross
+// as decimal numbers.
+type FlutterwaveProvider struct {
+	secretKey   string
+	webhookHash string
+	client      *http.Client
+}
+
+type flutterwaveResponse struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type flutterwaveTransaction struct {
+	ID          int64       `json:"id"`
+	TxRef       string      `json:"tx_ref"`
+	Status      string      `json:"status"`
+	Amount      json.Number `json:"amount"`
+	Currency    string      `json:"currency"`
+	PaymentType string      `json:"payment_type"`
+	CreatedAt   time.Time   `json:"created_at"`
+	Message     string      `json:"processor_response"`
+}
+
+func NewFlutterwaveProvider(secretKey, webhookHash string) (*FlutterwaveProvider, error) {
+	if secretKey == "" || webhookHash == "" {
+		return nil, errors.New("flutterwave secret key and webhook hash are not configured")
+	}
+
+	return &FlutterwaveProvider{
+		secretKey:   secretKey,
+		webhookHash: webhookHash,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}, nil
+}
+
+func (f *FlutterwaveProvider) Name() string {
+	return "flutterwave"
+}
+
+func (f *FlutterwaveProvider) Initialize(ctx context.Context, req PaymentRequest) (PaymentSession, error) {
+	session := PaymentSession{}
+
+	body := map[string]interface{}{
+		"tx_ref":       req.Reference,
+		"amount":       json.Number(req.Amount.String()),
+		"currency":     req.Amount.Currency(),
+		"redirect_url": req.CallbackURL,
+		"customer":     map[string]string{"email": req.Email},
+		"meta":         req.Metadata,
+	}
+
+	data := struct {
+		Link string `json:"link"`
+	}{}
+	if err := f.do(ctx, http.MethodPost, "/payments", body, &data); err != nil {
+		return session, err
+	}
+
+	session.AuthorizationURL = data.Link
+	return session, nil
+}
+
+func (f *FlutterwaveProvider) Verify(ctx context.Context, reference string) (PaymentResult, error) {
+	result := PaymentResult{Reference: reference}
+
+	transaction := flutterwaveTransaction{}
+	path := "/transactions/verify_by_reference?tx_ref=" + url.QueryEscape(reference)
+	if err := f.do(ctx, http.MethodGet, path, nil, &transaction); err != nil {
+		return result, err
+	}
+
+	amount, err := ParseMoney(transaction.Amount.String())
+	if err != nil {
+		return result, fmt.Errorf("flutterwave: bad amount %q: %v", transaction.Amount, err)
+	}
+	amount.currency = transaction.Currency
+
+	result.ProviderReference = strconv.FormatInt(transaction.ID, 10)
+	result.Amount = amount
+	result.Channel = transaction.PaymentType
+	result.Message = transaction.Message
+
+	switch transaction.Status {
+	case "successful":
+		result.Status = PaymentSucceeded
+		result.PaidAt = transaction.CreatedAt
+	case "failed", "cancelled":
+		result.Status = PaymentFailed
+	default:
+		result.Status = PaymentPending
+	}
+	return result, nil
+}
+
+// Refund goes through the transaction id Flutterwave gave the payment, not
+// our reference.
+func (f *FlutterwaveProvider) Refund(ctx context.Context, req RefundRequest) (RefundResult, error) {
+	result := RefundResult{}
+
+	body := map[string]interface{}{
+		"amount": json.Number(req.Amount.String()),
+	}
+
+	data := flutterwaveRefund{}
+	path := "/transactions/" + url.PathEscape(req.ProviderReference) + "/refund"
+	if err := f.do(withIdempotencyKey(ctx, req.ID), http.MethodPost, path, body, &data); err != nil {
+		return result, err
+	}
+	return data.result(), nil
+}
+
+func (f *FlutterwaveProvider) RefundStatus(ctx context.Context, providerReference string) (RefundResult, error) {
+	data := flutterwaveRefund{}
+	if err := f.do(ctx, http.MethodGet, "/refunds/"+url.PathEscape(providerReference), nil, &data); err != nil {
+		return RefundResult{}, err
+	}
+	return data.result(), nil
+}
+
+type flutterwaveRefund struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+}
+
+func (r flutterwaveRefund) result() RefundResult {
+	result := RefundResult{ProviderReference: strconv.FormatInt(r.ID, 10), Status: RefundProcessing}
+
+	switch r.Status {
+	case "completed", "successful":
+		result.Status = RefundCompleted
+	case "failed":
+		result.Status = RefundFailed
+		result.Message = "Refund failed at Flutterwave"
+	}
+	return result
+}
+
+// Webhook checks the verif-hash header against the secret hash set on the
+// Flutterwave dashboard.
+func (f *FlutterwaveProvider) Webhook(header http.Header, body []byte) (string, error) {
+	if !equalSignature(header.Get("verif-hash"), f.webhookHash) {
+		return "", ErrInvalidSignature
+	}
+
+	event := struct {
+		Event string `json:"event"`
+		Data  struct {
+			TxRef string `json:"tx_ref"`
+		} `json:"data"`
+	}{}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return "", fmt.Errorf("flutterwave: could not decode webhook: %v", err)
+	}
+	return event.Data.TxRef, nil
+}
+
+func (f *FlutterwaveProvider) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, flutterwaveBaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+f.secretKey)
+	req.Header.Set("Content-Type", "application/json")
+	setIdempotencyKey(req)
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resp := flutterwaveResponse{}
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return fmt.Errorf("flutterwave: could not decode response: %v", err)
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		return ErrPaymentNotFound
+	}
+	if res.StatusCode >= 500 {
+		return fmt.Errorf("flutterwave: %s", resp.Message)
+	}
+	if resp.Status != "success" || res.StatusCode >= 300 {
+		return &DeclinedError{Message: "flutterwave: " + resp.Message}
+	}
+
+	if err := json.Unmarshal(resp.Data, out); err != nil {
+		return fmt.Errorf("flutterwave: could not decode response: %v", err)
+	}
+	return nil
+}
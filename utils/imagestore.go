@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// ImageStore is where uploaded images end up. Save returns the public URL of
+// the stored object and Delete removes it again using the same name.
+type ImageStore interface {
+	Save(ctx context.Context, name string, contentType string, data []byte) (string, error)
+	Delete(ctx context.Context, name string) error
+}
+
+const (
+	MaxImageSize      = 5 << 20 // 5 MB
+	MaxImageDimension = 6000
+	ThumbnailSize     = 320
+)
+
+var ErrUnsupportedImage = errors.New("file is not a supported image (jpeg, png or gif)")
+
+var allowedImageTypes = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// NewImageStore picks the store configured by IMAGE_STORE. Without it,
+// deployments with Cloudinary settings keep using Cloudinary and anything
+// else, such as a fresh development setup, stores images on local disk.
+func NewImageStore(config *Config) (ImageStore, error) {
+	switch config.ImageStore {
+	case "":
+		if config.CloudName == "" && config.CloudApiKey == "" && config.CloudApiSecret == "" {
+			return NewLocalStore(config.LocalUploadDir, config.LocalUploadURL)
+		}
+		return NewCloudinaryStore(config)
+	case "cloudinary":
+		return NewCloudinaryStore(config)
+	case "local":
+		return NewLocalStore(config.LocalUploadDir, config.LocalUploadURL)
+	default:
+		return nil, fmt.Errorf("unknown image store %q", config.ImageStore)
+	}
+}
+
+// SniffImage looks at the bytes themselves rather than the client supplied
+// header and returns the content type and file extension of the image.
+func SniffImage(data []byte) (string, string, error) {
+	contentType := http.DetectContentType(data)
+
+	ext, ok := allowedImageTypes[contentType]
+	if !ok {
+		return "", "", ErrUnsupportedImage
+	}
+
+	return contentType, ext, nil
+}
+
+// ImageDimensions reads only the image header so oversized images can be
+// rejected before they are fully decoded.
+func ImageDimensions(data []byte) (int, int, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, ErrUnsupportedImage
+	}
+
+	if cfg.Width > MaxImageDimension || cfg.Height > MaxImageDimension {
+		return 0, 0, fmt.Errorf("image dimensions must not exceed %dx%d", MaxImageDimension, MaxImageDimension)
+	}
+
+	return cfg.Width, cfg.Height, nil
+}
+
+// MakeThumbnail scales the image down so its longest side is at most size
+// pixels. PNGs stay PNGs to keep transparency, everything else becomes a jpeg.
+func MakeThumbnail(data []byte, contentType string, size int) ([]byte, string, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupportedImage
+	}
+
+	dst := scaleDown(src, size)
+
+	buf := new(bytes.Buffer)
+	if contentType == "image/png" {
+		err = png.Encode(buf, dst)
+		contentType = "image/png"
+	} else {
+		err = jpeg.Encode(buf, dst, &jpeg.Options{Quality: 80})
+		contentType = "image/jpeg"
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), contentType, nil
+}
+
+// scaleDown does a simple area average which is good enough for thumbnails
+// and avoids pulling in an extra imaging dependency.
+func scaleDown(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	if w <= size && h <= size {
+		dst := image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+		return dst
+	}
+
+	newW, newH := size, size
+	if w > h {
+		newH = h * size / w
+	} else {
+		newW = w * size / h
+	}
+	if newW < 1 {
+		newW = 1
+	}
+	if newH < 1 {
+		newH = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, newW, newH))
+
+	for y := 0; y < newH; y++ {
+		y0 := bounds.Min.Y + y*h/newH
+		y1 := bounds.Min.Y + (y+1)*h/newH
+		for x := 0; x < newW; x++ {
+			x0 := bounds.Min.X + x*w/newW
+			x1 := bounds.Min.X + (x+1)*w/newW
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					b += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(b / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+
+	return dst
+}
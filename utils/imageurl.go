@@ -0,0 +1,320 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	MaxRemoteImageSize  = 500 * 1024 // 500 KB
+	sniffLength         = 512
+	imageURLCachePrefix = "imgurl:"
+)
+
+var (
+	imageURLOkTTL     = 24 * time.Hour
+	imageURLFailedTTL = time.Hour
+)
+
+var errBlockedAddress = errors.New("address is not publicly routable")
+
+// Reasons returned to the client when an image url is rejected.
+const (
+	ReasonInvalidURL     = "invalid url"
+	ReasonScheme         = "only http and https urls are allowed"
+	ReasonBlockedAddress = "url points to a private or internal address"
+	ReasonUnreachable    = "could not reach url"
+	ReasonBadStatus      = "url did not return a successful response"
+	ReasonNotImage       = "url does not point to a jpeg, png, gif or webp image"
+	ReasonTooLarge       = "image must not be more than 500KB"
+)
+
+// ImageURLResult is the verdict for a single url. Reason is empty when the
+// url points to an acceptable image.
+type ImageURLResult struct {
+	URL    string `json:"url"`
+	OK     bool   `json:"ok"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ImageURLVerifier checks that user supplied urls point to small public
+// images without letting the server be used to probe internal addresses.
+type ImageURLVerifier struct {
+	client *http.Client
+	rdb    *redis.Client
+}
+
+func NewImageURLVerifier(rdb *redis.Client) *ImageURLVerifier {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		// Control runs after DNS resolution with the actual address being
+		// dialed, so this also covers redirects and DNS rebinding.
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return errBlockedAddress
+			}
+			return nil
+		},
+	}
+
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: 5 * time.Second,
+		MaxIdleConns:          20,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	return &ImageURLVerifier{
+		rdb: rdb,
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 3 {
+					return errors.New("too many redirects")
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return errors.New(ReasonScheme)
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// Verify checks every url concurrently and returns the results in the same
+// order they were given.
+func (v *ImageURLVerifier) Verify(ctx context.Context, urls []string) []ImageURLResult {
+	results := make([]ImageURLResult, len(urls))
+
+	var wg sync.WaitGroup
+	for i, urlStr := range urls {
+		wg.Add(1)
+		go func(i int, urlStr string) {
+			defer wg.Done()
+			reason := v.verifyCached(ctx, urlStr)
+			results[i] = ImageURLResult{
+				URL:    urlStr,
+				OK:     reason == "",
+				Reason: reason,
+			}
+		}(i, urlStr)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (v *ImageURLVerifier) verifyCached(ctx context.Context, urlStr string) string {
+	if reason := CheckImageURLSyntax(urlStr); reason != "" {
+		return reason
+	}
+
+	sum := sha256.Sum256([]byte(urlStr))
+	key := imageURLCachePrefix + hex.EncodeToString(sum[:])
+
+	if v.rdb != nil {
+		cached, err := v.rdb.Get(ctx, key).Result()
+		if err == nil {
+			return cached
+		}
+	}
+
+	reason := v.verify(ctx, urlStr)
+
+	// network hiccups are not a verdict on the url, so don't remember them
+	if v.rdb != nil && reason != ReasonUnreachable {
+		ttl := imageURLOkTTL
+		if reason != "" {
+			ttl = imageURLFailedTTL
+		}
+		// a cache failure only costs us another lookup next time
+		v.rdb.Set(ctx, key, reason, ttl)
+	}
+
+	return reason
+}
+
+// CheckImageURLSyntax validates a url without touching the network.
+func CheckImageURLSyntax(urlStr string) string {
+	u, err := url.Parse(urlStr)
+	if err != nil || u.Host == "" {
+		return ReasonInvalidURL
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ReasonScheme
+	}
+	if u.User != nil {
+		return ReasonInvalidURL
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !isPublicIP(ip) {
+		return ReasonBlockedAddress
+	}
+	if strings.EqualFold(u.Hostname(), "localhost") {
+		return ReasonBlockedAddress
+	}
+	return ""
+}
+
+func (v *ImageURLVerifier) verify(ctx context.Context, urlStr string) string {
+	size := int64(-1)
+
+	// HEAD is cheap and lets us reject obvious non images or huge files
+	// before downloading anything. Plenty of servers don't implement it, so
+	// any failure here just falls through to the ranged GET.
+	if resp, err := v.request(ctx, http.MethodHead, urlStr, ""); err == nil {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			contentType := resp.Header.Get("Content-Type")
+			if contentType != "" && !strings.HasPrefix(contentType, "image/") && !strings.HasPrefix(contentType, "application/octet-stream") {
+				return ReasonNotImage
+			}
+			if resp.ContentLength > MaxRemoteImageSize {
+				return ReasonTooLarge
+			}
+			size = resp.ContentLength
+		}
+	} else if reason := blockedReason(err); reason != "" {
+		return reason
+	}
+
+	resp, err := v.request(ctx, http.MethodGet, urlStr, fmt.Sprintf("bytes=0-%d", sniffLength-1))
+	if err != nil {
+		if reason := blockedReason(err); reason != "" {
+			return reason
+		}
+		return ReasonUnreachable
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if total := contentRangeTotal(resp.Header.Get("Content-Range")); total >= 0 {
+			size = total
+		}
+	case http.StatusOK:
+		// the server ignored the range header and is sending the whole file
+		if resp.ContentLength >= 0 {
+			size = resp.ContentLength
+		}
+	default:
+		return ReasonBadStatus
+	}
+
+	if size > MaxRemoteImageSize {
+		return ReasonTooLarge
+	}
+
+	limit := int64(sniffLength)
+	if size < 0 && resp.StatusCode == http.StatusOK {
+		// no idea how big it is, so read at most one byte past the limit
+		limit = MaxRemoteImageSize + 1
+	}
+
+	head, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil && len(head) == 0 {
+		return ReasonUnreachable
+	}
+	if int64(len(head)) > MaxRemoteImageSize {
+		return ReasonTooLarge
+	}
+
+	if !isImageMagic(head) {
+		return ReasonNotImage
+	}
+
+	return ""
+}
+
+func (v *ImageURLVerifier) request(ctx context.Context, method, urlStr, rangeHeader string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+	if rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+	req.Header.Set("Accept", "image/*")
+
+	return v.client.Do(req)
+}
+
+func blockedReason(err error) string {
+	if errors.Is(err, errBlockedAddress) {
+		return ReasonBlockedAddress
+	}
+	return ""
+}
+
+// contentRangeTotal parses the total size out of "bytes 0-511/12345". It
+// returns -1 when the total is unknown.
+func contentRangeTotal(header string) int64 {
+	i := strings.LastIndex(header, "/")
+	if i < 0 || header[i+1:] == "*" {
+		return -1
+	}
+	total, err := strconv.ParseInt(header[i+1:], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return total
+}
+
+func isImageMagic(data []byte) bool {
+	switch http.DetectContentType(data) {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
+var blockedNetworks = func() []*net.IPNet {
+	cidrs := []string{
+		"0.0.0.0/8",
+		"100.64.0.0/10", // carrier grade NAT
+		"192.0.0.0/24",
+		"198.18.0.0/15",
+		"64:ff9b::/96", // NAT64 can reach IPv4 private space
+	}
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, _ := net.ParseCIDR(cidr)
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, n := range blockedNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"errors"
+	"sort"
+)
+
+// The platform's own ledger accounts, created by migration.
+const (
+	AccountProvider   = "provider"
+	AccountSales      = "sales"
+	AccountTips       = "tips"
+	AccountCashback   = "cashback"
+	AccountCommission = "commission"
+	AccountPromotions = "promotions"
+)
+
+// Ledger transaction kinds.
+const (
+	LedgerTopUp      = "topup"
+	LedgerPayment    = "payment"
+	LedgerRefund     = "refund"
+	LedgerCashback   = "cashback"
+	LedgerAdjustment = "adjustment"
+	LedgerSettlement = "settlement"
+	LedgerPayout     = "payout"
+)
+
+// WalletProvider is recorded as the provider of payments made from a
+// wallet balance.
+const WalletProvider = "wallet"
+
+var (
+	MinTopUp = Naira(100)
+	MaxTopUp = Naira(1000000)
+)
+
+var (
+	ErrUnbalancedLedger  = errors.New("ledger entries do not add up to zero")
+	ErrInsufficientFunds = errors.New("account balance is too low")
+)
+
+// LedgerLine is one side of a ledger transaction: money into Account when
+// Amount is positive, out of it when negative.
+type LedgerLine struct {
+	Account string
+	Amount  Money
+}
+
+// BalanceLedger checks that lines move money rather than create it, merges
+// lines for the same account and drops any that come to zero. The result is
+// sorted by account so concurrent transactions lock accounts in the same
+// order.
+func BalanceLedger(lines []LedgerLine) ([]LedgerLine, error) {
+	totals := map[string]Money{}
+	sum := Kobo(0)
+	for _, line := range lines {
+		total, ok := totals[line.Account]
+		if !ok {
+			total = Kobo(0)
+		}
+		totals[line.Account] = total.Add(line.Amount)
+		sum = sum.Add(line.Amount)
+	}
+	if !sum.IsZero() {
+		return nil, ErrUnbalancedLedger
+	}
+
+	balanced := []LedgerLine{}
+	for account, total := range totals {
+		if !total.IsZero() {
+			balanced = append(balanced, LedgerLine{Account: account, Amount: total})
+		}
+	}
+	sort.Slice(balanced, func(i, j int) bool {
+		return balanced[i].Account < balanced[j].Account
+	})
+
+	if len(balanced) == 0 {
+		return nil, ErrUnbalancedLedger
+	}
+	return balanced, nil
+}
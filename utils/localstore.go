@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore keeps images on disk. It is meant for development and tests
+// where there are no cloudinary credentials; the server exposes the
+// directory under baseURL.
+type LocalStore struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocalStore(dir, baseURL string) (*LocalStore, error) {
+	if dir == "" {
+		dir = "uploads"
+	}
+	if baseURL == "" {
+		baseURL = "/uploads"
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &LocalStore{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+// Dir is the directory the images are written to.
+func (l *LocalStore) Dir() string {
+	return l.dir
+}
+
+// BaseURL is the path the directory should be served under.
+func (l *LocalStore) BaseURL() string {
+	return l.baseURL
+}
+
+func (l *LocalStore) Save(ctx context.Context, name string, contentType string, data []byte) (string, error) {
+	path, err := l.path(name)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+
+	return l.baseURL + "/" + filepath.ToSlash(filepath.Clean(name)), nil
+}
+
+func (l *LocalStore) Delete(ctx context.Context, name string) error {
+	path, err := l.path(name)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// path keeps every name inside the upload directory.
+func (l *LocalStore) path(name string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(clean) || clean == "." || strings.HasPrefix(clean, "..") {
+		return "", errors.New("invalid image name")
+	}
+	return filepath.Join(l.dir, clean), nil
+}
@@ -0,0 +1,262 @@
+package utils
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CurrencyNGN is the only currency in use. Money without a currency is
+// treated as naira.
+const CurrencyNGN = "NGN"
+
+// Money is an amount in the minor unit of its currency, kobo for naira. It
+// is stored in the database as a bigint and crosses the API as a decimal
+// string such as "1500.50", so no float is ever involved.
+//
+// Adding or comparing amounts in different currencies is a programming error
+// and panics, as does overflowing int64.
+type Money struct {
+	kobo     int64
+	currency string
+}
+
+// Kobo returns an amount of naira given in kobo.
+func Kobo(kobo int64) Money {
+	return Money{kobo: kobo, currency: CurrencyNGN}
+}
+
+// Naira returns a whole number of naira.
+func Naira(naira int64) Money {
+	return Kobo(naira).Mul(100)
+}
+
+// ParseMoney reads a decimal naira amount such as "1500", "1500.5" or
+// "-20.00". More than two decimal places is an error rather than being
+// rounded away.
+func ParseMoney(amount string) (Money, error) {
+	amount = strings.TrimSpace(amount)
+	invalid := fmt.Errorf("invalid amount %q", amount)
+
+	negative := strings.HasPrefix(amount, "-")
+	digits := strings.TrimPrefix(amount, "-")
+
+	whole, frac, hasPoint := strings.Cut(digits, ".")
+	if whole == "" && frac == "" {
+		return Money{}, invalid
+	}
+	if hasPoint && frac == "" {
+		return Money{}, invalid
+	}
+	if len(frac) > 2 {
+		return Money{}, fmt.Errorf("invalid amount %q: more than two decimal places", amount)
+	}
+	for _, part := range []string{whole, frac} {
+		for _, char := range part {
+			if char < '0' || char > '9' {
+				return Money{}, invalid
+			}
+		}
+	}
+
+	if whole == "" {
+		whole = "0"
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+
+	naira, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || naira > math.MaxInt64/100 {
+		return Money{}, invalid
+	}
+	kobo, _ := strconv.ParseInt(frac, 10, 64)
+
+	total := naira*100 + kobo
+	if negative {
+		total = -total
+	}
+	return Kobo(total), nil
+}
+
+// MustParseMoney is ParseMoney for constants in code, and panics on bad
+// input.
+func MustParseMoney(amount string) Money {
+	money, err := ParseMoney(amount)
+	if err != nil {
+		panic(err)
+	}
+	return money
+}
+
+// Kobo returns the amount in kobo.
+func (m Money) Kobo() int64 {
+	return m.kobo
+}
+
+func (m Money) Currency() string {
+	if m.currency == "" {
+		return CurrencyNGN
+	}
+	return m.currency
+}
+
+// String formats the amount with exactly two decimal places, without a
+// currency symbol.
+func (m Money) String() string {
+	sign := ""
+	kobo := m.kobo
+	if kobo < 0 {
+		sign = "-"
+		kobo = -kobo
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, kobo/100, kobo%100)
+}
+
+func (m Money) IsZero() bool {
+	return m.kobo == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.kobo < 0
+}
+
+// Cmp returns -1, 0 or 1 as m is less than, equal to or more than other.
+func (m Money) Cmp(other Money) int {
+	m.sameCurrency(other)
+	switch {
+	case m.kobo < other.kobo:
+		return -1
+	case m.kobo > other.kobo:
+		return 1
+	}
+	return 0
+}
+
+func (m Money) Add(other Money) Money {
+	m.sameCurrency(other)
+	sum := m.kobo + other.kobo
+	if (sum > m.kobo) != (other.kobo > 0) {
+		panic("money: overflow")
+	}
+	return Money{kobo: sum, currency: m.Currency()}
+}
+
+func (m Money) Sub(other Money) Money {
+	return m.Add(Money{kobo: -other.kobo, currency: other.currency})
+}
+
+// Mul multiplies by a whole number, such as a quantity.
+func (m Money) Mul(n int64) Money {
+	if n != 0 && (m.kobo*n)/n != m.kobo {
+		panic("money: overflow")
+	}
+	return Money{kobo: m.kobo * n, currency: m.Currency()}
+}
+
+// Percent returns percent per cent of m, rounded half away from zero to the
+// nearest kobo. Every percentage charge (fees, surges, tax) goes through
+// here or MulRatio so they all round the same way.
+func (m Money) Percent(percent int64) Money {
+	return m.MulRatio(percent, 100)
+}
+
+// MulRatio returns m*num/den rounded half away from zero to the nearest
+// kobo, for rates finer than a whole percent such as 7.5% VAT.
+func (m Money) MulRatio(num, den int64) Money {
+	if den <= 0 {
+		panic("money: ratio denominator must be positive")
+	}
+	product := m.Mul(num).kobo
+	half := den / 2
+	if product < 0 {
+		half = -half
+	}
+	return Money{kobo: (product + half) / den, currency: m.Currency()}
+}
+
+// Split divides m into n parts that add back up to m exactly. The odd kobo
+// left over go one each to the first parts.
+func (m Money) Split(n int) []Money {
+	parts := make([]Money, n)
+	if n == 0 {
+		return parts
+	}
+
+	share, left := m.kobo/int64(n), m.kobo%int64(n)
+	for i := range parts {
+		parts[i] = Money{kobo: share, currency: m.Currency()}
+		if int64(i) < left {
+			parts[i].kobo++
+		}
+	}
+	return parts
+}
+
+// Min returns the smaller of m and other.
+func (m Money) Min(other Money) Money {
+	if m.Cmp(other) <= 0 {
+		return m
+	}
+	return other
+}
+
+func (m Money) sameCurrency(other Money) {
+	if m.Currency() != other.Currency() {
+		panic(fmt.Sprintf("money: cannot combine %s with %s", m.Currency(), other.Currency()))
+	}
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON accepts the amount as a string, "1500.50", or as a bare
+// number, 1500.50. Either way the digits are read exactly.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := strings.TrimSpace(string(data))
+	if text == "null" {
+		return nil
+	}
+
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+
+	money, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	*m = money
+	return nil
+}
+
+// Value stores the amount as a bigint of kobo.
+func (m Money) Value() (driver.Value, error) {
+	return m.kobo, nil
+}
+
+func (m *Money) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case int64:
+		*m = Kobo(value)
+	case []byte:
+		kobo, err := strconv.ParseInt(string(value), 10, 64)
+		if err != nil {
+			return fmt.Errorf("money: cannot scan %q", value)
+		}
+		*m = Kobo(kobo)
+	case string:
+		kobo, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("money: cannot scan %q", value)
+		}
+		*m = Kobo(kobo)
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+	return nil
+}
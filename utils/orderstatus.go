@@ -0,0 +1,82 @@
+package utils
+
+// Order statuses in the order they normally happen. Cancelled and rejected
+// are terminal side exits. Scheduled orders wait in OrderScheduled until they
+// are released to the shop.
+const (
+	OrderScheduled = "scheduled"
+	OrderPending   = "pending"
+	OrderAccepted  = "accepted"
+	OrderPreparing = "preparing"
+	OrderReady     = "ready"
+	OrderPickedUp  = "picked_up"
+	OrderDelivered = "delivered"
+	OrderCancelled = "cancelled"
+	OrderRejected  = "rejected"
+)
+
+// Who is moving an order along. These are relationships to the order, not
+// account roles: a vendor is whoever owns the order's shop.
+const (
+	ActorCustomer = "customer"
+	ActorVendor   = "vendor"
+	ActorRider    = "rider"
+	ActorAdmin    = "admin"
+	ActorSystem   = "system"
+)
+
+type orderTransition struct {
+	from, to string
+}
+
+// orderTransitions lists every allowed status change and the actors that may
+// make it. Anything not listed is refused. Admins can make any listed change.
+var orderTransitions = map[orderTransition][]string{
+	{OrderScheduled, OrderPending}:   {ActorSystem},
+	{OrderScheduled, OrderCancelled}: {ActorCustomer, ActorVendor},
+	{OrderPending, OrderAccepted}:    {ActorVendor},
+	{OrderPending, OrderRejected}:    {ActorVendor, ActorSystem},
+	{OrderPending, OrderCancelled}:   {ActorCustomer, ActorVendor},
+	{OrderAccepted, OrderPreparing}:  {ActorVendor},
+	{OrderAccepted, OrderCancelled}:  {ActorCustomer, ActorVendor},
+	{OrderPreparing, OrderReady}:     {ActorVendor},
+	{OrderPreparing, OrderCancelled}: {ActorCustomer, ActorVendor},
+	{OrderReady, OrderCancelled}:     {ActorCustomer, ActorVendor},
+	{OrderReady, OrderPickedUp}:      {ActorRider},
+	{OrderPickedUp, OrderDelivered}:  {ActorRider},
+}
+
+// CanTransitionOrder reports whether actor may move an order from one status
+// to another.
+func CanTransitionOrder(from, to, actor string) bool {
+	actors, ok := orderTransitions[orderTransition{from, to}]
+	if !ok {
+		return false
+	}
+	if actor == ActorAdmin {
+		return true
+	}
+	for _, allowed := range actors {
+		if allowed == actor {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOrderStatuses returns the statuses actor may move an order to from its
+// current status, in lifecycle order.
+func NextOrderStatuses(from, actor string) []string {
+	next := []string{}
+	for _, to := range []string{OrderPending, OrderAccepted, OrderPreparing, OrderReady, OrderPickedUp, OrderDelivered, OrderCancelled, OrderRejected} {
+		if CanTransitionOrder(from, to, actor) {
+			next = append(next, to)
+		}
+	}
+	return next
+}
+
+// IsFinalOrderStatus reports whether an order can no longer change.
+func IsFinalOrderStatus(status string) bool {
+	return status == OrderDelivered || status == OrderCancelled || status == OrderRejected
+}
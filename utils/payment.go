@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Payment statuses. A payment starts pending and is settled exactly once,
+// either by the webhook or by the customer checking on it.
+const (
+	PaymentPending   = "pending"
+	PaymentSucceeded = "succeeded"
+	PaymentFailed    = "failed"
+)
+
+// Order payment statuses.
+const (
+	OrderUnpaid        = "unpaid"
+	OrderPaymentFailed = "failed"
+	OrderPaid          = "paid"
+
+	OrderPartiallyRefunded = "partially_refunded"
+	OrderRefunded          = "refunded"
+)
+
+// PaymentInProgressFor is how long a pending payment keeps a second one for
+// the same thing from being started. After that the customer is taken to
+// have given up on it; if it goes through anyway it is refunded as a
+// duplicate.
+const PaymentInProgressFor = 30 * time.Minute
+
+var (
+	ErrInvalidSignature = errors.New("webhook signature does not match")
+	ErrPaymentNotFound  = errors.New("payment not found at provider")
+)
+
+// DeclinedError is a provider answering a request with a refusal. Any other
+// error, such as a timeout or a server error, leaves it unknown whether the
+// provider acted on the request.
+type DeclinedError struct {
+	Message string
+}
+
+func (e *DeclinedError) Error() string {
+	return e.Message
+}
+
+// Declined reports whether err is the provider refusing a request, so that
+// nothing can have happened there.
+func Declined(err error) bool {
+	var declined *DeclinedError
+	return errors.As(err, &declined)
+}
+
+// PaymentRequest is what is sent to a provider to start taking a payment.
+// Reference is ours and is how the provider refers back to it.
+type PaymentRequest struct {
+	Reference   string
+	Amount      Money
+	Email       string
+	CallbackURL string
+	Metadata    map[string]string
+}
+
+// PaymentSession is where to send the customer to pay.
+type PaymentSession struct {
+	AuthorizationURL string
+	AccessCode       string
+}
+
+// PaymentResult is the provider's account of a payment. Amount is what was
+// actually charged and must be checked against what was asked for.
+type PaymentResult struct {
+	Reference         string
+	ProviderReference string
+	Status            string
+	Amount            Money
+	Channel           string
+	PaidAt            time.Time
+	Message           string
+}
+
+// RefundRequest refunds part or all of a settled payment. Some providers
+// refund by their own transaction id, so both references are passed. ID is
+// ours for the refund and is sent as the idempotency key, so the same refund
+// asked for twice is only paid once.
+type RefundRequest struct {
+	ID                string
+	Reference         string
+	ProviderReference string
+	Amount            Money
+}
+
+type idempotencyKey struct{}
+
+// withIdempotencyKey marks the provider requests made with ctx as retries
+// of one another when key is set.
+func withIdempotencyKey(ctx context.Context, key string) context.Context {
+	if key == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// setIdempotencyKey puts the key from withIdempotencyKey on a request.
+func setIdempotencyKey(req *http.Request) {
+	if key, ok := req.Context().Value(idempotencyKey{}).(string); ok {
+		req.Header.Set("Idempotency-Key", key)
+	}
+}
+
+// RefundResult is where a refund has got to at the provider, as one of the
+// refund statuses: processing, refunded or failed.
+type RefundResult struct {
+	ProviderReference string
+	Status            string
+	Message           string
+}
+
+// PaymentProvider takes payments through a payment gateway. Webhook checks
+// the signature on a notification and returns the reference it is about;
+// the payment itself is always read back with Verify rather than trusted
+// from the notification body.
+type PaymentProvider interface {
+	Name() string
+	Initialize(ctx context.Context, req PaymentRequest) (PaymentSession, error)
+	Verify(ctx context.Context, reference string) (PaymentResult, error)
+	Refund(ctx context.Context, req RefundRequest) (RefundResult, error)
+	RefundStatus(ctx context.Context, providerReference string) (RefundResult, error)
+	Webhook(header http.Header, body []byte) (string, error)
+}
+
+// NewPaymentProvider picks the provider configured by PAYMENT_PROVIDER. The
+// fake provider lets anyone with a payment's reference settle it, so it is
+// only used when asked for by name, never as a fallback.
+func NewPaymentProvider(config *Config) (PaymentProvider, error) {
+	switch config.PaymentProvider {
+	case "":
+		return nil, errors.New(`PAYMENT_PROVIDER is not set; set it to "fake" for development`)
+	case "fake":
+		return NewFakePaymentProvider(), nil
+	case "paystack":
+		return NewPaystackProvider(config.PaystackSecretKey)
+	case "flutterwave":
+		return NewFlutterwaveProvider(config.FlutterwaveSecretKey, config.FlutterwaveWebhookHash)
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", config.PaymentProvider)
+	}
+}
+
+// PaymentChannel prints how a payment was made for receipts, such as
+// "Card via Paystack".
+func PaymentChannel(channel, provider string) string {
+	names := map[string]string{
+		"card":          "Card",
+		"bank":          "Bank",
+		"bank_transfer": "Bank transfer",
+		"banktransfer":  "Bank transfer",
+		"ussd":          "USSD",
+		"mobile_money":  "Mobile money",
+		"wallet":        "Wallet",
+	}
+	providers := map[string]string{
+		"paystack":    "Paystack",
+		"flutterwave": "Flutterwave",
+		"fake":        "test provider",
+	}
+
+	name, ok := names[channel]
+	if !ok {
+		name = "Online payment"
+	}
+	if by, ok := providers[provider]; ok {
+		return name + " via " + by
+	}
+	return name
+}
+
+func equalSignature(got, want string) bool {
+	return got != "" && hmac.Equal([]byte(got), []byte(want))
+}
@@ -0,0 +1,301 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const paystackBaseURL = "https://api.paystack.co"
+
+// PaystackProvider takes payments through the Paystack transaction API.
+// Amounts are sent in kobo, which is what Paystack expects.
+type PaystackProvider struct {
+	secretKey string
+	client    *http.Client
+}
+
+type paystackResponse struct {
+	Status  bool            `json:"status"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type paystackTransaction struct {
+	ID        int64  `json:"id"`
+	Reference string `json:"reference"`
+	Status    string `json:"status"`
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+	Channel   string `json:"channel"`
+	PaidAt    string `json:"paid_at"`
+	Message   string `json:"gateway_response"`
+}
+
+func NewPaystackProvider(secretKey string) (*PaystackProvider, error) {
+	if secretKey == "" {
+		return nil, errors.New("paystack secret key is not configured")
+	}
+
+	return &PaystackProvider{
+		secretKey: secretKey,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}, nil
+}
+
+func (p *PaystackProvider) Name() string {
+	return "paystack"
+}
+
+func (p *PaystackProvider) Initialize(ctx context.Context, req PaymentRequest) (PaymentSession, error) {
+	session := PaymentSession{}
+
+	body := map[string]interface{}{
+		"reference":    req.Reference,
+		"amount":       req.Amount.Kobo(),
+		"currency":     req.Amount.Currency(),
+		"email":        req.Email,
+		"callback_url": req.CallbackURL,
+		"metadata":     req.Metadata,
+	}
+
+	data := struct {
+		AuthorizationURL string `json:"authorization_url"`
+		AccessCode       string `json:"access_code"`
+	}{}
+	if err := p.do(ctx, http.MethodPost, "/transaction/initialize", body, &data); err != nil {
+		return session, err
+	}
+
+	session.AuthorizationURL = data.AuthorizationURL
+	session.AccessCode = data.AccessCode
+	return session, nil
+}
+
+func (p *PaystackProvider) Verify(ctx context.Context, reference string) (PaymentResult, error) {
+	result := PaymentResult{Reference: reference}
+
+	transaction := paystackTransaction{}
+	if err := p.do(ctx, http.MethodGet, "/transaction/verify/"+url.PathEscape(reference), nil, &transaction); err != nil {
+		return result, err
+	}
+
+	result.ProviderReference = strconv.FormatInt(transaction.ID, 10)
+	result.Amount = Money{kobo: transaction.Amount, currency: transaction.Currency}
+	result.Channel = transaction.Channel
+	result.Message = transaction.Message
+
+	switch transaction.Status {
+	case "success":
+		result.Status = PaymentSucceeded
+		result.PaidAt, _ = time.Parse(time.RFC3339, transaction.PaidAt)
+	case "failed", "abandoned", "reversed":
+		result.Status = PaymentFailed
+	default:
+		result.Status = PaymentPending
+	}
+	return result, nil
+}
+
+func (p *PaystackProvider) Refund(ctx context.Context, req RefundRequest) (RefundResult, error) {
+	result := RefundResult{}
+
+	body := map[string]interface{}{
+		"transaction": req.Reference,
+		"amount":      req.Amount.Kobo(),
+	}
+
+	data := paystackRefund{}
+	if err := p.do(withIdempotencyKey(ctx, req.ID), http.MethodPost, "/refund", body, &data); err != nil {
+		return result, err
+	}
+	return data.result(), nil
+}
+
+func (p *PaystackProvider) RefundStatus(ctx context.Context, providerReference string) (RefundResult, error) {
+	data := paystackRefund{}
+	if err := p.do(ctx, http.MethodGet, "/refund/"+url.PathEscape(providerReference), nil, &data); err != nil {
+		return RefundResult{}, err
+	}
+	return data.result(), nil
+}
+
+type paystackRefund struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+}
+
+func (r paystackRefund) result() RefundResult {
+	result := RefundResult{ProviderReference: strconv.FormatInt(r.ID, 10), Status: RefundProcessing}
+
+	switch r.Status {
+	case "processed":
+		result.Status = RefundCompleted
+	case "failed":
+		result.Status = RefundFailed
+		result.Message = "Refund failed at Paystack"
+	}
+	return result
+}
+
+// Webhook checks the x-paystack-signature header, an HMAC-SHA512 of the body
+// keyed with the secret key.
+func (p *PaystackProvider) Webhook(header http.Header, body []byte) (string, error) {
+	mac := hmac.New(sha512.New, []byte(p.secretKey))
+	mac.Write(body)
+
+	if !equalSignature(header.Get("x-paystack-signature"), hex.EncodeToString(mac.Sum(nil))) {
+		return "", ErrInvalidSignature
+	}
+
+	event := struct {
+		Event string `json:"event"`
+		Data  struct {
+			Reference string `json:"reference"`
+		} `json:"data"`
+	}{}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return "", fmt.Errorf("paystack: could not decode webhook: %v", err)
+	}
+	return event.Data.Reference, nil
+}
+
+func (p *PaystackProvider) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, paystackBaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.secretKey)
+	req.Header.Set("Content-Type", "application/json")
+	setIdempotencyKey(req)
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resp := paystackResponse{}
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return fmt.Errorf("paystack: could not decode response: %v", err)
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		return ErrPaymentNotFound
+	}
+	if res.StatusCode >= 500 {
+		return fmt.Errorf("paystack: %s", resp.Message)
+	}
+	if !resp.Status || res.StatusCode >= 300 {
+		return &DeclinedError{Message: "paystack: " + resp.Message}
+	}
+
+	if err := json.Unmarshal(resp.Data, out); err != nil {
+		return fmt.Errorf("paystack: could not decode response: %v", err)
+	}
+	return nil
+}
+
+// VerifyAccount resolves the account's name with the bank and registers it
+// as a transfer recipient.
+func (p *PaystackProvider) VerifyAccount(ctx context.Context, account BankAccount) (VerifiedAccount, error) {
+	verified := VerifiedAccount{}
+
+	query := url.Values{}
+	query.Set("account_number", account.AccountNumber)
+	query.Set("bank_code", account.BankCode)
+
+	resolved := struct {
+		AccountName string `json:"account_name"`
+	}{}
+	if err := p.do(ctx, http.MethodGet, "/bank/resolve?"+query.Encode(), nil, &resolved); err != nil {
+		if errors.Is(err, ErrPaymentNotFound) || Declined(err) {
+			return verified, ErrAccountNotFound
+		}
+		return verified, err
+	}
+
+	body := map[string]interface{}{
+		"type":           "nuban",
+		"name":           resolved.AccountName,
+		"account_number": account.AccountNumber,
+		"bank_code":      account.BankCode,
+		"currency":       CurrencyNGN,
+	}
+
+	recipient := struct {
+		RecipientCode string `json:"recipient_code"`
+	}{}
+	if err := p.do(ctx, http.MethodPost, "/transferrecipient", body, &recipient); err != nil {
+		return verified, err
+	}
+
+	verified.AccountName = resolved.AccountName
+	verified.RecipientCode = recipient.RecipientCode
+	return verified, nil
+}
+
+// Transfer pays out of the Paystack balance.
+func (p *PaystackProvider) Transfer(ctx context.Context, req TransferRequest) (TransferResult, error) {
+	body := map[string]interface{}{
+		"source":    "balance",
+		"amount":    req.Amount.Kobo(),
+		"recipient": req.RecipientCode,
+		"reference": req.Reference,
+		"reason":    req.Reason,
+	}
+
+	transfer := paystackTransfer{}
+	if err := p.do(ctx, http.MethodPost, "/transfer", body, &transfer); err != nil {
+		return TransferResult{}, err
+	}
+	return transfer.result(), nil
+}
+
+func (p *PaystackProvider) TransferStatus(ctx context.Context, reference string) (TransferResult, error) {
+	transfer := paystackTransfer{}
+	if err := p.do(ctx, http.MethodGet, "/transfer/verify/"+url.PathEscape(reference), nil, &transfer); err != nil {
+		return TransferResult{}, err
+	}
+	return transfer.result(), nil
+}
+
+type paystackTransfer struct {
+	TransferCode string `json:"transfer_code"`
+	Status       string `json:"status"`
+	Reason       string `json:"reason"`
+}
+
+func (t paystackTransfer) result() TransferResult {
+	result := TransferResult{ProviderReference: t.TransferCode, Status: PayoutProcessing}
+
+	switch t.Status {
+	case "success":
+		result.Status = PayoutPaid
+	case "failed", "reversed", "abandoned":
+		result.Status = PayoutFailed
+		result.Message = t.Reason
+	}
+	return result
+}
@@ -0,0 +1,267 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	_ "embed"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// A4 page size in points.
+const (
+	PageWidth  = 595.0
+	PageHeight = 842.0
+)
+
+// PDF is a minimal writer for plain text documents: DejaVu Sans text,
+// rules and as many A4 pages as needed. Coordinates are in points from the
+// bottom left corner, as in PDF itself. The fonts are embedded, cut down to
+// the glyphs used, so names and the naira sign print as they are written;
+// characters the font does not have print as "?".
+type PDF struct {
+	pages []*bytes.Buffer
+	used  [2]map[uint16]rune
+}
+
+func NewPDF() *PDF {
+	pdf := &PDF{used: [2]map[uint16]rune{{}, {}}}
+	pdf.AddPage()
+	return pdf
+}
+
+func (p *PDF) AddPage() {
+	p.pages = append(p.pages, &bytes.Buffer{})
+}
+
+func (p *PDF) page() *bytes.Buffer {
+	return p.pages[len(p.pages)-1]
+}
+
+// Text writes text with its baseline starting at x, y.
+func (p *PDF) Text(x, y, size float64, bold bool, text string) {
+	style := 0
+	if bold {
+		style = 1
+	}
+	fmt.Fprintf(p.page(), "BT /F%d %.1f Tf %.2f %.2f Td <%s> Tj ET\n", style+1, size, x, y, p.encode(style, text))
+}
+
+// encode writes text as the hex glyph IDs that Identity-H expects, noting
+// each glyph so it is kept in the embedded font.
+func (p *PDF) encode(style int, text string) string {
+	font := pdfFonts()[style]
+
+	encoded := strings.Builder{}
+	for _, r := range text {
+		if r == '\n' || r == '\r' || r == '\t' {
+			r = ' '
+		}
+		id, ok := font.glyphs[r]
+		if !ok || r < 32 {
+			r = '?'
+			id = font.glyphs[r]
+		}
+		if _, seen := p.used[style][id]; !seen {
+			p.used[style][id] = r
+		}
+		fmt.Fprintf(&encoded, "%04X", id)
+	}
+	return encoded.String()
+}
+
+// TextRight writes text so that it ends at x, for columns of amounts.
+func (p *PDF) TextRight(x, y, size float64, bold bool, text string) {
+	p.Text(x-TextWidth(text, size, bold), y, size, bold, text)
+}
+
+// Line draws a thin rule from x1, y1 to x2, y2.
+func (p *PDF) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(p.page(), "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+}
+
+// Bytes lays the pages out as a complete PDF file.
+func (p *PDF) Bytes() []byte {
+	out := &bytes.Buffer{}
+	offsets := []int{}
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	stream := func(dict string, data []byte) {
+		object(fmt.Sprintf("<< /Length %d%s >>\nstream\n%s\nendstream", len(data), dict, data))
+	}
+
+	out.WriteString("%PDF-1.4\n")
+
+	// Objects 1 and 2 are fixed; each page then takes two, its page
+	// dictionary and its content stream, and each font five after them.
+	kids := []string{}
+	for i := range p.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 3+i*2))
+	}
+	fontsAt := 3 + len(p.pages)*2
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+
+	for i, page := range p.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 %d 0 R /F2 %d 0 R >> >> /Contents %d 0 R >>",
+			PageWidth, PageHeight, fontsAt, fontsAt+5, 4+i*2))
+		stream("", page.Bytes())
+	}
+
+	for style, font := range pdfFonts() {
+		first := fontsAt + style*5
+		name := subsetTag(p.used[style]) + "+" + pdfFontNames[style]
+
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+			name, first+1, first+4))
+		object(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /CIDToGIDMap /Identity /W [%s] >>",
+			name, first+2, pdfWidths(font, p.used[style])))
+		object(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+			name, font.scale(font.bbox[0]), font.scale(font.bbox[1]), font.scale(font.bbox[2]), font.scale(font.bbox[3]),
+			font.scale(font.ascent), font.scale(font.descent), font.scale(font.capHeight), first+3))
+
+		subset := font.subset(p.used[style])
+		compressed := &bytes.Buffer{}
+		writer := zlib.NewWriter(compressed)
+		writer.Write(subset)
+		writer.Close()
+		stream(fmt.Sprintf(" /Length1 %d /Filter /FlateDecode", len(subset)), compressed.Bytes())
+
+		stream("", toUnicode(p.used[style]))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+//go:embed fonts/DejaVuSans.ttf
+var dejaVuSans []byte
+
+//go:embed fonts/DejaVuSans-Bold.ttf
+var dejaVuSansBold []byte
+
+var pdfFontNames = [2]string{"DejaVuSans", "DejaVuSans-Bold"}
+
+var (
+	pdfFontsOnce sync.Once
+	pdfFontFaces [2]*trueType
+)
+
+// pdfFonts parses the embedded regular and bold fonts the first time they
+// are needed. They are part of the binary, so failing to read them is a
+// build mistake rather than something to handle.
+func pdfFonts() [2]*trueType {
+	pdfFontsOnce.Do(func() {
+		for i, data := range [][]byte{dejaVuSans, dejaVuSansBold} {
+			font, err := parseTrueType(data)
+			if err != nil {
+				panic(fmt.Sprintf("could not read %s: %v", pdfFontNames[i], err))
+			}
+			pdfFontFaces[i] = font
+		}
+	})
+	return pdfFontFaces
+}
+
+// pdfWidths lists the width of every glyph used, so viewers space the text
+// as it was laid out.
+func pdfWidths(font *trueType, used map[uint16]rune) string {
+	widths := strings.Builder{}
+	for _, id := range sortedGlyphs(used) {
+		fmt.Fprintf(&widths, "%d [%.0f] ", id, font.width(id))
+	}
+	return strings.TrimSpace(widths.String())
+}
+
+// toUnicode maps glyphs back to characters so text can be copied and
+// searched.
+func toUnicode(used map[uint16]rune) []byte {
+	cmap := &bytes.Buffer{}
+	cmap.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	cmap.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	cmap.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	cmap.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+
+	// A bfchar section holds at most 100 entries.
+	glyphs := sortedGlyphs(used)
+	for len(glyphs) > 0 {
+		n := len(glyphs)
+		if n > 100 {
+			n = 100
+		}
+		fmt.Fprintf(cmap, "%d beginbfchar\n", n)
+		for _, id := range glyphs[:n] {
+			fmt.Fprintf(cmap, "<%04X> <", id)
+			for _, unit := range utf16.Encode([]rune{used[id]}) {
+				fmt.Fprintf(cmap, "%04X", unit)
+			}
+			cmap.WriteString(">\n")
+		}
+		cmap.WriteString("endbfchar\n")
+		glyphs = glyphs[n:]
+	}
+
+	cmap.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend")
+	return cmap.Bytes()
+}
+
+func sortedGlyphs(used map[uint16]rune) []uint16 {
+	glyphs := make([]uint16, 0, len(used))
+	for id := range used {
+		glyphs = append(glyphs, id)
+	}
+	sort.Slice(glyphs, func(i, j int) bool { return glyphs[i] < glyphs[j] })
+	return glyphs
+}
+
+// subsetTag is the six capital letters PDF puts before the name of a
+// font that is only partly embedded. It comes from the glyphs kept, so the
+// same text always gets the same tag.
+func subsetTag(used map[uint16]rune) string {
+	hash := fnv.New32a()
+	for _, id := range sortedGlyphs(used) {
+		binary.Write(hash, binary.BigEndian, id)
+	}
+	sum := hash.Sum32()
+
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = 'A' + byte(sum%26)
+		sum /= 26
+	}
+	return string(tag)
+}
+
+// TextWidth is how wide text is at the given size, measured from the font
+// so right-aligned columns line up.
+func TextWidth(text string, size float64, bold bool) float64 {
+	font := pdfFonts()[0]
+	if bold {
+		font = pdfFonts()[1]
+	}
+
+	width := 0.0
+	for _, r := range text {
+		id, ok := font.glyphs[r]
+		if !ok {
+			id = font.glyphs['?']
+		}
+		width += font.width(id)
+	}
+	return width * size / 1000
+}
@@ -2,6 +2,8 @@ package utils
 
 import (
 	"math/rand"
+
+	"github.com/nrednav/cuid2"
 )
 
 var alphabets = "abcdefghijklmnopqrstuvwxyz"
@@ -59,10 +61,22 @@ func RandomText() string {
 	return RandomString(100)
 }
 
-func RandomPrice() string {
-	return RandIntegers(5) + "." + RandIntegers(2)
+func RandomPrice() Money {
+	return Kobo(int64(randomInteger(100, 9999999)))
 }
 
 func RandomQty() int32 {
 	return randomInteger(1, 2000)
 }
+
+// NewID returns a 32 character cuid, the id format used for every table.
+func NewID() (string, error) {
+	generate, err := cuid2.Init(
+		cuid2.WithLength(32),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return generate(), nil
+}
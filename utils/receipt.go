@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReceiptLine is one item on a receipt with its options already named.
+type ReceiptLine struct {
+	Name      string
+	Quantity  int32
+	UnitPrice Money
+	LineTotal Money
+	Options   []string
+	Note      string
+	VATExempt bool
+}
+
+// Receipt holds everything printed on an order's receipt. It is filled in
+// from what was stored when the order was placed, never from current
+// prices.
+type Receipt struct {
+	ShopName    string
+	ShopAddress string
+	ShopPhone   string
+
+	InvoiceNumber   string
+	OrderID         string
+	PlacedAt        time.Time
+	DeliveredAt     time.Time
+	CustomerName    string
+	DeliveryAddress string
+	PaymentMethod   string
+
+	Lines []ReceiptLine
+
+	Subtotal         Money
+	ServiceCharge    Money
+	VAT              Money
+	VATRate          string
+	PricesIncludeVAT bool
+	DeliveryFee      Money
+	Discount         Money
+	Tip              Money
+	Total            Money
+}
+
+const (
+	receiptMargin = 50.0
+	receiptBottom = 60.0
+	receiptLineHt = 14.0
+)
+
+// RenderReceipt lays a receipt out as a PDF, carrying items over to new
+// pages when they do not fit.
+func RenderReceipt(r Receipt) []byte {
+	pdf := NewPDF()
+	right := PageWidth - receiptMargin
+	y := PageHeight - receiptMargin
+
+	next := func(lines float64) {
+		y -= receiptLineHt * lines
+		if y < receiptBottom {
+			pdf.AddPage()
+			y = PageHeight - receiptMargin
+		}
+	}
+
+	pdf.Text(receiptMargin, y, 18, true, r.ShopName)
+	pdf.TextRight(right, y, 14, true, "RECEIPT")
+	next(1.5)
+	pdf.Text(receiptMargin, y, 10, false, r.ShopAddress)
+	if r.InvoiceNumber != "" {
+		pdf.TextRight(right, y, 10, false, "Invoice "+r.InvoiceNumber)
+	}
+	next(1)
+	pdf.Text(receiptMargin, y, 10, false, r.ShopPhone)
+	pdf.TextRight(right, y, 10, false, "Order "+r.OrderID)
+	next(2)
+
+	details := [][2]string{
+		{"Customer", r.CustomerName},
+		{"Deliver to", r.DeliveryAddress},
+		{"Placed", r.PlacedAt.Format("2 Jan 2006 15:04")},
+		{"Delivered", r.DeliveredAt.Format("2 Jan 2006 15:04")},
+		{"Payment", r.PaymentMethod},
+	}
+	for _, detail := range details {
+		pdf.Text(receiptMargin, y, 10, true, detail[0])
+		pdf.Text(receiptMargin+80, y, 10, false, truncate(detail[1], 80))
+		next(1)
+	}
+	next(1)
+
+	qtyX := receiptMargin + 300
+	unitX := receiptMargin + 390
+	pdf.Text(receiptMargin, y, 10, true, "Item")
+	pdf.TextRight(qtyX, y, 10, true, "Qty")
+	pdf.TextRight(unitX, y, 10, true, "Unit price")
+	pdf.TextRight(right, y, 10, true, "Amount")
+	next(0.5)
+	pdf.Line(receiptMargin, y, right, y)
+	next(1.2)
+
+	for _, line := range r.Lines {
+		name := truncate(line.Name, 50)
+		if line.VATExempt {
+			name += " *"
+		}
+		pdf.Text(receiptMargin, y, 10, false, name)
+		pdf.TextRight(qtyX, y, 10, false, fmt.Sprint(line.Quantity))
+		pdf.TextRight(unitX, y, 10, false, line.UnitPrice.String())
+		pdf.TextRight(right, y, 10, false, line.LineTotal.String())
+		next(1)
+
+		for _, option := range line.Options {
+			pdf.Text(receiptMargin+12, y, 9, false, truncate(option, 60))
+			next(1)
+		}
+		if line.Note != "" {
+			pdf.Text(receiptMargin+12, y, 9, false, "Note: "+truncate(line.Note, 70))
+			next(1)
+		}
+	}
+
+	next(-0.5)
+	pdf.Line(receiptMargin, y, right, y)
+	next(1.2)
+
+	vatLabel := "VAT " + r.VATRate
+	if r.PricesIncludeVAT {
+		vatLabel += " (included in prices)"
+	}
+	totals := []struct {
+		label  string
+		amount Money
+	}{
+		{"Subtotal", r.Subtotal},
+		{"Service charge", r.ServiceCharge},
+		{vatLabel, r.VAT},
+		{"Delivery", r.DeliveryFee},
+	}
+	if !r.Discount.IsZero() {
+		totals = append(totals, struct {
+			label  string
+			amount Money
+		}{"Discount", Kobo(0).Sub(r.Discount)})
+	}
+	if !r.Tip.IsZero() {
+		totals = append(totals, struct {
+			label  string
+			amount Money
+		}{"Rider tip", r.Tip})
+	}
+	for _, total := range totals {
+		pdf.TextRight(unitX, y, 10, false, total.label)
+		pdf.TextRight(right, y, 10, false, total.amount.String())
+		next(1)
+	}
+	pdf.TextRight(unitX, y, 12, true, "Total ("+r.Total.Currency()+")")
+	pdf.TextRight(right, y, 12, true, r.Total.String())
+	next(2)
+
+	for _, line := range r.Lines {
+		if line.VATExempt {
+			pdf.Text(receiptMargin, y, 9, false, "* Exempt from VAT.")
+			next(1)
+			break
+		}
+	}
+	pdf.Text(receiptMargin, y, 9, false, "Thank you for your order.")
+
+	return pdf.Bytes()
+}
+
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-3]) + "..."
+}
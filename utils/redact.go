@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// SecretQueryParams are query parameters that carry credentials and must not
+// end up in access logs.
+var SecretQueryParams = []string{"access_token"}
+
+// RedactQuery hides the values of the given query parameters in a request
+// path such as "/orders/1/track?access_token=...". The rest of the path is
+// left as it was.
+func RedactQuery(path string, keys ...string) string {
+	base, raw, found := strings.Cut(path, "?")
+	if !found {
+		return path
+	}
+
+	query, err := url.ParseQuery(raw)
+	if err != nil {
+		// Better to lose the query than to log a token we could not find.
+		return base + "?REDACTED"
+	}
+
+	redacted := false
+	for _, key := range keys {
+		if query.Has(key) {
+			query.Set(key, "REDACTED")
+			redacted = true
+		}
+	}
+	if !redacted {
+		return path
+	}
+	return base + "?" + query.Encode()
+}
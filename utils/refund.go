@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"errors"
+	"time"
+)
+
+// Support refunds start awaiting approval when they are over the approval
+// threshold, and may be rejected there. From pending on they move through
+// the same states as cancellation refunds.
+const (
+	RefundAwaitingApproval = "awaiting_approval"
+	RefundRejected         = "rejected"
+)
+
+// Who asked for a refund. Duplicate refunds give back a payment made for
+// something that was already paid for.
+const (
+	RefundBySupport       = "support"
+	RefundForCancellation = "cancellation"
+	RefundForDuplicate    = "duplicate"
+)
+
+// DefaultRefundApproval is the largest refund one admin can give on their
+// own when REFUND_APPROVAL_THRESHOLD is not set.
+var DefaultRefundApproval = Naira(20000)
+
+var ErrRefundTooLarge = errors.New("refund is more than is left to refund")
+
+// RefundLostAfter is how long a refund sent without a word back from the
+// provider is left before it is sent again. Until then the first request
+// may still be on its way.
+const RefundLostAfter = 30 * time.Minute
+
+// NeedsApproval reports whether a support refund of amount must be
+// approved by a second admin.
+func NeedsApproval(amount, threshold Money) bool {
+	return amount.Cmp(threshold) > 0
+}
+
+// ItemRefund is what the customer paid for quantity of an order line. The
+// line's share of the service charge, VAT and any discount comes with it,
+// so refunding every line gives back everything but delivery and tips.
+// goods is what the customer paid for the food: the order total less
+// delivery and tips.
+func ItemRefund(lineTotal Money, quantity, lineQuantity int32, goods, subtotal Money) Money {
+	share := lineTotal.MulRatio(int64(quantity), int64(lineQuantity))
+	if subtotal.IsZero() {
+		return share
+	}
+	return share.MulRatio(goods.Kobo(), subtotal.Kobo())
+}
+
+// SplitRefund divides a refund of amount between sales and tips. Refunds
+// come out of sales first; only once everything but the tip has been given
+// back does the tip go too. refunded is what was already given back.
+func SplitRefund(amount, refunded, total, tip Money) (Money, Money) {
+	left := total.Sub(tip).Sub(refunded)
+	if left.Cmp(Kobo(0)) < 0 {
+		left = Kobo(0)
+	}
+	if amount.Cmp(left) <= 0 {
+		return amount, Kobo(0)
+	}
+	return left, amount.Sub(left)
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultScheduleRelease is how long before the requested time a
+	// scheduled order is sent to the shop when SCHEDULE_RELEASE_MINUTES is
+	// not set.
+	DefaultScheduleRelease = 45 * time.Minute
+
+	// MaxScheduleAhead is how far in the future an order can be scheduled.
+	MaxScheduleAhead = 7 * 24 * time.Hour
+
+	DefaultTimezone = "Africa/Lagos"
+)
+
+// OpeningHours is one opening window on a weekday, in minutes from midnight.
+// ClosesMinute may be 1440 for midnight, and a window that closes before it
+// opens runs past midnight into the next day.
+type OpeningHours struct {
+	Weekday      time.Weekday
+	OpensMinute  int
+	ClosesMinute int
+}
+
+// IsOpenAt reports whether t, already in the shop's timezone, falls inside
+// one of the windows. No windows at all means the shop keeps no fixed hours.
+func IsOpenAt(t time.Time, hours []OpeningHours) bool {
+	if len(hours) == 0 {
+		return true
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	yesterday := (t.Weekday() + 6) % 7
+
+	for _, window := range hours {
+		overnight := window.ClosesMinute <= window.OpensMinute
+
+		if window.Weekday == t.Weekday() {
+			if minute >= window.OpensMinute && (overnight || minute < window.ClosesMinute) {
+				return true
+			}
+		}
+		if overnight && window.Weekday == yesterday && minute < window.ClosesMinute {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseClock turns "HH:MM" into minutes from midnight. "24:00" is allowed as
+// a closing time.
+func ParseClock(clock string) (int, error) {
+	var hour, minute int
+	if _, err := fmt.Sscanf(clock, "%d:%d", &hour, &minute); err != nil || len(clock) != 5 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", clock)
+	}
+	if hour < 0 || minute < 0 || minute > 59 || hour > 24 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", clock)
+	}
+	return hour*60 + minute, nil
+}
+
+// FormatClock is the inverse of ParseClock.
+func FormatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
@@ -0,0 +1,70 @@
+package utils
+
+import "time"
+
+const (
+	// DefaultVendorCommission is the percentage of an order's subtotal the
+	// platform keeps when VENDOR_COMMISSION_PERCENT is not set.
+	DefaultVendorCommission = 15
+
+	// DefaultProcessingFeeBasisPoints passes the payment provider's fee,
+	// 1.5%, on to shops when PAYMENT_FEE_BASIS_POINTS is not set.
+	DefaultProcessingFeeBasisPoints = 150
+)
+
+// Payout statuses. A payout is pending until the transfer is sent, then
+// processing until the provider says it arrived or failed.
+const (
+	PayoutPending    = "pending"
+	PayoutProcessing = "processing"
+	PayoutPaid       = "paid"
+	PayoutFailed     = "failed"
+)
+
+// PayoutLostAfter is how long a transfer the provider has never heard of is
+// given to turn up before its payout is taken as failed. A request that
+// timed out may still be on its way.
+const PayoutLostAfter = 30 * time.Minute
+
+// MinPayout keeps transfer fees from eating small payouts.
+var MinPayout = Naira(1000)
+
+// VendorFees is what the platform takes from each order before paying the
+// shop. Commission is charged on the food only; the processing fee on
+// everything the customer paid the shop, which is what the provider
+// charges on.
+type VendorFees struct {
+	CommissionPercent        int64
+	ProcessingFeeBasisPoints int64
+}
+
+// VendorEarning is one order's money as far as its shop is concerned.
+type VendorEarning struct {
+	Gross         Money
+	Commission    Money
+	ProcessingFee Money
+	Net           Money
+}
+
+// Earning splits gross, what the customer paid for the shop's part of the
+// order, into the platform's cut and the shop's. Delivery fees and tips are
+// never part of gross.
+func (f VendorFees) Earning(gross, subtotal Money) VendorEarning {
+	commission := subtotal.Percent(f.CommissionPercent)
+	fee := gross.MulRatio(f.ProcessingFeeBasisPoints, basisPoints)
+
+	return VendorEarning{
+		Gross:         gross,
+		Commission:    commission,
+		ProcessingFee: fee,
+		Net:           gross.Sub(commission).Sub(fee),
+	}
+}
+
+// SettlementPeriod returns the week t falls in, from Monday midnight to the
+// next, in t's location.
+func SettlementPeriod(t time.Time) (time.Time, time.Time) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	return start, start.AddDate(0, 0, 7)
+}
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// DefaultVATBasisPoints is Nigerian VAT, 7.5%, used when TAX_RULES_FILE
+	// is not set. Rates are in basis points: 100 is one per cent.
+	DefaultVATBasisPoints = 750
+
+	basisPoints = 10000
+)
+
+// TaxRules says how VAT is charged. When PricesIncludeVAT is set, menu
+// prices already contain VAT and it is only broken out on the invoice;
+// otherwise it is added on top. Products whose category is listed in
+// ExemptCategories carry no VAT.
+type TaxRules struct {
+	VATBasisPoints   int64    `json:"vat_basis_points"`
+	PricesIncludeVAT bool     `json:"prices_include_vat"`
+	ExemptCategories []string `json:"exempt_categories"`
+}
+
+// TaxLine is one line of an order as far as tax is concerned.
+type TaxLine struct {
+	Amount   Money
+	Category string
+}
+
+// LineTax is the VAT worked out for one TaxLine.
+type LineTax struct {
+	VAT    Money
+	Exempt bool
+}
+
+// TaxBreakdown is the tax on one shop's order. Total is what the customer
+// pays before delivery: the subtotal, the service charge and any VAT that
+// was not already in the prices.
+type TaxBreakdown struct {
+	VATBasisPoints           int64
+	PricesIncludeVAT         bool
+	ServiceChargeBasisPoints int64
+
+	Subtotal      Money
+	ServiceCharge Money
+	Taxable       Money
+	VAT           Money
+	Total         Money
+	Lines         []LineTax
+}
+
+// DefaultTaxRules charges VAT on top of menu prices with no exemptions.
+func DefaultTaxRules() TaxRules {
+	return TaxRules{VATBasisPoints: DefaultVATBasisPoints}
+}
+
+// LoadTaxRules reads rules from a JSON file laid out like TaxRules.
+func LoadTaxRules(path string) (TaxRules, error) {
+	rules := TaxRules{}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return rules, err
+	}
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return rules, err
+	}
+	return rules, rules.validate()
+}
+
+func (r TaxRules) validate() error {
+	if r.VATBasisPoints < 0 || r.VATBasisPoints > basisPoints {
+		return fmt.Errorf("vat rate must be between 0 and %d basis points", basisPoints)
+	}
+	for _, category := range r.ExemptCategories {
+		if strings.TrimSpace(category) == "" {
+			return errors.New("exempt categories cannot be blank")
+		}
+	}
+	return nil
+}
+
+// Exempt reports whether products in category carry no VAT. Categories are
+// free text typed by vendors, so the match ignores case and spacing.
+func (r TaxRules) Exempt(category string) bool {
+	category = strings.TrimSpace(category)
+	for _, exempt := range r.ExemptCategories {
+		if strings.EqualFold(strings.TrimSpace(exempt), category) {
+			return true
+		}
+	}
+	return false
+}
+
+// Compute works out VAT line by line and the shop's service charge on the
+// subtotal. The service charge is not part of the menu prices, so VAT on it
+// is always added on top.
+func (r TaxRules) Compute(lines []TaxLine, serviceChargeBasisPoints int64) TaxBreakdown {
+	breakdown := TaxBreakdown{
+		VATBasisPoints:           r.VATBasisPoints,
+		PricesIncludeVAT:         r.PricesIncludeVAT,
+		ServiceChargeBasisPoints: serviceChargeBasisPoints,
+		Subtotal:                 Kobo(0),
+		Taxable:                  Kobo(0),
+		VAT:                      Kobo(0),
+		Lines:                    []LineTax{},
+	}
+
+	for _, line := range lines {
+		tax := LineTax{VAT: Kobo(0), Exempt: r.Exempt(line.Category)}
+		if !tax.Exempt {
+			if r.PricesIncludeVAT {
+				tax.VAT = line.Amount.Sub(line.Amount.MulRatio(basisPoints, basisPoints+r.VATBasisPoints))
+			} else {
+				tax.VAT = line.Amount.MulRatio(r.VATBasisPoints, basisPoints)
+			}
+			breakdown.Taxable = breakdown.Taxable.Add(line.Amount)
+		}
+
+		breakdown.Subtotal = breakdown.Subtotal.Add(line.Amount)
+		breakdown.VAT = breakdown.VAT.Add(tax.VAT)
+		breakdown.Lines = append(breakdown.Lines, tax)
+	}
+
+	breakdown.ServiceCharge = breakdown.Subtotal.MulRatio(serviceChargeBasisPoints, basisPoints)
+	serviceVAT := breakdown.ServiceCharge.MulRatio(r.VATBasisPoints, basisPoints)
+	breakdown.Taxable = breakdown.Taxable.Add(breakdown.ServiceCharge)
+	breakdown.VAT = breakdown.VAT.Add(serviceVAT)
+
+	added := breakdown.VAT
+	if r.PricesIncludeVAT {
+		added = serviceVAT
+	}
+	breakdown.Total = breakdown.Subtotal.Add(breakdown.ServiceCharge).Add(added)
+	return breakdown
+}
+
+// Add combines two breakdowns for the same shop, such as the members of a
+// group order. Rates are taken from whichever has lines.
+func (b TaxBreakdown) Add(other TaxBreakdown) TaxBreakdown {
+	if len(b.Lines) == 0 {
+		b.VATBasisPoints = other.VATBasisPoints
+		b.PricesIncludeVAT = other.PricesIncludeVAT
+		b.ServiceChargeBasisPoints = other.ServiceChargeBasisPoints
+	}
+
+	b.Subtotal = b.Subtotal.Add(other.Subtotal)
+	b.ServiceCharge = b.ServiceCharge.Add(other.ServiceCharge)
+	b.Taxable = b.Taxable.Add(other.Taxable)
+	b.VAT = b.VAT.Add(other.VAT)
+	b.Total = b.Total.Add(other.Total)
+	b.Lines = append(b.Lines, other.Lines...)
+	return b
+}
+
+// InvoiceNumber formats a shop's nth invoice. Numbers run per shop, so the
+// shop is always printed next to it.
+func InvoiceNumber(n int64) string {
+	return fmt.Sprintf("INV-%06d", n)
+}
+
+// FormatBasisPoints prints a rate for people, 750 as "7.5%".
+func FormatBasisPoints(bp int64) string {
+	whole, frac := bp/100, bp%100
+	switch {
+	case frac == 0:
+		return fmt.Sprintf("%d%%", whole)
+	case frac%10 == 0:
+		return fmt.Sprintf("%d.%d%%", whole, frac/10)
+	default:
+		return fmt.Sprintf("%d.%02d%%", whole, frac)
+	}
+}
@@ -0,0 +1,54 @@
+package utils
+
+import "time"
+
+const (
+	TipAtCheckout    = "checkout"
+	TipAfterDelivery = "after_delivery"
+
+	TipPending  = "pending"
+	TipCaptured = "captured"
+	TipFailed   = "failed"
+	TipRefunded = "refunded"
+
+	// DefaultRiderCommission is the percentage of delivery fees the platform
+	// keeps when RIDER_COMMISSION_PERCENT is not set.
+	DefaultRiderCommission = 20
+
+	// TipWindow is how long after delivery a customer can still add a tip.
+	TipWindow = 24 * time.Hour
+)
+
+// MaxTip stops a mistyped tip from being charged.
+var MaxTip = Naira(50000)
+
+// RiderEarnings is what a rider made over a period. Commission is only ever
+// taken from delivery fees; tips are passed on in full.
+type RiderEarnings struct {
+	From             time.Time `json:"from"`
+	To               time.Time `json:"to"`
+	Deliveries       int64     `json:"deliveries"`
+	DeliveryFees     Money     `json:"delivery_fees"`
+	CommissionRate   int64     `json:"commission_percent"`
+	Commission       Money     `json:"commission"`
+	DeliveryEarnings Money     `json:"delivery_earnings"`
+	Tips             Money     `json:"tips"`
+	Total            Money     `json:"total"`
+}
+
+// NewRiderEarnings takes commissionPercent of the delivery fees and adds the
+// tips on top untouched.
+func NewRiderEarnings(deliveries int64, fees, tips Money, commissionPercent int64) RiderEarnings {
+	commission := fees.Percent(commissionPercent)
+	earned := fees.Sub(commission)
+
+	return RiderEarnings{
+		Deliveries:       deliveries,
+		DeliveryFees:     fees,
+		CommissionRate:   commissionPercent,
+		Commission:       commission,
+		DeliveryEarnings: earned,
+		Tips:             tips,
+		Total:            earned.Add(tips),
+	}
+}
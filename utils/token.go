@@ -20,15 +20,19 @@ type jwtCustomClaim struct {
 
 const (
 	StandardRole = "standard"
+	AdminRole    = "admin"
+	RiderRole    = "rider"
 )
 
 func NewJWTToken(config *Config) *JWTToken {
 	return &JWTToken{config: config}
 }
 
-func (j *JWTToken) CreateToken(userID string, isAdmin bool, ttl time.Duration) (string, error) {
+func (j *JWTToken) CreateToken(userID string, role string, ttl time.Duration) (string, error) {
 
-	role := StandardRole
+	if role == "" {
+		role = StandardRole
+	}
 	claims := jwtCustomClaim{
 		Id:        userID,
 		ExpiresAt: time.Now().Add(ttl * time.Minute).Unix(),
@@ -0,0 +1,400 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// trueType is the part of a TrueType font the PDF writer needs: glyph
+// lookup and widths for laying text out, and the outlines for embedding a
+// subset of it.
+type trueType struct {
+	tables     map[string][]byte
+	unitsPerEm int
+	bbox       [4]int
+	ascent     int
+	descent    int
+	capHeight  int
+	advances   []int
+	glyphs     map[rune]uint16
+	loca       []int
+}
+
+// subsetTables are the tables a PDF viewer needs from an embedded TrueType
+// font. Glyphs are found through CIDToGIDMap, so cmap is left out.
+var subsetTables = []string{"cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "prep"}
+
+var errTrueType = errors.New("truetype: malformed font")
+
+func parseTrueType(data []byte) (*trueType, error) {
+	if len(data) < 12 {
+		return nil, errTrueType
+	}
+
+	font := &trueType{tables: map[string][]byte{}}
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < numTables; i++ {
+		record := 12 + i*16
+		if record+16 > len(data) {
+			return nil, errTrueType
+		}
+		offset := int(binary.BigEndian.Uint32(data[record+8:]))
+		length := int(binary.BigEndian.Uint32(data[record+12:]))
+		if offset+length > len(data) {
+			return nil, errTrueType
+		}
+		font.tables[string(data[record:record+4])] = data[offset : offset+length]
+	}
+
+	for _, tag := range []string{"head", "hhea", "maxp", "hmtx", "loca", "glyf", "cmap"} {
+		if _, ok := font.tables[tag]; !ok {
+			return nil, fmt.Errorf("truetype: no %s table", tag)
+		}
+	}
+
+	head := font.tables["head"]
+	hhea := font.tables["hhea"]
+	maxp := font.tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return nil, errTrueType
+	}
+
+	font.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	for i := range font.bbox {
+		font.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+i*2:])))
+	}
+	font.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	font.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	font.capHeight = font.ascent
+	if os2 := font.tables["OS/2"]; len(os2) >= 90 && binary.BigEndian.Uint16(os2) >= 2 {
+		font.capHeight = int(int16(binary.BigEndian.Uint16(os2[88:])))
+	}
+	if font.unitsPerEm == 0 {
+		return nil, errTrueType
+	}
+
+	numGlyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+	numMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	hmtx := font.tables["hmtx"]
+	if numMetrics == 0 || numMetrics > numGlyphs || len(hmtx) < numMetrics*4 {
+		return nil, errTrueType
+	}
+	// Glyphs past the last metric share its advance.
+	font.advances = make([]int, numGlyphs)
+	for i := range font.advances {
+		metric := i
+		if metric >= numMetrics {
+			metric = numMetrics - 1
+		}
+		font.advances[i] = int(binary.BigEndian.Uint16(hmtx[metric*4:]))
+	}
+
+	loca := font.tables["loca"]
+	font.loca = make([]int, numGlyphs+1)
+	long := binary.BigEndian.Uint16(head[50:]) == 1
+	for i := range font.loca {
+		if long {
+			if len(loca) < (i+1)*4 {
+				return nil, errTrueType
+			}
+			font.loca[i] = int(binary.BigEndian.Uint32(loca[i*4:]))
+		} else {
+			if len(loca) < (i+1)*2 {
+				return nil, errTrueType
+			}
+			font.loca[i] = int(binary.BigEndian.Uint16(loca[i*2:])) * 2
+		}
+	}
+	if font.loca[numGlyphs] > len(font.tables["glyf"]) {
+		return nil, errTrueType
+	}
+
+	glyphs, err := parseCmap(font.tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+	font.glyphs = glyphs
+
+	return font, nil
+}
+
+// parseCmap reads the Unicode character map, preferring the full format 12
+// table over the Basic Multilingual Plane one.
+func parseCmap(cmap []byte) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, errTrueType
+	}
+
+	best, bestRank := -1, 0
+	for i := 0; i < int(binary.BigEndian.Uint16(cmap[2:])); i++ {
+		record := 4 + i*8
+		if record+8 > len(cmap) {
+			return nil, errTrueType
+		}
+		platform := binary.BigEndian.Uint16(cmap[record:])
+		encoding := binary.BigEndian.Uint16(cmap[record+2:])
+		offset := int(binary.BigEndian.Uint32(cmap[record+4:]))
+
+		rank := 0
+		switch {
+		case platform == 3 && encoding == 10:
+			rank = 3
+		case platform == 3 && encoding == 1:
+			rank = 2
+		case platform == 0:
+			rank = 1
+		}
+		if rank > bestRank && offset+4 <= len(cmap) {
+			best, bestRank = offset, rank
+		}
+	}
+	if best < 0 {
+		return nil, errors.New("truetype: no unicode cmap")
+	}
+
+	table := cmap[best:]
+	glyphs := map[rune]uint16{}
+	switch binary.BigEndian.Uint16(table) {
+	case 4:
+		if len(table) < 14 {
+			return nil, errTrueType
+		}
+		segments := int(binary.BigEndian.Uint16(table[6:])) / 2
+		ends := 14
+		starts := ends + segments*2 + 2
+		deltas := starts + segments*2
+		ranges := deltas + segments*2
+		if ranges+segments*2 > len(table) {
+			return nil, errTrueType
+		}
+
+		for i := 0; i < segments; i++ {
+			end := int(binary.BigEndian.Uint16(table[ends+i*2:]))
+			start := int(binary.BigEndian.Uint16(table[starts+i*2:]))
+			delta := int(binary.BigEndian.Uint16(table[deltas+i*2:]))
+			rangeOffset := int(binary.BigEndian.Uint16(table[ranges+i*2:]))
+
+			for c := start; c <= end && c != 0xFFFF; c++ {
+				glyph := 0
+				if rangeOffset == 0 {
+					glyph = (c + delta) & 0xFFFF
+				} else {
+					at := ranges + i*2 + rangeOffset + (c-start)*2
+					if at+2 > len(table) {
+						return nil, errTrueType
+					}
+					if glyph = int(binary.BigEndian.Uint16(table[at:])); glyph != 0 {
+						glyph = (glyph + delta) & 0xFFFF
+					}
+				}
+				if glyph != 0 {
+					glyphs[rune(c)] = uint16(glyph)
+				}
+			}
+		}
+	case 12:
+		if len(table) < 16 {
+			return nil, errTrueType
+		}
+		groups := int(binary.BigEndian.Uint32(table[12:]))
+		if 16+groups*12 > len(table) {
+			return nil, errTrueType
+		}
+
+		for i := 0; i < groups; i++ {
+			group := table[16+i*12:]
+			start := binary.BigEndian.Uint32(group)
+			end := binary.BigEndian.Uint32(group[4:])
+			glyph := binary.BigEndian.Uint32(group[8:])
+			for c := start; c <= end && c <= 0x10FFFF; c++ {
+				glyphs[rune(c)] = uint16(glyph + c - start)
+			}
+		}
+	default:
+		return nil, errors.New("truetype: unsupported cmap format")
+	}
+	return glyphs, nil
+}
+
+// glyph returns the outline of a glyph, empty for blank ones like space.
+func (f *trueType) glyph(id uint16) []byte {
+	if int(id)+1 >= len(f.loca) {
+		return nil
+	}
+	return f.tables["glyf"][f.loca[id]:f.loca[id+1]]
+}
+
+// components lists the glyphs a composite glyph is built from, such as the
+// base letter and the accent of "ọ".
+func (f *trueType) components(id uint16) []uint16 {
+	outline := f.glyph(id)
+	if len(outline) < 10 || int16(binary.BigEndian.Uint16(outline)) >= 0 {
+		return nil
+	}
+
+	const (
+		argsAreWords  = 0x0001
+		haveScale     = 0x0008
+		moreParts     = 0x0020
+		haveXYScale   = 0x0040
+		haveTwoByTwo  = 0x0080
+		componentHead = 4
+	)
+
+	parts := []uint16{}
+	at := 10
+	for at+componentHead <= len(outline) {
+		flags := binary.BigEndian.Uint16(outline[at:])
+		parts = append(parts, binary.BigEndian.Uint16(outline[at+2:]))
+		at += componentHead
+
+		if flags&argsAreWords != 0 {
+			at += 4
+		} else {
+			at += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			at += 2
+		case flags&haveXYScale != 0:
+			at += 4
+		case flags&haveTwoByTwo != 0:
+			at += 8
+		}
+		if flags&moreParts == 0 {
+			break
+		}
+	}
+	return parts
+}
+
+// width is a glyph's advance in thousandths of the font size, the unit PDF
+// uses for widths.
+func (f *trueType) width(id uint16) float64 {
+	if int(id) >= len(f.advances) {
+		return 0
+	}
+	return float64(f.advances[id]) * 1000 / float64(f.unitsPerEm)
+}
+
+// scale converts font units to thousandths of the font size.
+func (f *trueType) scale(units int) int {
+	return units * 1000 / f.unitsPerEm
+}
+
+// subset returns the font with the outlines of every glyph but those used
+// left empty. Glyph IDs do not change, so text can refer to glyphs by
+// their ID in the full font.
+func (f *trueType) subset(used map[uint16]rune) []byte {
+	keep := map[uint16]bool{0: true}
+	pending := []uint16{}
+	for id := range used {
+		pending = append(pending, id)
+	}
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if keep[id] {
+			continue
+		}
+		keep[id] = true
+		pending = append(pending, f.components(id)...)
+	}
+
+	glyf := &bytes.Buffer{}
+	loca := make([]byte, 0, len(f.loca)*4)
+	for id := 0; id < len(f.loca)-1; id++ {
+		loca = binary.BigEndian.AppendUint32(loca, uint32(glyf.Len()))
+		if keep[uint16(id)] {
+			glyf.Write(f.glyph(uint16(id)))
+			for glyf.Len()%4 != 0 {
+				glyf.WriteByte(0)
+			}
+		}
+	}
+	loca = binary.BigEndian.AppendUint32(loca, uint32(glyf.Len()))
+
+	// The new loca uses long offsets, and the whole-file checksum is
+	// worked out again below.
+	head := append([]byte{}, f.tables["head"]...)
+	binary.BigEndian.PutUint32(head[8:], 0)
+	binary.BigEndian.PutUint16(head[50:], 1)
+
+	tables := map[string][]byte{}
+	for _, tag := range subsetTables {
+		if table, ok := f.tables[tag]; ok {
+			tables[tag] = table
+		}
+	}
+	tables["glyf"] = glyf.Bytes()
+	tables["loca"] = loca
+	tables["head"] = head
+
+	out := writeTrueType(tables)
+	binary.BigEndian.PutUint32(out[tableOffset(out, "head")+8:], 0xB1B0AFBA-trueTypeChecksum(out))
+	return out
+}
+
+// writeTrueType lays tables out as a font file, each on a four byte
+// boundary as the format requires.
+func writeTrueType(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= len(tags) {
+		entrySelector++
+	}
+	searchRange := (1 << entrySelector) * 16
+
+	out := &bytes.Buffer{}
+	binary.Write(out, binary.BigEndian, uint32(0x00010000))
+	binary.Write(out, binary.BigEndian, uint16(len(tags)))
+	binary.Write(out, binary.BigEndian, uint16(searchRange))
+	binary.Write(out, binary.BigEndian, uint16(entrySelector))
+	binary.Write(out, binary.BigEndian, uint16(len(tags)*16-searchRange))
+
+	offset := 12 + len(tags)*16
+	for _, tag := range tags {
+		table := tables[tag]
+		out.WriteString(tag)
+		binary.Write(out, binary.BigEndian, trueTypeChecksum(table))
+		binary.Write(out, binary.BigEndian, uint32(offset))
+		binary.Write(out, binary.BigEndian, uint32(len(table)))
+		offset += (len(table) + 3) &^ 3
+	}
+	for _, tag := range tags {
+		out.Write(tables[tag])
+		for out.Len()%4 != 0 {
+			out.WriteByte(0)
+		}
+	}
+	return out.Bytes()
+}
+
+func tableOffset(font []byte, tag string) int {
+	numTables := int(binary.BigEndian.Uint16(font[4:]))
+	for i := 0; i < numTables; i++ {
+		record := 12 + i*16
+		if string(font[record:record+4]) == tag {
+			return int(binary.BigEndian.Uint32(font[record+8:]))
+		}
+	}
+	return -1
+}
+
+func trueTypeChecksum(data []byte) uint32 {
+	sum := uint32(0)
+	for i := 0; i < len(data); i += 4 {
+		word := [4]byte{}
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}